@@ -27,6 +27,7 @@ import (
 	"github.com/maksim-paskal/kubernetes-manager/pkg/cache"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/modules/autotests"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/web"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/webhook"
@@ -44,6 +45,14 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == portForwardCommand {
+		if err := runPortForward(os.Args[2:]); err != nil {
+			log.WithError(err).Fatal()
+		}
+
+		return
+	}
+
 	flag.Parse()
 
 	if *version {
@@ -160,6 +169,8 @@ func RunLeaderElection(ctx context.Context) {
 				ctx, span := telemetry.Start(ctx, "api.OnStartedLeading")
 				defer span.End()
 
+				go autotests.Schedule(ctx)
+
 				batch.Schedule(ctx)
 			},
 			OnStoppedLeading: func() {
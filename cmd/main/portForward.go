@@ -0,0 +1,81 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/portforward"
+	"github.com/pkg/errors"
+)
+
+const portForwardCommand = "port-forward"
+
+// opens local listener and forwards connections to service or pod in environment:
+// kubernetes-manager port-forward -server https://manager -environment cluster:namespace -service mysql -port 3306.
+func runPortForward(args []string) error {
+	flags := flag.NewFlagSet(portForwardCommand, flag.ExitOnError)
+
+	server := flags.String("server", os.Getenv("KUBERNETES_MANAGER_URL"), "kubernetes-manager URL")
+	environmentID := flags.String("environment", "", "environment ID (cluster:namespace)")
+	service := flags.String("service", "", "service name")
+	pod := flags.String("pod", "", "pod name")
+	port := flags.Int("port", 0, "service or pod port")
+	listen := flags.String("listen", "", "local address, default 127.0.0.1:<port>")
+	token := flags.String("token", os.Getenv("KUBERNETES_MANAGER_TOKEN"), "bearer token")
+	cookie := flags.String("cookie", os.Getenv("KUBERNETES_MANAGER_COOKIE"), "authentication cookie")
+
+	if err := flags.Parse(args); err != nil {
+		return errors.Wrap(err, "can not parse flags")
+	}
+
+	if len(*server) == 0 || len(*environmentID) == 0 || *port == 0 || (len(*service) == 0 && len(*pod) == 0) {
+		flags.Usage()
+
+		return errors.New("server, environment, service or pod and port must be specified")
+	}
+
+	if len(*listen) == 0 {
+		*listen = "127.0.0.1:" + strconv.Itoa(*port)
+	}
+
+	headers := http.Header{}
+
+	if len(*token) > 0 {
+		headers.Set("Authorization", "Bearer "+*token)
+	}
+
+	if len(*cookie) > 0 {
+		headers.Set("Cookie", *cookie)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	client := portforward.Client{
+		Server:        *server,
+		EnvironmentID: *environmentID,
+		Service:       *service,
+		Pod:           *pod,
+		Port:          *port,
+		Headers:       headers,
+	}
+
+	return client.ListenAndServe(ctx, *listen)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type ContainerActionResult struct {
+	Action string
+	// parsed output of action, string for text output
+	Output   any
+	Stdout   string
+	Stderr   string
+	ExecCode string
+}
+
+// returns actions that user can execute in container.
+func (e *Environment) GetContainerActions(ctx context.Context, container string) ([]*config.ContainerAction, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetContainerActions")
+	defer span.End()
+
+	containerInfo, err := e.GetContainerInfo(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	user := e.GetUser(ctx)
+	result := make([]*config.ContainerAction, 0)
+
+	for _, action := range config.Get().ContainerActions {
+		if !action.Match(containerInfo.ContainerImage, containerInfo.PodLabels) {
+			continue
+		}
+
+		if !e.isContainerActionAuthorized(action, user) {
+			continue
+		}
+
+		result = append(result, action)
+	}
+
+	return result, nil
+}
+
+// execute action in container, action must match container and user must have permission of action.
+func (e *Environment) RunContainerAction(ctx context.Context, container, name string, params map[string]string) (*ContainerActionResult, error) {
+	ctx, span := telemetry.Start(ctx, "api.RunContainerAction")
+	defer span.End()
+
+	action := config.Get().GetContainerAction(name)
+	if action == nil {
+		return nil, errors.Errorf("unknown container action %s", name)
+	}
+
+	user := e.GetUser(ctx)
+
+	if !e.isContainerActionAuthorized(action, user) {
+		return nil, errors.Errorf("user has no permission to container action %s", name)
+	}
+
+	containerInfo, err := e.GetContainerInfo(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	if !action.Match(containerInfo.ContainerImage, containerInfo.PodLabels) {
+		return nil, errors.Errorf("container action %s is not available in container %s", name, container)
+	}
+
+	command, err := action.GetCommand(params)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get command of action "+name)
+	}
+
+	log.WithFields(log.Fields{
+		"audit":       "container-action",
+		"user":        user,
+		"environment": e.ID,
+		"container":   container,
+		"action":      name,
+	}).Info("container action executed")
+
+	execResult, err := e.ExecContainer(ctx, container, command)
+	if err != nil {
+		return nil, err
+	}
+
+	return newContainerActionResult(action, execResult)
+}
+
+func (e *Environment) isContainerActionAuthorized(action *config.ContainerAction, user string) bool {
+	if len(action.Permission) == 0 {
+		return true
+	}
+
+	return config.Get().IsAuthorized(action.Permission, user, e.ID)
+}
+
+func newContainerActionResult(action *config.ContainerAction, execResult *ExecContainerResults) (*ContainerActionResult, error) {
+	result := ContainerActionResult{
+		Action:   action.Name,
+		Output:   execResult.Stdout,
+		Stdout:   execResult.Stdout,
+		Stderr:   execResult.Stderr,
+		ExecCode: execResult.ExecCode,
+	}
+
+	if action.Output == config.ContainerActionOutputJSON && len(execResult.ExecCode) == 0 {
+		var output any
+
+		if err := json.Unmarshal([]byte(execResult.Stdout), &output); err != nil {
+			return nil, errors.Wrap(err, "can not parse output of action "+action.Name)
+		}
+
+		result.Output = output
+	}
+
+	return &result, nil
+}
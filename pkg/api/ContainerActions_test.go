@@ -0,0 +1,60 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+)
+
+func TestNewContainerActionResult(t *testing.T) {
+	t.Parallel()
+
+	textAction := &config.ContainerAction{Name: "text"}
+	jsonAction := &config.ContainerAction{Name: "json", Output: config.ContainerActionOutputJSON}
+
+	result, err := api.NewContainerActionResult(textAction, &api.ExecContainerResults{Stdout: `{"a":1}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Output != `{"a":1}` {
+		t.Fatalf("text output must be returned as is, got %v", result.Output)
+	}
+
+	result, err = api.NewContainerActionResult(jsonAction, &api.ExecContainerResults{Stdout: `{"a":1}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, ok := result.Output.(map[string]any)
+	if !ok || output["a"] != float64(1) {
+		t.Fatalf("json output must be parsed, got %v", result.Output)
+	}
+
+	if _, err := api.NewContainerActionResult(jsonAction, &api.ExecContainerResults{Stdout: "not json"}); err == nil {
+		t.Fatal("invalid json must return error")
+	}
+
+	// output of failed command is not parsed
+	result, err = api.NewContainerActionResult(jsonAction, &api.ExecContainerResults{Stdout: "not json", ExecCode: "exit code 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.ExecCode != "exit code 1" {
+		t.Fatal("exec code must be returned")
+	}
+}
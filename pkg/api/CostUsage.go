@@ -0,0 +1,141 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const costMonthFormat = "2006-01"
+
+// running time of environment, saved in namespace annotation on scale events.
+type CostUsage struct {
+	// time when environment was scaled up, empty if environment is scaled down
+	RunningSince string
+	// running hours per month in 2006-01 format
+	RunningHours map[string]float64
+	// requested CPU cores and memory bytes of pods on last scale down
+	CPURequests    float64
+	MemoryRequests float64
+}
+
+// add running time to months of period, months are in UTC.
+func (u *CostUsage) AddRunningTime(from, to time.Time) {
+	if u.RunningHours == nil {
+		u.RunningHours = make(map[string]float64)
+	}
+
+	from = from.UTC()
+	to = to.UTC()
+
+	for from.Before(to) {
+		monthEnd := time.Date(from.Year(), from.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		if monthEnd.After(to) {
+			monthEnd = to
+		}
+
+		u.RunningHours[from.Format(costMonthFormat)] += monthEnd.Sub(from).Hours()
+
+		from = monthEnd
+	}
+}
+
+// returns running hours of month, running period is counted till now.
+func (u *CostUsage) GetRunningHours(month string, runningSince, now time.Time) float64 {
+	result := u.RunningHours[month]
+
+	if !runningSince.IsZero() {
+		current := CostUsage{}
+		current.AddRunningTime(runningSince, now)
+
+		result += current.RunningHours[month]
+	}
+
+	return result
+}
+
+func (e *Environment) getCostUsage() *CostUsage {
+	result := CostUsage{
+		RunningHours: make(map[string]float64),
+	}
+
+	usage, ok := e.NamespaceAnnotations[config.LabelCostUsage]
+	if !ok {
+		return &result
+	}
+
+	if err := json.Unmarshal([]byte(usage), &result); err != nil {
+		log.WithError(err).Warn("error parsing cost usage")
+	}
+
+	return &result
+}
+
+// time when running environment was scaled up, environments that were not scaled are running from creation.
+func (e *Environment) getRunningSince(usage *CostUsage) time.Time {
+	for _, value := range []string{usage.RunningSince, e.NamespaceLastScaled} {
+		if runningSince, err := utils.StringToTime(value); err == nil {
+			return runningSince
+		}
+	}
+
+	return time.Time{}
+}
+
+// accumulate running time of environment on scale event, podsInfo is used on scale down.
+func (e *Environment) saveCostUsage(ctx context.Context, replicas int32, podsInfo *PodsInfo) error {
+	ctx, span := telemetry.Start(ctx, "api.saveCostUsage")
+	defer span.End()
+
+	usage := e.getCostUsage()
+	now := time.Now()
+
+	if replicas > 0 {
+		// environment is already running
+		if len(usage.RunningSince) > 0 {
+			return nil
+		}
+
+		usage.RunningSince = utils.TimeToString(now)
+	} else {
+		if runningSince := e.getRunningSince(usage); !runningSince.IsZero() {
+			usage.AddRunningTime(runningSince, now)
+		}
+
+		usage.RunningSince = ""
+
+		if podsInfo != nil {
+			usage.CPURequests = podsInfo.cpuRequests
+			usage.MemoryRequests = podsInfo.memoryRequests
+		}
+	}
+
+	usageJSON, err := json.Marshal(usage)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling cost usage")
+	}
+
+	annotations := map[string]string{
+		config.LabelCostUsage: string(usageJSON),
+	}
+
+	return e.SaveNamespaceMeta(ctx, annotations, e.NamespaceLabels)
+}
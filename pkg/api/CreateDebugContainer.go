@@ -0,0 +1,186 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	debugContainerPrefix       = "debugger-"
+	debugContainerNameLength   = 5
+	debugContainerPollInterval = 2 * time.Second
+)
+
+type DebugContainerState string
+
+const (
+	DebugContainerStatePending    DebugContainerState = "pending"
+	DebugContainerStateWaiting    DebugContainerState = "waiting"
+	DebugContainerStateRunning    DebugContainerState = "running"
+	DebugContainerStateTerminated DebugContainerState = "terminated"
+)
+
+type DebugContainerStatus struct {
+	Pod             string
+	Name            string
+	Image           string
+	TargetContainer string
+	State           DebugContainerState
+	Message         string
+	// container that can be used in terminal <pod>:<container>
+	Container string
+}
+
+// returns statuses of ephemeral containers in pod.
+func (e *Environment) GetDebugContainers(ctx context.Context, pod string) ([]*DebugContainerStatus, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetDebugContainers")
+	defer span.End()
+
+	podInfo, err := e.clientset.CoreV1().Pods(e.Namespace).Get(ctx, pod, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get pod")
+	}
+
+	result := make([]*DebugContainerStatus, 0)
+
+	for _, container := range podInfo.Spec.EphemeralContainers {
+		result = append(result, newDebugContainerStatus(podInfo, container.Name))
+	}
+
+	return result, nil
+}
+
+// attach ephemeral container to pod with process namespace of target container,
+// status is returned after container started or start timeout.
+func (e *Environment) CreateDebugContainer(ctx context.Context, container, image string) (*DebugContainerStatus, error) {
+	ctx, span := telemetry.Start(ctx, "api.CreateDebugContainer")
+	defer span.End()
+
+	user := e.GetUser(ctx)
+
+	if !config.Get().IsAuthorized(config.PermissionDebugContainer, user, e.ID) {
+		return nil, errors.New("user has no permission to debug container")
+	}
+
+	if !slices.Contains(config.Get().DebugContainer.Images, image) {
+		return nil, errors.Errorf("image %s is not allowed", image)
+	}
+
+	containerInfo, err := types.NewContainerInfo(container)
+	if err != nil {
+		return nil, err
+	}
+
+	pod, err := e.clientset.CoreV1().Pods(e.Namespace).Get(ctx, containerInfo.PodName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get pod")
+	}
+
+	name := debugContainerPrefix + utils.RandomString(debugContainerNameLength)
+
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    image,
+			ImagePullPolicy:          corev1.PullIfNotPresent,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		TargetContainerName: containerInfo.ContainerName,
+	})
+
+	_, err = e.clientset.CoreV1().Pods(e.Namespace).UpdateEphemeralContainers(ctx, pod.Name, pod, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "can not create debug container")
+	}
+
+	log.WithFields(log.Fields{
+		"audit":       config.PermissionDebugContainer,
+		"user":        user,
+		"environment": e.ID,
+		"container":   container,
+		"image":       image,
+		"name":        name,
+	}).Info("debug container created")
+
+	var status *DebugContainerStatus
+
+	timeout := time.Duration(config.Get().DebugContainer.StartTimeoutSeconds) * time.Second
+
+	err = wait.PollUntilContextTimeout(ctx, debugContainerPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		pod, err := e.clientset.CoreV1().Pods(e.Namespace).Get(ctx, containerInfo.PodName, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrap(err, "can not get pod")
+		}
+
+		status = newDebugContainerStatus(pod, name)
+
+		return status.State == DebugContainerStateRunning || status.State == DebugContainerStateTerminated, nil
+	})
+	// container status is returned after timeout
+	if err != nil && status == nil {
+		return nil, errors.Wrap(err, "can not get debug container status")
+	}
+
+	return status, nil
+}
+
+func newDebugContainerStatus(pod *corev1.Pod, name string) *DebugContainerStatus {
+	result := DebugContainerStatus{
+		Pod:       pod.Name,
+		Name:      name,
+		State:     DebugContainerStatePending,
+		Container: pod.Name + ":" + name,
+	}
+
+	for _, container := range pod.Spec.EphemeralContainers {
+		if container.Name == name {
+			result.Image = container.Image
+			result.TargetContainer = container.TargetContainerName
+		}
+	}
+
+	for _, status := range pod.Status.EphemeralContainerStatuses {
+		if status.Name != name {
+			continue
+		}
+
+		switch {
+		case status.State.Running != nil:
+			result.State = DebugContainerStateRunning
+		case status.State.Terminated != nil:
+			result.State = DebugContainerStateTerminated
+			result.Message = strings.TrimSpace(status.State.Terminated.Reason + " " + status.State.Terminated.Message)
+		case status.State.Waiting != nil:
+			result.State = DebugContainerStateWaiting
+			result.Message = strings.TrimSpace(status.State.Waiting.Reason + " " + status.State.Waiting.Message)
+		}
+	}
+
+	return &result
+}
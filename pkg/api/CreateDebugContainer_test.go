@@ -0,0 +1,63 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewDebugContainerStatus(t *testing.T) {
+	t.Parallel()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+		Spec: corev1.PodSpec{
+			EphemeralContainers: []corev1.EphemeralContainer{
+				{
+					EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger-1", Image: "busybox:latest"},
+					TargetContainerName:      "app",
+				},
+				{
+					EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger-2", Image: "busybox:latest"},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			EphemeralContainerStatuses: []corev1.ContainerStatus{{
+				Name: "debugger-1",
+				State: corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull"},
+				},
+			}},
+		},
+	}
+
+	status := api.NewDebugContainerStatus(pod, "debugger-1")
+
+	if status.State != api.DebugContainerStateWaiting || status.Message != "ErrImagePull" {
+		t.Fatalf("wrong status %s: %s", status.State, status.Message)
+	}
+
+	if status.TargetContainer != "app" || status.Container != "pod:debugger-1" {
+		t.Fatalf("wrong container %s, %s", status.TargetContainer, status.Container)
+	}
+
+	// container without status
+	if status := api.NewDebugContainerStatus(pod, "debugger-2"); status.State != api.DebugContainerStatePending {
+		t.Fatalf("wrong status %s", status.State)
+	}
+}
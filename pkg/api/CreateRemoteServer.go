@@ -0,0 +1,157 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const remoteServerNameRandLength = 6
+
+var errRemoteServerLifecycleNotSupported = errors.New("provider can not create and delete servers")
+
+type CreateRemoteServerInput struct {
+	Template string
+}
+
+type remoteServerUserData struct {
+	Name     string
+	Owner    string
+	Template string
+}
+
+type RemoteServerTemplateItem struct {
+	Name        string
+	Description string
+	Image       string
+	ServerType  string
+	Location    string
+}
+
+// returns templates without user data, it can contain secrets.
+func GetRemoteServerTemplates() []*RemoteServerTemplateItem {
+	result := make([]*RemoteServerTemplateItem, 0, len(config.Get().RemoteServer.Templates))
+
+	for _, template := range config.Get().RemoteServer.Templates {
+		result = append(result, &RemoteServerTemplateItem{
+			Name:        template.Name,
+			Description: template.Description,
+			Image:       template.Image,
+			ServerType:  template.ServerType,
+			Location:    template.Location,
+		})
+	}
+
+	return result
+}
+
+// returns lifecycle provider by name.
+func getRemoteServerLifecycleProvider(cloud string) (remoteserver.LifecycleProvider, error) {
+	provider, err := client.GetRemoteServerProvider(cloud)
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycleProvider, ok := provider.(remoteserver.LifecycleProvider)
+	if !ok {
+		return nil, errors.Wrap(errRemoteServerLifecycleNotSupported, cloud)
+	}
+
+	return lifecycleProvider, nil
+}
+
+// create server from template, user from context is owner of server.
+func CreateRemoteServer(ctx context.Context, input CreateRemoteServerInput) (*GetRemoteServerItem, error) {
+	ctx, span := telemetry.Start(ctx, "api.CreateRemoteServer")
+	defer span.End()
+
+	security, ok := ctx.Value(types.ContextSecurityKey).(types.ContextSecurity)
+	if !ok || len(security.Owner) == 0 {
+		return nil, errors.New("user is empty")
+	}
+
+	template := config.Get().RemoteServer.GetTemplate(input.Template)
+	if template == nil {
+		return nil, errors.Errorf("template %s not found", input.Template)
+	}
+
+	if !config.Get().IsAuthorized(config.PermissionRemoteServerCreate, security.Owner, template.Name) {
+		return nil, errors.New("user has no permission to create remote server")
+	}
+
+	provider, err := getRemoteServerLifecycleProvider(template.GetProvider())
+	if err != nil {
+		return nil, err
+	}
+
+	userData := remoteServerUserData{
+		Name:     fmt.Sprintf("%s-%s", template.Name, utils.RandomString(remoteServerNameRandLength)),
+		Owner:    security.Owner,
+		Template: template.Name,
+	}
+
+	cloudInit, err := utils.GetTemplatedResult(ctx, template.UserData, userData)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing user data")
+	}
+
+	labels := make(map[string]string)
+
+	maps.Copy(labels, template.Labels)
+
+	labels[ownerLabel] = remoteserver.LabelValue(security.Owner)
+	labels[templateLabel] = remoteserver.LabelValue(template.Name)
+	labels[lastPowerOnTimeLabel] = utils.TimeToUnix(time.Now())
+	labels[config.LabelScaleDownDelayShort] = config.Get().GetScaleDownDelay().TimeToUnix()
+
+	server, err := provider.CreateServer(ctx, &remoteserver.CreateServerInput{
+		Name:       userData.Name,
+		Image:      template.Image,
+		ServerType: template.ServerType,
+		Location:   template.Location,
+		SSHKeys:    template.SSHKeys,
+		UserData:   string(cloudInit),
+		Labels:     labels,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating server")
+	}
+
+	item := newRemoteServerItem(ctx, template.GetProvider(), server)
+
+	log.WithFields(log.Fields{
+		"server":   server.Name,
+		"template": template.Name,
+		"owner":    security.Owner,
+	}).Info("remote server created")
+
+	eventMessage := item.NewWebhookMessage(types.EventRemoteServerCreated)
+	eventMessage.Reason = "Remote server created"
+	eventMessage.Properties["template"] = template.Name
+
+	sendWebhookEvent(ctx, eventMessage)
+
+	return item, nil
+}
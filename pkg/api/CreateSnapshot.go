@@ -0,0 +1,49 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/pkg/errors"
+)
+
+func (e *Environment) CreateSnapshot(ctx context.Context) (string, error) {
+	ctx, span := telemetry.Start(ctx, "api.CreateSnapshot")
+	defer span.End()
+
+	snapshots := config.Get().Snapshots
+
+	pipelineURL, err := e.CreateGitlabPipeline(ctx, &CreateGitlabPipelineInput{
+		ProjectID: snapshots.ProjectID,
+		Ref:       snapshots.Ref,
+		Operation: GitlabPipelineOperationSnapshot,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error creating pipeline")
+	}
+
+	eventMessage := e.NewWebhookMessage(types.EventSnapshotCreated)
+	eventMessage.Reason = "Snapshot created ..."
+	eventMessage.Properties["slackEmoji"] = ":camera:"
+	eventMessage.Properties["projectID"] = snapshots.ProjectID
+	eventMessage.Properties["ref"] = snapshots.Ref
+	eventMessage.Properties["pipeline"] = pipelineURL
+
+	e.SendWebhookEvent(ctx, eventMessage)
+
+	return pipelineURL, nil
+}
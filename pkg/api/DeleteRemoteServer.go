@@ -0,0 +1,124 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type DeleteRemoteServerInput struct {
+	Cloud string
+	ID    string
+}
+
+// delete server of user, snapshot of server is created before delete.
+// snapshot takes minutes, server is deleted in background and result is sent to webhooks.
+func DeleteRemoteServer(ctx context.Context, input DeleteRemoteServerInput) error {
+	ctx, span := telemetry.Start(ctx, "api.DeleteRemoteServer")
+	defer span.End()
+
+	security, ok := ctx.Value(types.ContextSecurityKey).(types.ContextSecurity)
+	if !ok || len(security.Owner) == 0 {
+		return errors.New("user is empty")
+	}
+
+	server, err := GetRemoteServer(ctx, input.Cloud, input.ID)
+	if err != nil {
+		return err
+	}
+
+	if server.GetOwner() != remoteserver.LabelValue(security.Owner) &&
+		!config.Get().IsAuthorized(config.PermissionRemoteServerDelete, security.Owner, "") {
+		return errors.Errorf("server is owned by %s", server.GetOwner())
+	}
+
+	if _, err := getRemoteServerLifecycleProvider(server.Cloud); err != nil {
+		return err
+	}
+
+	go func(ctx context.Context) {
+		if _, err := deleteRemoteServer(ctx, server, "deleted by "+security.Owner); err != nil {
+			log.WithError(err).Errorf("error deleting remote server %s", server.ServerName)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+// create snapshot and delete server, returns snapshot ID.
+// result of delete is sent to webhooks with remote-server-deleted event.
+func deleteRemoteServer(ctx context.Context, server *GetRemoteServerItem, reason string) (string, error) {
+	snapshotID, err := deleteRemoteServerWithSnapshot(ctx, server)
+	if err != nil {
+		eventMessage := server.NewWebhookMessage(types.EventRemoteServerDeleted)
+		eventMessage.Reason = "Remote server was not deleted"
+		eventMessage.Properties["snapshot"] = snapshotID
+		eventMessage.Properties["error"] = err.Error()
+
+		sendWebhookEvent(ctx, eventMessage)
+
+		return snapshotID, err
+	}
+
+	log.WithFields(log.Fields{
+		"server":   server.ServerName,
+		"owner":    server.GetOwner(),
+		"snapshot": snapshotID,
+	}).Infof("remote server %s", reason)
+
+	eventMessage := server.NewWebhookMessage(types.EventRemoteServerDeleted)
+	eventMessage.Reason = "Remote server " + reason
+	eventMessage.Properties["snapshot"] = snapshotID
+
+	sendWebhookEvent(ctx, eventMessage)
+
+	return snapshotID, nil
+}
+
+func deleteRemoteServerWithSnapshot(ctx context.Context, server *GetRemoteServerItem) (string, error) {
+	ctx, span := telemetry.Start(ctx, "api.deleteRemoteServerWithSnapshot")
+	defer span.End()
+
+	provider, err := getRemoteServerLifecycleProvider(server.Cloud)
+	if err != nil {
+		return "", err
+	}
+
+	snapshotLabels := map[string]string{
+		ownerLabel: server.GetOwner(),
+		"server":   remoteserver.LabelValue(server.ServerName),
+	}
+
+	description := fmt.Sprintf("%s %s", server.ServerName, utils.TimeToString(time.Now()))
+
+	snapshotID, err := provider.CreateSnapshot(ctx, server.ID, description, snapshotLabels)
+	if err != nil {
+		return "", errors.Wrap(err, "error creating snapshot")
+	}
+
+	if err := provider.DeleteServer(ctx, server.ID); err != nil {
+		return snapshotID, errors.Wrap(err, "error deleting server")
+	}
+
+	return snapshotID, nil
+}
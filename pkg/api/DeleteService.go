@@ -0,0 +1,62 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// pipeline will be created with this environment variable:
+// DELETE=true
+// NAMESPACE=<environment.Namespace>
+// CLUSTER=<environment.Cluster>
+//
+// pipeline if succeeded, must delete namespace annotation:
+// kubectl annotate namespace $NAMESPACE kubernetes-manager/project-${CI_PROJECT_ID}-
+//
+// tagfork branches of service will be deleted after annotation is removed.
+func (e *Environment) DeleteService(ctx context.Context, projectID, ref string) (string, error) {
+	ctx, span := telemetry.Start(ctx, "api.DeleteService")
+	defer span.End()
+
+	pipelineURL, err := e.CreateGitlabPipeline(ctx, &CreateGitlabPipelineInput{
+		ProjectID: projectID,
+		Ref:       ref,
+		Operation: GitlabPipelineOperationDelete,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error creating pipeline")
+	}
+
+	eventMessage := e.NewWebhookMessage(types.EventServiceDeleted)
+	eventMessage.Reason = "Service deleted ..."
+	eventMessage.Properties["slackEmoji"] = ":x:"
+	eventMessage.Properties["projectID"] = projectID
+	eventMessage.Properties["ref"] = ref
+	eventMessage.Properties["pipeline"] = pipelineURL
+
+	if project, err := GetCachedProject(ctx, projectID); err != nil {
+		log.WithError(err).Warn("error getting project")
+	} else {
+		eventMessage.Properties["project"] = project.Path
+	}
+
+	e.SendWebhookEvent(ctx, eventMessage)
+
+	return pipelineURL, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// owners of staled servers are warned, servers are deleted when owner
+// did not power on server in DeleteStaledAfterDays after warning.
+func DeleteStaledRemoteServers(ctx context.Context) error {
+	ctx, span := telemetry.Start(ctx, "api.DeleteStaledRemoteServers")
+	defer span.End()
+
+	deleteAfterDays := config.Get().RemoteServer.DeleteStaledAfterDays
+	if deleteAfterDays <= 0 {
+		return nil
+	}
+
+	servers, err := GetRemoteServers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error listing servers")
+	}
+
+	deleteAfter := time.Duration(deleteAfterDays) * config.HoursInDay * time.Hour
+
+	for _, server := range servers {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !server.IsStaled() {
+			continue
+		}
+
+		// only servers of providers that can delete servers
+		if _, err := getRemoteServerLifecycleProvider(server.Cloud); err != nil {
+			continue
+		}
+
+		log := log.WithField("server", server.ServerName)
+
+		warningTime, warned := server.GetStaledWarningTime()
+
+		if !warned {
+			if err := warnStaledRemoteServer(ctx, server, time.Now().Add(deleteAfter)); err != nil {
+				log.WithError(err).Error("error warning owner of staled server")
+			}
+
+			continue
+		}
+
+		if time.Since(warningTime) < deleteAfter {
+			continue
+		}
+
+		if _, err := deleteRemoteServer(ctx, server, "deleted because it was not used"); err != nil {
+			log.WithError(err).Error("error deleting staled server")
+		}
+	}
+
+	return nil
+}
+
+func warnStaledRemoteServer(ctx context.Context, server *GetRemoteServerItem, deleteTime time.Time) error {
+	labels := map[string]string{
+		staledWarningTimeLabel: utils.TimeToUnix(time.Now()),
+	}
+
+	if err := SetRemoteServerLabels(ctx, server.Cloud, server.ID, labels); err != nil {
+		return errors.Wrap(err, "error set labels")
+	}
+
+	eventMessage := server.NewWebhookMessage(types.EventRemoteServerStaled)
+	eventMessage.Reason = fmt.Sprintf("Remote server was not used and will be deleted after %s, start server to keep it",
+		utils.TimeToString(deleteTime),
+	)
+	eventMessage.Properties["slackEmoji"] = ":warning:"
+
+	sendWebhookEvent(ctx, eventMessage)
+
+	return nil
+}
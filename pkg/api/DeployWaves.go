@@ -0,0 +1,333 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errDeployWavesCycle = errors.New("services dependencies has cycle")
+	errDeployRunning    = errors.New("deploy is already running, wait until it finished")
+)
+
+type DeployStatusValue string
+
+const (
+	DeployStatusRunning DeployStatusValue = "running"
+	DeployStatusSuccess DeployStatusValue = "success"
+	DeployStatusFailed  DeployStatusValue = "failed"
+)
+
+type DeployWavePipeline struct {
+	ProjectID   string
+	Ref         string
+	PipelineID  string
+	PipelineURL string
+	Status      scm.PipelineStatus
+	Error       string
+}
+
+// status of services deploy, services are deployed in waves by profile dependencies.
+type DeployStatus struct {
+	Operation GitlabPipelineOperation
+	Status    DeployStatusValue
+	Wave      int
+	Waves     [][]*DeployWavePipeline
+	Created   string
+	Updated   string
+	Error     string
+}
+
+// returns status of last services deploy, nil if environment was deployed without waves.
+func (e *Environment) GetDeployStatus() *DeployStatus {
+	value, ok := e.NamespaceAnnotations[config.LabelDeployStatus]
+	if !ok || len(value) == 0 {
+		return nil
+	}
+
+	result := DeployStatus{}
+
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		log.WithError(err).Warn("error parsing deploy status")
+
+		return nil
+	}
+
+	return &result
+}
+
+// waves are created by one process, deploy is staled if process was stopped before deploy finished.
+func (s *DeployStatus) IsStaled(now time.Time) bool {
+	if s.Status != DeployStatusRunning {
+		return false
+	}
+
+	updated, err := utils.StringToTime(s.Updated)
+	if err != nil {
+		return true
+	}
+
+	return now.Sub(updated) > time.Duration(config.Get().DeployWaves.TimeoutMinutes)*time.Minute
+}
+
+// returns true if deploy in waves is running now.
+func (e *Environment) IsDeployRunning(now time.Time) bool {
+	status := e.GetDeployStatus()
+
+	return status != nil && status.Status == DeployStatusRunning && !status.IsStaled(now)
+}
+
+// mark staled deploy as failed, next waves of deploy will not be created.
+func (e *Environment) FailStaledDeploy(ctx context.Context) error {
+	ctx, span := telemetry.Start(ctx, "api.FailStaledDeploy")
+	defer span.End()
+
+	status := e.GetDeployStatus()
+	if status == nil || !status.IsStaled(time.Now()) {
+		return nil
+	}
+
+	status.Status = DeployStatusFailed
+	status.Error = "deploy was interrupted"
+
+	return e.saveDeployStatus(ctx, status)
+}
+
+func (e *Environment) saveDeployStatus(ctx context.Context, status *DeployStatus) error {
+	status.Updated = utils.TimeToString(time.Now())
+
+	statusJSON, err := json.Marshal(status)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling deploy status")
+	}
+
+	annotations := map[string]string{
+		config.LabelDeployStatus: string(statusJSON),
+	}
+
+	return e.SaveNamespaceMeta(ctx, annotations, e.NamespaceLabels)
+}
+
+// split services to waves, services in wave are deployed after all services in previous waves,
+// dependencies on projects that are not in services are ignored.
+func GetDeployWaves(services []*EnvironmentServices, profile *config.ProjectProfile) ([][]*EnvironmentServices, error) {
+	if profile == nil || len(profile.Dependencies) == 0 {
+		return [][]*EnvironmentServices{services}, nil
+	}
+
+	selected := make(map[string]bool, len(services))
+
+	for _, service := range services {
+		selected[service.GeProjectID()] = true
+	}
+
+	deployed := make(map[string]bool, len(services))
+	result := make([][]*EnvironmentServices, 0)
+
+	for len(deployed) < len(services) {
+		wave := make([]*EnvironmentServices, 0)
+
+		for _, service := range services {
+			if deployed[service.GeProjectID()] {
+				continue
+			}
+
+			dependencies := profile.GetProjectDependencies(int64(service.ProjectID))
+
+			ready := !slices.ContainsFunc(dependencies, func(dependency string) bool {
+				return selected[dependency] && !deployed[dependency]
+			})
+
+			if ready {
+				wave = append(wave, service)
+			}
+		}
+
+		if len(wave) == 0 {
+			return nil, errDeployWavesCycle
+		}
+
+		for _, service := range wave {
+			deployed[service.GeProjectID()] = true
+		}
+
+		result = append(result, wave)
+	}
+
+	return result, nil
+}
+
+// create pipelines of all services in wave concurrently.
+func (e *Environment) createWavePipelines(ctx context.Context, services []*EnvironmentServices, op GitlabPipelineOperation) []*DeployWavePipeline {
+	ctx, span := telemetry.Start(ctx, "api.createWavePipelines")
+	defer span.End()
+
+	result := make([]*DeployWavePipeline, len(services))
+
+	var wg sync.WaitGroup
+
+	wg.Add(len(services))
+
+	for i, service := range services {
+		go func() {
+			defer wg.Done()
+
+			item := &DeployWavePipeline{
+				ProjectID: service.GeProjectID(),
+				Ref:       service.Ref,
+			}
+
+			pipeline, err := e.createGitlabPipeline(ctx, &CreateGitlabPipelineInput{
+				ProjectID: item.ProjectID,
+				Ref:       item.Ref,
+				Operation: op,
+			})
+			if err != nil {
+				item.Error = err.Error()
+				item.Status = scm.PipelineStatusFailed
+			} else {
+				item.PipelineID = pipeline.ID
+				item.PipelineURL = pipeline.WebURL
+				item.Status = pipeline.Status
+			}
+
+			result[i] = item
+		}()
+	}
+
+	wg.Wait()
+
+	return result
+}
+
+// wait for pipelines of current wave and create pipelines of next waves,
+// deploy will be stopped on first failed pipeline.
+func (e *Environment) runDeployWaves(ctx context.Context, status *DeployStatus, waves [][]*EnvironmentServices) {
+	ctx, span := telemetry.Start(ctx, "api.runDeployWaves")
+	defer span.End()
+
+	log := log.WithField("namespace", e.Namespace)
+
+	deployWaves := config.Get().DeployWaves
+
+	// status must be saved after timeout
+	waitCtx, cancel := context.WithTimeout(ctx, time.Duration(deployWaves.TimeoutMinutes)*time.Minute)
+	defer cancel()
+
+	pollInterval := time.Duration(deployWaves.PollIntervalSeconds) * time.Second
+
+	for {
+		if err := e.waitDeployWave(waitCtx, status.Waves[status.Wave], pollInterval); err != nil {
+			status.Status = DeployStatusFailed
+			status.Error = err.Error()
+
+			break
+		}
+
+		if status.Wave == len(waves)-1 {
+			status.Status = DeployStatusSuccess
+
+			break
+		}
+
+		status.Wave++
+		status.Waves[status.Wave] = e.createWavePipelines(waitCtx, waves[status.Wave], status.Operation)
+
+		if err := e.saveDeployStatus(ctx, status); err != nil {
+			log.WithError(err).Error("error saving deploy status")
+		}
+	}
+
+	if err := e.saveDeployStatus(ctx, status); err != nil {
+		log.WithError(err).Error("error saving deploy status")
+	}
+
+	pipelineURLs := make([]string, 0)
+
+	for _, wave := range status.Waves {
+		for _, pipeline := range wave {
+			if len(pipeline.PipelineURL) > 0 {
+				pipelineURLs = append(pipelineURLs, pipeline.PipelineURL)
+			}
+		}
+	}
+
+	eventMessage := e.NewWebhookMessage(types.EventDeployFinished)
+	eventMessage.Reason = "Deploy " + string(status.Status) + " ..."
+	eventMessage.Properties["slackEmoji"] = ":rocket:"
+	eventMessage.Properties["operation"] = string(status.Operation)
+	eventMessage.Properties["status"] = string(status.Status)
+	eventMessage.Properties["pipelines"] = strings.Join(pipelineURLs, "\n")
+	eventMessage.Properties["error"] = status.Error
+
+	e.SendWebhookEvent(ctx, eventMessage)
+}
+
+// wait until all pipelines in wave finished, returns error if some pipeline was not succeeded.
+func (e *Environment) waitDeployWave(ctx context.Context, wave []*DeployWavePipeline, pollInterval time.Duration) error {
+	ctx, span := telemetry.Start(ctx, "api.waitDeployWave")
+	defer span.End()
+
+	for {
+		finished := true
+
+		for _, item := range wave {
+			if len(item.Error) > 0 {
+				return errors.Errorf("project %s: %s", item.ProjectID, item.Error)
+			}
+
+			if !item.Status.IsFinished() {
+				pipeline, err := e.scmProvider.GetPipeline(ctx, item.ProjectID, item.PipelineID)
+				if err != nil {
+					log.WithError(err).Warnf("can not get pipeline %s", item.PipelineURL)
+				} else {
+					item.Status = pipeline.Status
+				}
+			}
+
+			if !item.Status.IsFinished() {
+				finished = false
+
+				continue
+			}
+
+			if item.Status != scm.PipelineStatusSuccess {
+				return errors.Errorf("project %s pipeline %s: %s", item.ProjectID, item.Status, item.PipelineURL)
+			}
+		}
+
+		if finished {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "deploy timeout")
+		case <-time.After(pollInterval):
+		}
+	}
+}
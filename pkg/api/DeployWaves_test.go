@@ -0,0 +1,109 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+)
+
+func TestGetDeployWaves(t *testing.T) {
+	t.Parallel()
+
+	services, err := api.ParseEnvironmentServices("1:main;2:main;3:main;4:main", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without dependencies all services in one wave
+	waves, err := api.GetDeployWaves(services, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(waves) != 1 || len(waves[0]) != 4 {
+		t.Fatalf("unexpected waves %+v", waves)
+	}
+
+	// 2 and 3 after 1, 4 after 3, dependency on not selected project 5 is ignored
+	profile := &config.ProjectProfile{
+		NamespaceNameType: config.ProjectProfileNameTypeSimple,
+		Dependencies:      "2=1,3=1,4=3,4=5",
+	}
+
+	if err := profile.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	waves, err = api.GetDeployWaves(services, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]int{{1}, {2, 3}, {4}}
+
+	if len(waves) != len(want) {
+		t.Fatalf("unexpected waves count %d", len(waves))
+	}
+
+	for i, wave := range waves {
+		if len(wave) != len(want[i]) {
+			t.Fatalf("(wave %d) unexpected services %+v", i, wave)
+		}
+
+		for j, service := range wave {
+			if service.ProjectID != want[i][j] {
+				t.Fatalf("(wave %d) got project %d, want %d", i, service.ProjectID, want[i][j])
+			}
+		}
+	}
+
+	profile.Dependencies = "1=2,2=1"
+
+	if _, err := api.GetDeployWaves(services, profile); err == nil {
+		t.Fatal("must be error for cycle")
+	}
+
+	profile.Dependencies = "1=a"
+
+	if err := profile.Validate(); err == nil {
+		t.Fatal("must be invalid dependencies")
+	}
+}
+
+func TestDeployStatusIsStaled(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	timeout := time.Duration(config.Get().DeployWaves.TimeoutMinutes) * time.Minute
+
+	tests := []struct {
+		status *api.DeployStatus
+		staled bool
+	}{
+		{status: &api.DeployStatus{Status: api.DeployStatusRunning, Updated: utils.TimeToString(now)}, staled: false},
+		{status: &api.DeployStatus{Status: api.DeployStatusRunning, Updated: utils.TimeToString(now.Add(-timeout - time.Minute))}, staled: true},
+		{status: &api.DeployStatus{Status: api.DeployStatusRunning}, staled: true},
+		{status: &api.DeployStatus{Status: api.DeployStatusFailed, Updated: utils.TimeToString(now.Add(-timeout - time.Minute))}, staled: false},
+	}
+
+	for _, test := range tests {
+		if staled := test.status.IsStaled(now); staled != test.staled {
+			t.Errorf("status %+v, got %v, want %v", test.status, staled, test.staled)
+		}
+	}
+}
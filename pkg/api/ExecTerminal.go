@@ -0,0 +1,242 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	terminalSessionIDLength    = 16
+	terminalIdleCheckInterval  = 10 * time.Second
+	terminalReasonFinished     = "finished"
+	terminalReasonDisconnected = "disconnected"
+)
+
+var (
+	errTerminalIdle        = errors.New("idle timeout")
+	errTerminalMaxDuration = errors.New("max duration")
+	errTerminalForbidden   = errors.New("user has no permission to terminal")
+)
+
+type TerminalSize struct {
+	Cols uint16
+	Rows uint16
+}
+
+type ExecTerminalInput struct {
+	SessionID string
+	// must contains <pod>:<container>
+	Container string
+	Stdin     io.Reader
+	Stdout    io.Writer
+	// terminal size changes
+	Resize <-chan TerminalSize
+}
+
+func NewTerminalSessionID() string {
+	return utils.RandomString(terminalSessionIDLength)
+}
+
+type terminalSizeQueue struct {
+	ctx    context.Context //nolint:containedctx
+	resize <-chan TerminalSize
+}
+
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	select {
+	case <-q.ctx.Done():
+		return nil
+	case size, ok := <-q.resize:
+		if !ok {
+			return nil
+		}
+
+		return &remotecommand.TerminalSize{Width: size.Cols, Height: size.Rows}
+	}
+}
+
+// stdin that remembers time of last user input.
+type terminalStdin struct {
+	r        io.Reader
+	lastRead atomic.Int64
+}
+
+func (t *terminalStdin) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.lastRead.Store(time.Now().UnixNano())
+	}
+
+	return n, err //nolint:wrapcheck
+}
+
+func (t *terminalStdin) idle() time.Duration {
+	return time.Since(time.Unix(0, t.lastRead.Load()))
+}
+
+// interactive shell in container, session is closed on idle timeout or max duration,
+// every session is recorded to audit log and sent to webhooks.
+func (e *Environment) ExecTerminal(ctx context.Context, input *ExecTerminalInput) error {
+	ctx, span := telemetry.Start(ctx, "api.ExecTerminal")
+	defer span.End()
+
+	user := e.GetUser(ctx)
+
+	if !config.Get().IsAuthorized(config.PermissionTerminal, user, e.ID) {
+		return errTerminalForbidden
+	}
+
+	containerInfo, err := types.NewContainerInfo(input.Container)
+	if err != nil {
+		return err
+	}
+
+	if len(input.SessionID) == 0 {
+		input.SessionID = NewTerminalSessionID()
+	}
+
+	terminal := config.Get().Terminal
+	started := time.Now()
+
+	log.WithFields(e.terminalAuditFields(input, user)).Info("terminal session started")
+
+	sessionCtx, cancel := context.WithTimeoutCause(ctx, time.Duration(terminal.MaxDurationMinutes)*time.Minute, errTerminalMaxDuration)
+	defer cancel()
+
+	sessionCtx, cancelIdle := context.WithCancelCause(sessionCtx)
+	defer cancelIdle(nil)
+
+	stdin := &terminalStdin{r: input.Stdin}
+	stdin.lastRead.Store(started.UnixNano())
+
+	go func() {
+		ticker := time.NewTicker(terminalIdleCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sessionCtx.Done():
+				return
+			case <-ticker.C:
+				if stdin.idle() > time.Duration(terminal.IdleTimeoutMinutes)*time.Minute {
+					cancelIdle(errTerminalIdle)
+
+					return
+				}
+			}
+		}
+	}()
+
+	err = e.streamTerminal(sessionCtx, containerInfo, stdin, input)
+
+	reason := terminalReasonFinished
+
+	switch {
+	case context.Cause(sessionCtx) != nil && !errors.Is(context.Cause(sessionCtx), context.Canceled):
+		reason = context.Cause(sessionCtx).Error()
+	case ctx.Err() != nil:
+		reason = terminalReasonDisconnected
+	case err != nil:
+		reason = err.Error()
+	}
+
+	e.auditTerminalSession(context.WithoutCancel(ctx), input, user, time.Since(started), reason)
+
+	if err != nil && sessionCtx.Err() == nil {
+		return errors.Wrap(err, "error in terminal session")
+	}
+
+	return nil
+}
+
+func (e *Environment) streamTerminal(ctx context.Context, containerInfo *types.ContainerInfo, stdin io.Reader, input *ExecTerminalInput) error {
+	ctx, span := telemetry.Start(ctx, "api.streamTerminal")
+	defer span.End()
+
+	req := e.clientset.CoreV1().RESTClient().
+		Post().
+		Namespace(e.Namespace).
+		Resource("pods").
+		Name(containerInfo.PodName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: containerInfo.ContainerName,
+			Command:   config.Get().Terminal.Command,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    false,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+
+	restconfig, err := client.GetRestConfig(e.Cluster)
+	if err != nil {
+		return errors.New("can not get client config for cluster")
+	}
+
+	exec, err := remotecommand.NewSPDYExecutor(restconfig, "POST", req.URL())
+	if err != nil {
+		return errors.Wrap(err, "can not execute command")
+	}
+
+	return exec.StreamWithContext(ctx, remotecommand.StreamOptions{ //nolint:wrapcheck
+		Stdin:             stdin,
+		Stdout:            input.Stdout,
+		Tty:               true,
+		TerminalSizeQueue: &terminalSizeQueue{ctx: ctx, resize: input.Resize},
+	})
+}
+
+func (e *Environment) terminalAuditFields(input *ExecTerminalInput, user string) log.Fields {
+	return log.Fields{
+		"audit":       config.PermissionTerminal,
+		"session":     input.SessionID,
+		"user":        user,
+		"environment": e.ID,
+		"container":   input.Container,
+	}
+}
+
+func (e *Environment) auditTerminalSession(ctx context.Context, input *ExecTerminalInput, user string, duration time.Duration, reason string) {
+	durationText := duration.Round(time.Second).String()
+
+	fields := e.terminalAuditFields(input, user)
+	fields["duration"] = durationText
+	fields["reason"] = reason
+
+	log.WithFields(fields).Info("terminal session closed")
+
+	eventMessage := e.NewWebhookMessage(types.EventTerminalSession)
+	eventMessage.Reason = "Terminal session " + reason
+	eventMessage.Properties["user"] = user
+	eventMessage.Properties["session"] = input.SessionID
+	eventMessage.Properties["container"] = input.Container
+	eventMessage.Properties["duration"] = durationText
+	eventMessage.Properties["reason"] = reason
+
+	e.SendWebhookEvent(ctx, eventMessage)
+}
@@ -0,0 +1,267 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type FollowBranchResult struct {
+	ProjectID     string
+	Ref           string
+	CommitsBehind int
+	PipelineURL   string
+	Error         string
+	Created       string
+}
+
+func (e *Environment) IsFollowBranch() bool {
+	return e.NamespaceAnnotations[config.LabelFollowBranch] == config.TrueValue
+}
+
+// enable or disable auto-update of installed branches.
+func (e *Environment) SetFollowBranch(ctx context.Context, enabled bool) error {
+	ctx, span := telemetry.Start(ctx, "api.SetFollowBranch")
+	defer span.End()
+
+	if e.scmProvider == nil {
+		return errNoSCMProvider
+	}
+
+	annotations := map[string]string{
+		config.LabelFollowBranch: strconv.FormatBool(enabled),
+	}
+
+	// branches will be followed from current commits
+	if enabled {
+		for projectID, ref := range e.getFollowedBranches() {
+			branch, err := e.scmProvider.GetBranch(ctx, projectID, ref)
+			if err != nil {
+				return errors.Wrapf(err, "can not get branch %s in project %s", ref, projectID)
+			}
+
+			annotations[getFollowBranchSHALabel(projectID)] = branch.CommitSHA
+		}
+	}
+
+	return e.SaveNamespaceMeta(ctx, annotations, e.NamespaceLabels)
+}
+
+// returns results of last auto-update.
+func (e *Environment) GetFollowBranchResults() []*FollowBranchResult {
+	result := make([]*FollowBranchResult, 0)
+
+	info, ok := e.NamespaceAnnotations[config.LabelFollowBranchInfo]
+	if !ok {
+		return result
+	}
+
+	if err := json.Unmarshal([]byte(info), &result); err != nil {
+		log.WithError(err).Warn("error parsing follow branch results")
+	}
+
+	return result
+}
+
+// environment is in working hours, if it was scaled up and not scaled down yet.
+func (e *Environment) IsWorkingHours(now time.Time) bool {
+	scaleDelayTime, ok := e.getScaleDownDelay()
+
+	return ok && now.Before(scaleDelayTime)
+}
+
+// returns installed branches, tags forks will be ignored.
+func (e *Environment) getFollowedBranches() map[string]string {
+	result := make(map[string]string)
+
+	for key, value := range e.NamespaceAnnotations {
+		projectID, ok := strings.CutPrefix(key, config.LabelInstalledProject+"-")
+		if !ok {
+			continue
+		}
+
+		if isTagForkBranch(value) {
+			continue
+		}
+
+		result[projectID] = value
+	}
+
+	return result
+}
+
+func getFollowBranchSHALabel(projectID string) string {
+	return fmt.Sprintf("%s-%s", config.LabelFollowBranchSHA, projectID)
+}
+
+func getFollowBranchLastLabel(projectID string) string {
+	return fmt.Sprintf("%s-%s", config.LabelFollowBranchLast, projectID)
+}
+
+// pipelines in one project are created not often than MinIntervalMinutes.
+func (e *Environment) isFollowBranchRateLimited(projectID string, now time.Time) bool {
+	lastUpdate, err := utils.StringToTime(e.NamespaceAnnotations[getFollowBranchLastLabel(projectID)])
+	if err != nil {
+		return false
+	}
+
+	minInterval := time.Duration(config.Get().FollowBranch.MinIntervalMinutes) * time.Minute
+
+	return now.Sub(lastUpdate) < minInterval
+}
+
+// create deploy pipelines for installed branches that have new commits,
+// if projectID is empty all installed projects will be checked.
+func (e *Environment) UpdateFollowedBranches(ctx context.Context, projectID string) ([]*FollowBranchResult, error) {
+	ctx, span := telemetry.Start(ctx, "api.UpdateFollowedBranches")
+	defer span.End()
+
+	results, annotations, err := e.getFollowedBranchesUpdates(ctx, projectID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(annotations) > 0 {
+		if err := e.SaveNamespaceMeta(ctx, annotations, e.NamespaceLabels); err != nil {
+			return nil, errors.Wrap(err, "error saving namespace annotations")
+		}
+	}
+
+	return results, nil
+}
+
+// returns results of created pipelines and annotations that must be saved.
+func (e *Environment) getFollowedBranchesUpdates(ctx context.Context, projectID string, now time.Time) ([]*FollowBranchResult, map[string]string, error) {
+	ctx, span := telemetry.Start(ctx, "api.getFollowedBranchesUpdates")
+	defer span.End()
+
+	if !e.IsFollowBranch() || e.IsSystemNamespace() {
+		return nil, nil, nil
+	}
+
+	if e.scmProvider == nil {
+		return nil, nil, errNoSCMProvider
+	}
+
+	log := log.WithField("namespace", e.Namespace)
+
+	if !e.IsWorkingHours(now) {
+		log.Debug("environment is not in working hours")
+
+		return nil, nil, nil
+	}
+
+	annotations := make(map[string]string)
+	results := make([]*FollowBranchResult, 0)
+
+	for installedProjectID, ref := range e.getFollowedBranches() {
+		if len(projectID) > 0 && installedProjectID != projectID {
+			continue
+		}
+
+		if e.isFollowBranchRateLimited(installedProjectID, now) {
+			log.Debugf("project %s was updated recently", installedProjectID)
+
+			continue
+		}
+
+		result, sha, err := e.updateFollowedBranch(ctx, installedProjectID, ref)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error updating project %s", installedProjectID)
+		}
+
+		if len(sha) > 0 {
+			annotations[getFollowBranchSHALabel(installedProjectID)] = sha
+		}
+
+		if result != nil {
+			annotations[getFollowBranchLastLabel(installedProjectID)] = utils.TimeToString(now)
+			results = append(results, result)
+		}
+	}
+
+	if len(results) > 0 {
+		resultsJSON, err := json.Marshal(results)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "error marshaling results")
+		}
+
+		annotations[config.LabelFollowBranchInfo] = string(resultsJSON)
+	}
+
+	return results, annotations, nil
+}
+
+// returns result if pipeline was created and new commit that must be saved.
+func (e *Environment) updateFollowedBranch(ctx context.Context, projectID, ref string) (*FollowBranchResult, string, error) {
+	ctx, span := telemetry.Start(ctx, "api.updateFollowedBranch")
+	defer span.End()
+
+	branch, err := e.scmProvider.GetBranch(ctx, projectID, ref)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "can not get branch %s", ref)
+	}
+
+	lastSHA := e.NamespaceAnnotations[getFollowBranchSHALabel(projectID)]
+
+	// first check, branch will be followed from current commit
+	if len(lastSHA) == 0 {
+		return nil, branch.CommitSHA, nil
+	}
+
+	if lastSHA == branch.CommitSHA {
+		return nil, "", nil
+	}
+
+	compare, err := e.scmProvider.Compare(ctx, projectID, lastSHA, branch.CommitSHA)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "can not compare commits")
+	}
+
+	if compare.Commits < config.Get().FollowBranch.MinCommitsBehind {
+		return nil, "", nil
+	}
+
+	result := FollowBranchResult{
+		ProjectID:     projectID,
+		Ref:           ref,
+		CommitsBehind: compare.Commits,
+		Created:       utils.TimeToString(time.Now()),
+	}
+
+	pipelineURL, err := e.CreateGitlabPipeline(ctx, &CreateGitlabPipelineInput{
+		ProjectID: projectID,
+		Ref:       ref,
+		Operation: GitlabPipelineOperationDeploy,
+	})
+	if err != nil {
+		result.Error = err.Error()
+
+		return &result, "", nil
+	}
+
+	result.PipelineURL = pipelineURL
+
+	return &result, branch.CommitSHA, nil
+}
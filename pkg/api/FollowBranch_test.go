@@ -0,0 +1,204 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	scmgitlab "github.com/maksim-paskal/kubernetes-manager/pkg/scm/gitlab"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestIsWorkingHours(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	type testCase struct {
+		Annotations map[string]string
+		Want        bool
+	}
+
+	testCases := []testCase{
+		{Annotations: map[string]string{}, Want: false},
+		{Annotations: map[string]string{config.LabelScaleDownDelay: "bad-date"}, Want: false},
+		{Annotations: map[string]string{config.LabelScaleDownDelay: utils.TimeToString(now.Add(-time.Hour))}, Want: false},
+		{Annotations: map[string]string{config.LabelScaleDownDelay: utils.TimeToString(now.Add(time.Hour))}, Want: true},
+	}
+
+	for i, testCase := range testCases {
+		environment := &api.Environment{
+			NamespaceAnnotations: testCase.Annotations,
+		}
+
+		if got := environment.IsWorkingHours(now); got != testCase.Want {
+			t.Errorf("(case %d) got %v, want %v", i, got, testCase.Want)
+		}
+	}
+}
+
+func TestFollowBranchResults(t *testing.T) {
+	t.Parallel()
+
+	environment := &api.Environment{
+		NamespaceAnnotations: map[string]string{
+			config.LabelFollowBranch:     config.TrueValue,
+			config.LabelFollowBranchInfo: `[{"ProjectID":"1","Ref":"main","CommitsBehind":2,"PipelineURL":"https://gitlab/pipelines/1"}]`,
+		},
+	}
+
+	if !environment.IsFollowBranch() {
+		t.Fatal("environment must follow branch")
+	}
+
+	results := environment.GetFollowBranchResults()
+	if len(results) != 1 || results[0].CommitsBehind != 2 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	// environment without follow branch must be ignored
+	results, err := (&api.Environment{}).UpdateFollowedBranches(context.TODO(), "")
+	if err != nil || results != nil {
+		t.Fatalf("unexpected result %+v, %v", results, err)
+	}
+}
+
+// fake gitlab, branch main in all projects has commit new-sha,
+// from old-sha it has 2 new commits, from same-sha - no new commits.
+func newFollowBranchTestProvider(t *testing.T, pipelines *atomic.Int32) *scmgitlab.Provider {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v4/projects/{id}/repository/branches/main", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"main","commit":{"id":"new-sha"}}`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/{id}/repository/tags/main", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"404 Tag Not Found"}`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/{id}/repository/compare", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("from") == "same-sha" {
+			_, _ = w.Write([]byte(`{"commits":[]}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"commits":[{"id":"a"},{"id":"b"}]}`))
+	})
+
+	mux.HandleFunc("POST /api/v4/projects/{id}/pipeline", func(w http.ResponseWriter, _ *http.Request) {
+		pipelines.Add(1)
+
+		_, _ = w.Write([]byte(`{"id":10,"ref":"main","status":"created","web_url":"http://pipeline/10"}`))
+	})
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	client, err := gitlab.NewClient("", gitlab.WithBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := scmgitlab.NewProvider(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return provider
+}
+
+func TestGetFollowedBranchesUpdates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	pipelines := atomic.Int32{}
+
+	environment := &api.Environment{
+		Namespace: "test",
+		NamespaceAnnotations: map[string]string{
+			config.LabelFollowBranch:   config.TrueValue,
+			config.LabelScaleDownDelay: utils.TimeToString(now.Add(time.Hour)),
+			// first check, commit must be recorded
+			config.LabelInstalledProject + "-1": "main",
+			// no new commits
+			config.LabelInstalledProject + "-2": "main",
+			config.LabelFollowBranchSHA + "-2":  "same-sha",
+			// new commits, pipeline must be created
+			config.LabelInstalledProject + "-3": "main",
+			config.LabelFollowBranchSHA + "-3":  "old-sha",
+			// new commits, but project was updated recently
+			config.LabelInstalledProject + "-4": "main",
+			config.LabelFollowBranchSHA + "-4":  "old-sha",
+			config.LabelFollowBranchLast + "-4": utils.TimeToString(now.Add(-time.Minute)),
+			// tag forks are not followed
+			config.LabelInstalledProject + "-5": "tagfork-1700000000-v1.0.0-abcde",
+		},
+	}
+
+	environment.SetSCMProvider(newFollowBranchTestProvider(t, &pipelines))
+
+	results, annotations, err := environment.GetFollowedBranchesUpdates(context.TODO(), "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].ProjectID != "3" || results[0].CommitsBehind != 2 || results[0].PipelineURL != "http://pipeline/10" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	if pipelines.Load() != 1 {
+		t.Fatalf("must be created 1 pipeline, got %d", pipelines.Load())
+	}
+
+	wantAnnotations := map[string]string{
+		config.LabelFollowBranchSHA + "-1":  "new-sha",
+		config.LabelFollowBranchSHA + "-3":  "new-sha",
+		config.LabelFollowBranchLast + "-3": utils.TimeToString(now),
+	}
+
+	for key, value := range wantAnnotations {
+		if annotations[key] != value {
+			t.Errorf("annotation %s got %s, want %s", key, annotations[key], value)
+		}
+	}
+
+	for _, key := range []string{config.LabelFollowBranchSHA + "-2", config.LabelFollowBranchSHA + "-4", config.LabelFollowBranchSHA + "-5"} {
+		if _, ok := annotations[key]; ok {
+			t.Errorf("annotation %s must not be changed", key)
+		}
+	}
+
+	if len(annotations[config.LabelFollowBranchInfo]) == 0 {
+		t.Error("results must be saved")
+	}
+
+	// environment is scaled down, nothing to update
+	environment.NamespaceAnnotations[config.LabelScaleDownDelay] = utils.TimeToString(now.Add(-time.Hour))
+
+	results, annotations, err = environment.GetFollowedBranchesUpdates(context.TODO(), "", now)
+	if err != nil || results != nil || annotations != nil {
+		t.Fatalf("unexpected result %+v, %+v, %v", results, annotations, err)
+	}
+}
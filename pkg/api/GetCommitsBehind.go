@@ -16,9 +16,9 @@ import (
 	"context"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/pkg/errors"
-	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 type GetCommitsBehindResult struct {
@@ -28,46 +28,38 @@ type GetCommitsBehindResult struct {
 	CommitsBehind  *int
 }
 
-func GetCommitsBehind(ctx context.Context, p *gitlab.Project, projectID, branch string) (*GetCommitsBehindResult, error) {
+func GetCommitsBehind(ctx context.Context, p *scm.Project, projectID, branch string) (*GetCommitsBehindResult, error) {
 	ctx, span := telemetry.Start(ctx, "api.GetCommitsBehind")
 	defer span.End()
 
-	gitlabClient := client.GetGitlabClient()
+	scmProvider := client.GetSCMProvider()
 
-	if gitlabClient == nil {
-		return nil, errNoGitlabClient
+	if scmProvider == nil {
+		return nil, errNoSCMProvider
 	}
 
-	gitlabProject := p
+	project := p
 
-	if gitlabProject == nil {
-		project, err := GetCachedGitlabProject(ctx, projectID)
+	if project == nil {
+		cachedProject, err := GetCachedProject(ctx, projectID)
 		if err != nil {
 			return nil, errors.Wrap(err, "can not get project")
 		}
 
-		gitlabProject = project
+		project = cachedProject
 	}
 
 	result := GetCommitsBehindResult{
-		DefaultBranch: &gitlabProject.DefaultBranch,
-		WebURL:        &gitlabProject.WebURL,
+		DefaultBranch: &project.DefaultBranch,
+		WebURL:        &project.WebURL,
 	}
 
-	if len(branch) > 0 && branch != gitlabProject.DefaultBranch {
-		branchCompare, _, err := gitlabClient.Repositories.Compare(
-			projectID,
-			&gitlab.CompareOptions{
-				From: &branch,
-				To:   &gitlabProject.DefaultBranch,
-			},
-			gitlab.WithContext(ctx),
-		)
+	if len(branch) > 0 && branch != project.DefaultBranch {
+		branchCompare, err := scmProvider.Compare(ctx, projectID, branch, project.DefaultBranch)
 		if err != nil {
 			result.BranchNotFound = true
 		} else {
-			commitsBehind := len(branchCompare.Commits)
-			result.CommitsBehind = &commitsBehind
+			result.CommitsBehind = &branchCompare.Commits
 		}
 	}
 
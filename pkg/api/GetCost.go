@@ -0,0 +1,363 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/metrics"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const gibibyte = 1024 * 1024 * 1024
+
+// resources of environment that are used in cost estimation.
+type CostResources struct {
+	// requested CPU cores
+	CPU float64
+	// requested memory in bytes
+	MemoryBytes float64
+	// capacity of persistent volumes in bytes
+	StorageBytes float64
+	// price per hour of cloud resources from webhooks
+	CloudResourcesHour float64
+}
+
+type EnvironmentCost struct {
+	ID        string
+	Cluster   string
+	Namespace string
+	Creator   string
+	Profile   string
+	Month     string
+	// hours when environment was scaled up in month
+	RunningHours   float64
+	CPU            float64
+	Memory         float64
+	Storage        float64
+	CloudResources float64
+	Total          float64
+}
+
+type RemoteServerCost struct {
+	Cloud      string
+	ID         string
+	Name       string
+	Owner      string
+	ServerType string
+	// hours when server existed in month, only running hours if provider does not bill stopped servers
+	Hours float64
+	Total float64
+}
+
+// total cost of group of environments and servers.
+type CostGroup struct {
+	Name  string
+	Total float64
+}
+
+type CostReport struct {
+	Month         string
+	Currency      string
+	Total         float64
+	Environments  []*EnvironmentCost
+	RemoteServers []*RemoteServerCost
+	// environments by creator and servers by owner
+	Users    []*CostGroup
+	Profiles []*CostGroup
+}
+
+type CostReportInput struct {
+	// month in 2006-01 format, current month if empty
+	Month string
+}
+
+func (i *CostReportInput) GetMonth(now time.Time) (time.Time, time.Time, error) {
+	if len(i.Month) == 0 {
+		i.Month = now.UTC().Format(costMonthFormat)
+	}
+
+	monthStart, err := time.Parse(costMonthFormat, i.Month)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Wrap(err, "month must be in YYYY-MM format")
+	}
+
+	if monthStart.After(now) {
+		return time.Time{}, time.Time{}, errors.New("month is in future")
+	}
+
+	return monthStart, monthStart.AddDate(0, 1, 0), nil
+}
+
+// returns hours of period in month, period is ended now.
+func getHoursInMonth(from, now, monthStart, monthEnd time.Time) float64 {
+	if from.Before(monthStart) {
+		from = monthStart
+	}
+
+	if now.After(monthEnd) {
+		now = monthEnd
+	}
+
+	if !from.Before(now) {
+		return 0
+	}
+
+	return now.Sub(from).Hours()
+}
+
+// cost rounded to cents.
+func roundCost(value float64) float64 {
+	const cents = 100
+
+	return math.Round(value*cents) / cents
+}
+
+// estimate cost of resources, storage is billed for all hours when environment existed.
+func NewEnvironmentCost(cost *config.Cost, resources CostResources, runningHours, existingHours, monthHours float64) *EnvironmentCost {
+	result := EnvironmentCost{
+		RunningHours:   roundCost(runningHours),
+		CPU:            roundCost(resources.CPU * cost.CPUHour * runningHours),
+		Memory:         roundCost(resources.MemoryBytes / gibibyte * cost.MemoryGiBHour * runningHours),
+		CloudResources: roundCost(resources.CloudResourcesHour * runningHours),
+	}
+
+	if monthHours > 0 {
+		result.Storage = roundCost(resources.StorageBytes / gibibyte * cost.StorageGiBMonth * existingHours / monthHours)
+	}
+
+	result.Total = roundCost(result.CPU + result.Memory + result.Storage + result.CloudResources)
+
+	return &result
+}
+
+// price per hour of cloud resources from webhooks of environment.
+func (e *Environment) getCloudResourcesCostPerHour() float64 {
+	result := 0.0
+
+	for _, webhook := range config.Get().WebHooks {
+		if slices.Contains(webhook.IDs, e.ID) {
+			result += webhook.CostPerHour
+		}
+	}
+
+	return result
+}
+
+// estimated cost of environment in month.
+func (e *Environment) GetCost(ctx context.Context, input *CostReportInput) (*EnvironmentCost, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetCost")
+	defer span.End()
+
+	now := time.Now()
+
+	monthStart, monthEnd, err := input.GetMonth(now)
+	if err != nil {
+		return nil, err
+	}
+
+	podsInfo, err := e.GetPodsInfo(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting pods info")
+	}
+
+	usage := e.getCostUsage()
+
+	resources := CostResources{
+		CPU:                usage.CPURequests,
+		MemoryBytes:        usage.MemoryRequests,
+		StorageBytes:       podsInfo.storageRequests,
+		CloudResourcesHour: e.getCloudResourcesCostPerHour(),
+	}
+
+	runningSince := time.Time{}
+
+	// requests of running pods are used for running environment
+	if podsInfo.PodsTotal > 0 {
+		runningSince = e.getRunningSince(usage)
+		resources.CPU = podsInfo.cpuRequests
+		resources.MemoryBytes = podsInfo.memoryRequests
+	}
+
+	created, err := utils.StringToTime(e.NamespaceCreated)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing namespace creation time")
+	}
+
+	result := NewEnvironmentCost(
+		&config.Get().Cost,
+		resources,
+		usage.GetRunningHours(input.Month, runningSince, now),
+		getHoursInMonth(created, now, monthStart, monthEnd),
+		monthEnd.Sub(monthStart).Hours(),
+	)
+
+	result.ID = e.ID
+	result.Cluster = e.Cluster
+	result.Namespace = e.Namespace
+	result.Creator = e.NamespaceCreatedBy
+	result.Profile = e.NamespaceAnnotations[config.LabelProjectProfile]
+	result.Month = input.Month
+
+	return result, nil
+}
+
+// estimated cost of environments and remote servers in month, deleted environments are not included.
+func GetCostReport(ctx context.Context, input *CostReportInput) (*CostReport, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetCostReport")
+	defer span.End()
+
+	now := time.Now()
+
+	monthStart, monthEnd, err := input.GetMonth(now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := CostReport{
+		Month:         input.Month,
+		Currency:      config.Get().Cost.Currency,
+		Environments:  make([]*EnvironmentCost, 0),
+		RemoteServers: make([]*RemoteServerCost, 0),
+	}
+
+	environments, err := GetEnvironments(ctx, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing environments")
+	}
+
+	users := make(map[string]float64)
+	profiles := make(map[string]float64)
+
+	for _, environment := range environments {
+		if environment.IsSystemNamespace() {
+			continue
+		}
+
+		environmentCost, err := environment.GetCost(ctx, input)
+		if err != nil {
+			log.WithError(err).WithField("environment", environment.ID).Error("error getting environment cost")
+
+			continue
+		}
+
+		result.Environments = append(result.Environments, environmentCost)
+		result.Total += environmentCost.Total
+		users[environmentCost.Creator] += environmentCost.Total
+		profiles[environmentCost.Profile] += environmentCost.Total
+	}
+
+	servers, err := GetRemoteServers(ctx)
+	if err != nil && !errors.Is(err, errNoRemoteServerProviders) {
+		return nil, errors.Wrap(err, "error listing servers")
+	}
+
+	for _, server := range servers {
+		serverCost := server.GetCost(&config.Get().Cost, isBilledWhenStopped(server.Cloud), now, monthStart, monthEnd)
+
+		result.RemoteServers = append(result.RemoteServers, serverCost)
+		result.Total += serverCost.Total
+		users[serverCost.Owner] += serverCost.Total
+	}
+
+	result.Total = roundCost(result.Total)
+	result.Users = newCostGroups(users)
+	result.Profiles = newCostGroups(profiles)
+
+	slices.SortFunc(result.Environments, func(a, b *EnvironmentCost) int {
+		return cmp.Compare(b.Total, a.Total)
+	})
+
+	slices.SortFunc(result.RemoteServers, func(a, b *RemoteServerCost) int {
+		return cmp.Compare(b.Total, a.Total)
+	})
+
+	return &result, nil
+}
+
+// returns false if provider does not bill stopped servers.
+func isBilledWhenStopped(cloud string) bool {
+	provider := config.Get().RemoteServer.GetProvider(cloud)
+	if provider == nil {
+		return true
+	}
+
+	return remoteserver.ProviderType(provider.Type).IsBilledWhenStopped()
+}
+
+// remote servers are billed for all hours when they existed or for running hours.
+func (i *GetRemoteServerItem) GetCost(cost *config.Cost, billedWhenStopped bool, now, monthStart, monthEnd time.Time) *RemoteServerCost {
+	hours := getHoursInMonth(i.Created, now, monthStart, monthEnd)
+
+	if !billedWhenStopped {
+		hours = i.GetRunningHours(now, monthStart, monthEnd)
+	}
+
+	return &RemoteServerCost{
+		Cloud:      i.Cloud,
+		ID:         i.ID,
+		Name:       i.ServerName,
+		Owner:      i.GetOwner(),
+		ServerType: i.ServerType,
+		Hours:      roundCost(hours),
+		Total:      roundCost(hours * cost.ServerTypeHour[i.ServerType]),
+	}
+}
+
+func newCostGroups(totals map[string]float64) []*CostGroup {
+	result := make([]*CostGroup, 0, len(totals))
+
+	for name, total := range totals {
+		result = append(result, &CostGroup{Name: name, Total: roundCost(total)})
+	}
+
+	slices.SortFunc(result, func(a, b *CostGroup) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(a.Name, b.Name))
+	})
+
+	return result
+}
+
+// update prometheus gauges with estimated cost of current month.
+func UpdateCostMetrics(ctx context.Context) error {
+	ctx, span := telemetry.Start(ctx, "api.UpdateCostMetrics")
+	defer span.End()
+
+	report, err := GetCostReport(ctx, &CostReportInput{})
+	if err != nil {
+		return errors.Wrap(err, "error getting cost report")
+	}
+
+	// remove deleted environments and servers
+	metrics.EnvironmentCost.Reset()
+	metrics.RemoteServerCost.Reset()
+
+	for _, item := range report.Environments {
+		metrics.EnvironmentCost.WithLabelValues(item.Cluster, item.Namespace, item.Creator, item.Profile).Set(item.Total)
+	}
+
+	for _, item := range report.RemoteServers {
+		metrics.RemoteServerCost.WithLabelValues(item.Cloud, item.Name, item.Owner, item.ServerType).Set(item.Total)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+)
+
+func TestCostUsage(t *testing.T) {
+	t.Parallel()
+
+	usage := api.CostUsage{}
+
+	// running period is split between months
+	usage.AddRunningTime(
+		time.Date(2024, 1, 31, 20, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 1, 6, 0, 0, 0, time.UTC),
+	)
+	usage.AddRunningTime(
+		time.Date(2024, 2, 5, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 5, 18, 0, 0, 0, time.UTC),
+	)
+
+	if hours := usage.RunningHours["2024-01"]; hours != 4 {
+		t.Fatalf("want 4 hours in January, got %f", hours)
+	}
+
+	if hours := usage.RunningHours["2024-02"]; hours != 16 {
+		t.Fatalf("want 16 hours in February, got %f", hours)
+	}
+
+	now := time.Date(2024, 2, 6, 12, 0, 0, 0, time.UTC)
+
+	if hours := usage.GetRunningHours("2024-02", now.Add(-2*time.Hour), now); hours != 18 {
+		t.Fatalf("want 18 hours with running environment, got %f", hours)
+	}
+
+	if hours := usage.GetRunningHours("2024-02", time.Time{}, now); hours != 16 {
+		t.Fatalf("want 16 hours with stopped environment, got %f", hours)
+	}
+}
+
+func TestNewEnvironmentCost(t *testing.T) {
+	t.Parallel()
+
+	cost := config.Cost{
+		CPUHour:         0.02,
+		MemoryGiBHour:   0.005,
+		StorageGiBMonth: 0.1,
+	}
+
+	resources := api.CostResources{
+		CPU:                2,
+		MemoryBytes:        4 * 1024 * 1024 * 1024,
+		StorageBytes:       10 * 1024 * 1024 * 1024,
+		CloudResourcesHour: 0.1,
+	}
+
+	// running 100 hours, existed half of month
+	result := api.NewEnvironmentCost(&cost, resources, 100, 360, 720)
+
+	if result.CPU != 4 {
+		t.Fatalf("want CPU cost 4, got %f", result.CPU)
+	}
+
+	if result.Memory != 2 {
+		t.Fatalf("want memory cost 2, got %f", result.Memory)
+	}
+
+	if result.Storage != 0.5 {
+		t.Fatalf("want storage cost 0.5, got %f", result.Storage)
+	}
+
+	if result.CloudResources != 10 {
+		t.Fatalf("want cloud resources cost 10, got %f", result.CloudResources)
+	}
+
+	if result.Total != 16.5 {
+		t.Fatalf("want total cost 16.5, got %f", result.Total)
+	}
+}
+
+func TestRemoteServerCost(t *testing.T) {
+	t.Parallel()
+
+	cost := config.Cost{
+		ServerTypeHour: map[string]float64{"cx22": 0.01},
+	}
+
+	input := api.CostReportInput{Month: "2024-02"}
+	now := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	monthStart, monthEnd, err := input.GetMonth(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := api.GetRemoteServerItem{
+		ServerType: "cx22",
+		Created:    time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC),
+		Labels:     map[string]string{"owner": "test"},
+	}
+
+	result := server.GetCost(&cost, true, now, monthStart, monthEnd)
+
+	// 10 days in February
+	if result.Hours != 240 || result.Total != 2.4 || result.Owner != "test" {
+		t.Fatalf("unexpected cost %+v", result)
+	}
+
+	if _, _, err := (&api.CostReportInput{Month: "2024-13"}).GetMonth(now); err == nil {
+		t.Fatal("invalid month must return error")
+	}
+
+	if _, _, err := (&api.CostReportInput{Month: "2024-04"}).GetMonth(now); err == nil {
+		t.Fatal("future month must return error")
+	}
+}
+
+func TestRemoteServerRunningCost(t *testing.T) {
+	t.Parallel()
+
+	cost := config.Cost{
+		ServerTypeHour: map[string]float64{"t3.medium": 0.1},
+	}
+
+	monthStart := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	now := time.Date(2024, 2, 20, 10, 0, 0, 0, time.UTC)
+	lastPowerOnTime := time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC)
+
+	server := api.GetRemoteServerItem{
+		Status:     api.GetRemoteServerItemStatusStoped,
+		ServerType: "t3.medium",
+		Created:    time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC),
+		Labels: map[string]string{
+			"runningHours202402": "20",
+			"lastPowerOnTime":    strconv.FormatInt(lastPowerOnTime.Unix(), 10),
+		},
+	}
+
+	// stopped server is billed only for saved running hours
+	if result := server.GetCost(&cost, false, now, monthStart, monthEnd); result.Hours != 20 || result.Total != 2 {
+		t.Fatalf("unexpected cost of stopped server %+v", result)
+	}
+
+	server.Status = api.GetRemoteServerItemStatusRunning
+
+	// running server is billed for 10 hours since last power on
+	if result := server.GetCost(&cost, false, now, monthStart, monthEnd); result.Hours != 30 || result.Total != 3 {
+		t.Fatalf("unexpected cost of running server %+v", result)
+	}
+
+	labels := server.GetRunningHoursLabels(now)
+
+	if len(labels) != 1 || labels["runningHours202402"] != "30.00" {
+		t.Fatalf("unexpected running hours labels %+v", labels)
+	}
+
+	// server that was powered on in previous month
+	server.Labels["lastPowerOnTime"] = strconv.FormatInt(time.Date(2024, 1, 31, 22, 0, 0, 0, time.UTC).Unix(), 10)
+
+	labels = server.GetRunningHoursLabels(time.Date(2024, 2, 1, 1, 0, 0, 0, time.UTC))
+
+	if len(labels) != 2 || labels["runningHours202401"] != "2.00" || labels["runningHours202402"] != "21.00" {
+		t.Fatalf("unexpected running hours labels %+v", labels)
+	}
+}
@@ -0,0 +1,281 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const deployProgressMaxEvents = 20
+
+type DeployProgressProject struct {
+	ProjectID   string
+	Ref         string
+	PipelineURL string
+	Status      scm.PipelineStatus
+	Jobs        []*scm.Job
+	Error       string
+}
+
+type DeployProgressWorkload struct {
+	Kind               string
+	Name               string
+	Generation         int64
+	ObservedGeneration int64
+	Replicas           int32
+	UpdatedReplicas    int32
+	ReadyReplicas      int32
+	Ready              bool
+}
+
+type DeployProgressItem struct {
+	time    time.Time
+	Created string
+	Kind    string
+	Object  string
+	Status  string
+	Message string
+}
+
+type DeployProgress struct {
+	Waves     *DeployStatus
+	Projects  []*DeployProgressProject
+	Workloads []*DeployProgressWorkload
+	Timeline  []*DeployProgressItem
+	Ready     bool
+}
+
+func (p *DeployProgress) addTimeline(created *time.Time, kind, object, status, message string) {
+	if created == nil {
+		return
+	}
+
+	p.Timeline = append(p.Timeline, &DeployProgressItem{
+		time:    *created,
+		Created: utils.TimeToString(*created),
+		Kind:    kind,
+		Object:  object,
+		Status:  status,
+		Message: message,
+	})
+}
+
+// returns deploy progress of installed projects: pipelines, rollout of workloads and warning events.
+func (e *Environment) GetDeployProgress(ctx context.Context) (*DeployProgress, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetDeployProgress")
+	defer span.End()
+
+	result := DeployProgress{
+		Waves:     e.GetDeployStatus(),
+		Projects:  make([]*DeployProgressProject, 0),
+		Workloads: make([]*DeployProgressWorkload, 0),
+		Timeline:  make([]*DeployProgressItem, 0),
+		Ready:     true,
+	}
+
+	for key, ref := range e.NamespaceAnnotations {
+		projectID, ok := strings.CutPrefix(key, config.LabelInstalledProject+"-")
+		if !ok {
+			continue
+		}
+
+		project := e.getDeployProgressProject(ctx, projectID, ref)
+
+		if !project.Status.IsFinished() {
+			result.Ready = false
+		}
+
+		for _, job := range project.Jobs {
+			object := fmt.Sprintf("%s/%s", projectID, job.Name)
+
+			result.addTimeline(job.Started, "job", object, string(scm.PipelineStatusRunning), job.Stage)
+			result.addTimeline(job.Finished, "job", object, string(job.Status), job.Stage)
+		}
+
+		result.Projects = append(result.Projects, project)
+	}
+
+	sort.Slice(result.Projects, func(i, j int) bool {
+		return result.Projects[i].ProjectID < result.Projects[j].ProjectID
+	})
+
+	workloads, err := e.getDeployProgressWorkloads(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting workloads")
+	}
+
+	for _, workload := range workloads {
+		if !workload.Ready {
+			result.Ready = false
+		}
+	}
+
+	result.Workloads = workloads
+
+	events, err := e.GetEvents(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting events")
+	}
+
+	warnings := 0
+
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning {
+			continue
+		}
+
+		result.addTimeline(&event.createdTime, "event", event.Object, event.Reason, event.Message)
+
+		// events are sorted by time, use only last events
+		if warnings++; warnings >= deployProgressMaxEvents {
+			break
+		}
+	}
+
+	if result.Waves != nil {
+		if result.Waves.Status == DeployStatusRunning {
+			result.Ready = false
+		}
+
+		if updated, err := utils.StringToTime(result.Waves.Updated); err == nil {
+			result.addTimeline(&updated, "waves", fmt.Sprintf("wave %d", result.Waves.Wave+1), string(result.Waves.Status), result.Waves.Error)
+		}
+	}
+
+	sort.SliceStable(result.Timeline, func(i, j int) bool {
+		return result.Timeline[i].time.After(result.Timeline[j].time)
+	})
+
+	return &result, nil
+}
+
+// errors of project are returned in result, deploy progress of other projects must be shown.
+func (e *Environment) getDeployProgressProject(ctx context.Context, projectID, ref string) *DeployProgressProject {
+	ctx, span := telemetry.Start(ctx, "api.getDeployProgressProject")
+	defer span.End()
+
+	result := DeployProgressProject{
+		ProjectID: projectID,
+		Ref:       ref,
+		Status:    scm.PipelineStatusSkipped,
+		Jobs:      make([]*scm.Job, 0),
+	}
+
+	pipeline, err := e.getLastEnvironmentPipeline(ctx, projectID)
+	if err != nil {
+		log.WithError(err).Warnf("can not get pipeline of project %s", projectID)
+
+		result.Error = err.Error()
+
+		return &result
+	}
+
+	// pipeline was not created by kubernetes-manager
+	if pipeline == nil {
+		return &result
+	}
+
+	result.PipelineURL = pipeline.WebURL
+	result.Status = pipeline.Status
+
+	jobs, err := e.scmProvider.ListJobs(ctx, projectID, pipeline.ID)
+	if err != nil {
+		log.WithError(err).Warnf("can not get jobs of project %s", projectID)
+
+		result.Error = err.Error()
+
+		return &result
+	}
+
+	result.Jobs = jobs
+
+	return &result
+}
+
+// returns rollout state of deployments and statefulsets.
+func (e *Environment) getDeployProgressWorkloads(ctx context.Context) ([]*DeployProgressWorkload, error) {
+	ctx, span := telemetry.Start(ctx, "api.getDeployProgressWorkloads")
+	defer span.End()
+
+	result := make([]*DeployProgressWorkload, 0)
+
+	deployments, err := e.clientset.AppsV1().Deployments(e.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list deployments")
+	}
+
+	for _, deployment := range deployments.Items {
+		result = append(result, newDeployProgressWorkload(
+			"Deployment",
+			deployment.ObjectMeta,
+			deployment.Spec.Replicas,
+			deployment.Status.ObservedGeneration,
+			deployment.Status.UpdatedReplicas,
+			deployment.Status.ReadyReplicas,
+		))
+	}
+
+	statefulsets, err := e.clientset.AppsV1().StatefulSets(e.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list statefulsets")
+	}
+
+	for _, statefulset := range statefulsets.Items {
+		result = append(result, newDeployProgressWorkload(
+			"StatefulSet",
+			statefulset.ObjectMeta,
+			statefulset.Spec.Replicas,
+			statefulset.Status.ObservedGeneration,
+			statefulset.Status.UpdatedReplicas,
+			statefulset.Status.ReadyReplicas,
+		))
+	}
+
+	return result, nil
+}
+
+func newDeployProgressWorkload(kind string, meta metav1.ObjectMeta, replicas *int32, observedGeneration int64, updatedReplicas, readyReplicas int32) *DeployProgressWorkload {
+	result := DeployProgressWorkload{
+		Kind:               kind,
+		Name:               meta.Name,
+		Generation:         meta.Generation,
+		ObservedGeneration: observedGeneration,
+		Replicas:           1,
+		UpdatedReplicas:    updatedReplicas,
+		ReadyReplicas:      readyReplicas,
+	}
+
+	if replicas != nil {
+		result.Replicas = *replicas
+	}
+
+	// rollout is finished when controller observed last spec and all replicas are updated and ready
+	result.Ready = result.ObservedGeneration >= result.Generation &&
+		result.UpdatedReplicas >= result.Replicas &&
+		result.ReadyReplicas >= result.Replicas
+
+	return &result
+}
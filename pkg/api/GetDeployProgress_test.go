@@ -0,0 +1,61 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeployProgressWorkload(t *testing.T) {
+	t.Parallel()
+
+	replicas := int32(2)
+
+	type testCase struct {
+		Replicas           *int32
+		Generation         int64
+		ObservedGeneration int64
+		UpdatedReplicas    int32
+		ReadyReplicas      int32
+		Want               bool
+	}
+
+	testCases := []testCase{
+		{Replicas: &replicas, Generation: 2, ObservedGeneration: 2, UpdatedReplicas: 2, ReadyReplicas: 2, Want: true},
+		// new spec was not observed by controller
+		{Replicas: &replicas, Generation: 3, ObservedGeneration: 2, UpdatedReplicas: 2, ReadyReplicas: 2, Want: false},
+		// rollout in progress
+		{Replicas: &replicas, Generation: 2, ObservedGeneration: 2, UpdatedReplicas: 1, ReadyReplicas: 2, Want: false},
+		{Replicas: &replicas, Generation: 2, ObservedGeneration: 2, UpdatedReplicas: 2, ReadyReplicas: 1, Want: false},
+		// default replicas is 1
+		{Replicas: nil, Generation: 1, ObservedGeneration: 1, UpdatedReplicas: 1, ReadyReplicas: 1, Want: true},
+	}
+
+	for i, testCase := range testCases {
+		workload := api.NewDeployProgressWorkload(
+			"Deployment",
+			metav1.ObjectMeta{Name: "test", Generation: testCase.Generation},
+			testCase.Replicas,
+			testCase.ObservedGeneration,
+			testCase.UpdatedReplicas,
+			testCase.ReadyReplicas,
+		)
+
+		if workload.Ready != testCase.Want {
+			t.Errorf("(case %d) got %v, want %v", i, workload.Ready, testCase.Want)
+		}
+	}
+}
@@ -14,11 +14,11 @@ package api
 
 import (
 	"context"
+	"strconv"
 
-	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/pkg/errors"
-	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 type GetGitlabPipelinesStatusResults struct {
@@ -27,58 +27,77 @@ type GetGitlabPipelinesStatusResults struct {
 	LastSuccessPipeline string
 }
 
+func (r *GetGitlabPipelinesStatusResults) setStatus(status, url string) {
+	switch status {
+	case "running":
+		r.LastRunningPipeline = url
+	case "success":
+		r.LastSuccessPipeline = url
+	case "failed":
+		r.LastErrorPipeline = url
+	}
+}
+
 const GetGitlabPipelinesStatusMaxLimit = 20
 
 func (e *Environment) GetGitlabPipelinesStatus(ctx context.Context, projectID string) (*GetGitlabPipelinesStatusResults, error) {
 	ctx, span := telemetry.Start(ctx, "api.GetGitlabPipelinesStatus")
 	defer span.End()
 
-	if e.gitlabClient == nil {
-		return nil, errNoGitlabClient
+	result := GetGitlabPipelinesStatusResults{}
+
+	pipeline, err := e.getLastEnvironmentPipeline(ctx, projectID)
+	if err != nil {
+		return nil, err
 	}
 
-	result := GetGitlabPipelinesStatusResults{}
+	if pipeline != nil {
+		result.setStatus(string(pipeline.Status), pipeline.WebURL)
+	}
+
+	return &result, nil
+}
+
+// returns last pipeline of project that was created for environment, nil if not found.
+func (e *Environment) getLastEnvironmentPipeline(ctx context.Context, projectID string) (*scm.Pipeline, error) {
+	ctx, span := telemetry.Start(ctx, "api.getLastEnvironmentPipeline")
+	defer span.End()
+
+	if e.scmProvider == nil {
+		return nil, errNoSCMProvider
+	}
+
+	// use pipeline status from GitLab webhook if it was received
+	if pipeline := e.GetGitlabWebhookPipeline(ctx, projectID); pipeline != nil {
+		return &scm.Pipeline{
+			ID:     strconv.FormatInt(pipeline.PipelineID, 10),
+			Ref:    pipeline.Ref,
+			Status: scm.PipelineStatus(pipeline.Status),
+			WebURL: pipeline.URL,
+		}, nil
+	}
 
 	// return last 20 project pipelines, that was created by API
-	projectPipelines, _, err := e.gitlabClient.Pipelines.ListProjectPipelines(
-		projectID,
-		&gitlab.ListProjectPipelinesOptions{
-			ListOptions: gitlab.ListOptions{
-				Page:    1,
-				PerPage: GetGitlabPipelinesStatusMaxLimit,
-			},
-			Source:   gitlab.Ptr("api"),
-			OrderBy:  gitlab.Ptr("id"),
-			Sort:     gitlab.Ptr("desc"),
-			Username: config.Get().GitlabTokenUser,
-		},
-		gitlab.WithContext(ctx),
-	)
+	projectPipelines, err := e.scmProvider.ListPipelines(ctx, &scm.ListPipelinesInput{
+		ProjectID: projectID,
+		Limit:     GetGitlabPipelinesStatusMaxLimit,
+		Triggered: true,
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get project pipelines")
 	}
 
 	for _, projectPipeline := range projectPipelines {
-		pipelineVars, err := GetCachedGitlabPipelineVariables(ctx, projectID, projectPipeline.ID)
+		pipelineVars, err := GetCachedPipelineVariables(ctx, projectID, projectPipeline.ID)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get project pipeline variables")
 		}
 
-		for _, pipelineVar := range pipelineVars {
-			if pipelineVar.Key == gitlabNamespaceKey && pipelineVar.Value == e.Namespace {
-				switch projectPipeline.Status {
-				case "running":
-					result.LastRunningPipeline = projectPipeline.WebURL
-				case "success":
-					result.LastSuccessPipeline = projectPipeline.WebURL
-				case "failed":
-					result.LastErrorPipeline = projectPipeline.WebURL
-				}
-				// use only first pipeline
-				return &result, nil
-			}
+		// use only first pipeline
+		if pipelineVars[gitlabNamespaceKey] == e.Namespace {
+			return projectPipeline, nil
 		}
 	}
 
-	return &result, nil
+	return nil, nil //nolint:nilnil
 }
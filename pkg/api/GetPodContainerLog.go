@@ -13,21 +13,37 @@ limitations under the License.
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"strconv"
+	"sync"
 
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 const defaultLogLines = 100
 
+var errNoLogPods = errors.New("no pods found")
+
 type GetPodContainerLogRequest struct {
-	Pod        string
-	Container  string
-	TailLines  *int64
-	Timestamps bool
+	Pod          string
+	Container    string
+	TailLines    *int64
+	SinceSeconds *int64
+	Timestamps   bool
+	Previous     bool
+	Follow       bool
+	// logs of all pods that match label selector
+	Selector string
 }
 
 func (l *GetPodContainerLogRequest) SetTimestamps(value string) {
@@ -38,8 +54,51 @@ func (l *GetPodContainerLogRequest) SetTimestamps(value string) {
 	}
 }
 
+func (l *GetPodContainerLogRequest) SetPrevious(value string) {
+	l.Previous = value == "true"
+}
+
+func (l *GetPodContainerLogRequest) SetFollow(value string) {
+	l.Follow = value == "true"
+}
+
+func (l *GetPodContainerLogRequest) SetTailLines(value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+
+	tailLines, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || tailLines < 0 {
+		return errors.Errorf("tailLines %s is not valid", value)
+	}
+
+	l.TailLines = &tailLines
+
+	return nil
+}
+
+func (l *GetPodContainerLogRequest) SetSinceSeconds(value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+
+	sinceSeconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || sinceSeconds <= 0 {
+		return errors.Errorf("sinceSeconds %s is not valid", value)
+	}
+
+	l.SinceSeconds = &sinceSeconds
+
+	return nil
+}
+
 func (l *GetPodContainerLogRequest) GetTailLines() *int64 {
 	if l.TailLines == nil {
+		// all logs since time
+		if l.SinceSeconds != nil {
+			return nil
+		}
+
 		tailLines := int64(defaultLogLines)
 
 		return &tailLines
@@ -48,29 +107,194 @@ func (l *GetPodContainerLogRequest) GetTailLines() *int64 {
 	return l.TailLines
 }
 
+func (l *GetPodContainerLogRequest) podLogOptions(container string) *corev1.PodLogOptions {
+	return &corev1.PodLogOptions{
+		Container:    container,
+		Follow:       l.Follow,
+		Previous:     l.Previous,
+		TailLines:    l.GetTailLines(),
+		SinceSeconds: l.SinceSeconds,
+		Timestamps:   l.Timestamps,
+	}
+}
+
 func (e *Environment) GetPodContainerLog(ctx context.Context, input *GetPodContainerLogRequest) (string, error) {
 	ctx, span := telemetry.Start(ctx, "api.GetPodContainerLog")
 	defer span.End()
 
-	podLogOptions := corev1.PodLogOptions{
-		Container:  input.Container,
-		Follow:     false,
-		TailLines:  input.GetTailLines(),
-		Timestamps: input.Timestamps,
+	// one-shot logs can not be followed
+	input.Follow = false
+
+	buf := new(bytes.Buffer)
+
+	if err := e.StreamPodContainerLog(ctx, input, buf); err != nil {
+		return "", err
 	}
 
-	podLogs, err := e.clientset.CoreV1().Pods(e.Namespace).GetLogs(input.Pod, &podLogOptions).Stream(ctx)
+	return buf.String(), nil
+}
+
+// write logs of pod container or logs of all pods that match selector,
+// lines of different pods are prefixed with pod name, stops when context is done.
+func (e *Environment) StreamPodContainerLog(ctx context.Context, input *GetPodContainerLogRequest, w io.Writer) error {
+	ctx, span := telemetry.Start(ctx, "api.StreamPodContainerLog")
+	defer span.End()
+
+	if len(input.Selector) == 0 {
+		podLogs, err := e.clientset.CoreV1().Pods(e.Namespace).GetLogs(input.Pod, input.podLogOptions(input.Container)).Stream(ctx)
+		if err != nil {
+			return err
+		}
+		defer podLogs.Close()
+
+		if _, err := io.Copy(w, podLogs); err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		return nil
+	}
+
+	pods, err := e.clientset.CoreV1().Pods(e.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: input.Selector,
+	})
 	if err != nil {
-		return "", err
+		return errors.Wrap(err, "can not list pods")
 	}
-	defer podLogs.Close()
 
-	buf := new(bytes.Buffer)
+	if len(pods.Items) == 0 {
+		return errors.Wrap(errNoLogPods, input.Selector)
+	}
 
-	_, err = io.Copy(buf, podLogs)
+	writer := &logWriter{w: w}
+
+	var wg sync.WaitGroup
+
+	// containers that logs are streamed
+	started := make(map[string]bool)
+
+	streamPod := func(pod *corev1.Pod) {
+		for _, container := range pod.Spec.Containers {
+			if len(input.Container) > 0 && container.Name != input.Container {
+				continue
+			}
+
+			key := pod.Name + "/" + container.Name
+			if started[key] {
+				continue
+			}
+
+			started[key] = true
+
+			prefix := pod.Name
+			if len(input.Container) == 0 && len(pod.Spec.Containers) > 1 {
+				prefix += "/" + container.Name
+			}
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				podLogs, err := e.clientset.CoreV1().Pods(e.Namespace).GetLogs(pod.Name, input.podLogOptions(container.Name)).Stream(ctx)
+				if err != nil {
+					writer.WriteLine(prefix, []byte(fmt.Sprintf("error: %s\n", err.Error())))
+
+					return
+				}
+				defer podLogs.Close()
+
+				if err := copyLogLines(prefix, podLogs, writer); err != nil && ctx.Err() == nil {
+					log.WithError(err).Warnf("error reading logs of %s", prefix)
+				}
+			}()
+		}
+	}
+
+	for i := range pods.Items {
+		// logs of pending pods will be followed when pods are started
+		if input.Follow && pods.Items[i].Status.Phase == corev1.PodPending {
+			continue
+		}
+
+		streamPod(&pods.Items[i])
+	}
+
+	if input.Follow {
+		if err := e.watchLogPods(ctx, input.Selector, pods.ResourceVersion, streamPod); err != nil {
+			writer.WriteLine(config.Namespace, []byte(fmt.Sprintf("error: new pods are not followed: %s\n", err.Error())))
+		}
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// calls streamPod for started pods that match selector until context is done.
+func (e *Environment) watchLogPods(ctx context.Context, selector, resourceVersion string, streamPod func(pod *corev1.Pod)) error {
+	watcher, err := e.clientset.CoreV1().Pods(e.Namespace).Watch(ctx, metav1.ListOptions{
+		LabelSelector:   selector,
+		ResourceVersion: resourceVersion,
+	})
 	if err != nil {
-		return "", err
+		return errors.Wrap(err, "can not watch pods")
 	}
+	defer watcher.Stop()
 
-	return buf.String(), nil
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return errors.New("watch of pods was closed")
+			}
+
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+
+			if pod, ok := event.Object.(*corev1.Pod); ok && pod.Status.Phase != corev1.PodPending {
+				streamPod(pod)
+			}
+		}
+	}
+}
+
+// merges lines of many logs into one writer.
+type logWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (l *logWriter) WriteLine(prefix string, line []byte) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	_, _ = fmt.Fprintf(l.w, "[%s] %s", prefix, line)
+}
+
+// copy lines with prefix, last line without new line is also copied.
+func copyLogLines(prefix string, r io.Reader, writer *logWriter) error {
+	reader := bufio.NewReader(r)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+
+		if len(line) > 0 {
+			if line[len(line)-1] != '\n' {
+				line = append(line, '\n')
+			}
+
+			writer.WriteLine(prefix, line)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
 }
@@ -0,0 +1,74 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+)
+
+func TestCopyLogLines(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+
+	if err := api.CopyLogLines("pod-1", strings.NewReader("line1\nline2\nline3"), buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[pod-1] line1\n[pod-1] line2\n[pod-1] line3\n"
+
+	if buf.String() != want {
+		t.Fatalf("want=%q,got=%q", want, buf.String())
+	}
+}
+
+func TestGetPodContainerLogRequest(t *testing.T) {
+	t.Parallel()
+
+	request := api.GetPodContainerLogRequest{}
+
+	if tailLines := request.GetTailLines(); tailLines == nil || *tailLines != 100 {
+		t.Fatal("default tailLines must be 100")
+	}
+
+	if err := request.SetSinceSeconds("60"); err != nil {
+		t.Fatal(err)
+	}
+
+	// all logs since time
+	if request.GetTailLines() != nil {
+		t.Fatal("tailLines must be nil with sinceSeconds")
+	}
+
+	if err := request.SetTailLines("10"); err != nil {
+		t.Fatal(err)
+	}
+
+	if *request.GetTailLines() != 10 {
+		t.Fatal("tailLines must be 10")
+	}
+
+	for _, value := range []string{"-1", "bad"} {
+		if err := request.SetTailLines(value); err == nil {
+			t.Fatalf("tailLines %s must be invalid", value)
+		}
+	}
+
+	if err := request.SetSinceSeconds("0"); err == nil {
+		t.Fatal("sinceSeconds 0 must be invalid")
+	}
+}
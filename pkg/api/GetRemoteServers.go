@@ -19,9 +19,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/hetznercloud/hcloud-go/hcloud"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
 	"github.com/pkg/errors"
@@ -31,13 +31,19 @@ import (
 type GetRemoteServerItemStatus string
 
 const (
-	lastPowerOnTimeLabel = "lastPowerOnTime"
-	staledTime           = 14 * 24 * time.Hour
+	lastPowerOnTimeLabel  = "lastPowerOnTime"
+	lastPowerOffTimeLabel = "lastPowerOffTime"
+	// time when owner was warned that server is staled
+	staledWarningTimeLabel = "staledWarningTime"
+	ownerLabel             = "owner"
+	templateLabel          = "template"
+	// running hours of month, label is added on power off, for example runningHours202402
+	runningHoursLabelPrefix = "runningHours"
 )
 
 const (
-	GetRemoteServerItemStatusRunning GetRemoteServerItemStatus = "Running"
-	GetRemoteServerItemStatusStoped  GetRemoteServerItemStatus = "Stoped"
+	GetRemoteServerItemStatusRunning = GetRemoteServerItemStatus(remoteserver.ServerStatusRunning)
+	GetRemoteServerItemStatusStoped  = GetRemoteServerItemStatus(remoteserver.ServerStatusStopped)
 )
 
 type GetRemoteServerLabel struct {
@@ -49,17 +55,25 @@ type GetRemoteServerLabel struct {
 func (l *GetRemoteServerLabel) ValidKey() bool {
 	validKeys := []string{
 		lastPowerOnTimeLabel,
+		staledWarningTimeLabel,
+		idleWarningTimeLabel,
+		scheduleLabel,
 	}
 
 	return slices.Contains(validKeys, l.Key)
 }
 
 type GetRemoteServerItem struct {
-	Cloud           string
-	ID              string
-	Name            string
+	// name of provider in config
+	Cloud string
+	ID    string
+	// owner of server or name of server if owner is not set
+	Name string
+	// name of server in provider
+	ServerName      string
 	Status          GetRemoteServerItemStatus
 	IPv4            string
+	ServerType      string
 	Created         time.Time
 	Labels          map[string]string
 	FormattedLabels []*GetRemoteServerLabel
@@ -75,89 +89,215 @@ func (i *GetRemoteServerItem) GetLastPowerOnTime() (time.Time, error) {
 	return utils.UnixToTime(lastPowerOnTimeString)
 }
 
+// time when running server was started, server can be powered on outside of manager after last power off.
+func (i *GetRemoteServerItem) getRunningSince() (time.Time, error) {
+	lastPowerOnTime, err := i.GetLastPowerOnTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if lastPowerOffTime, ok := i.getLabelTime(lastPowerOffTimeLabel); ok && lastPowerOffTime.After(lastPowerOnTime) {
+		return lastPowerOffTime, nil
+	}
+
+	return lastPowerOnTime, nil
+}
+
+func getRunningHoursLabel(month time.Time) string {
+	return runningHoursLabelPrefix + month.UTC().Format("200601")
+}
+
+// running hours of month from label, running period is counted till now.
+func (i *GetRemoteServerItem) GetRunningHours(now, monthStart, monthEnd time.Time) float64 {
+	result := 0.0
+
+	if value, ok := i.Labels[getRunningHoursLabel(monthStart)]; ok {
+		hours, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.WithError(err).Warnf("error parsing running hours of server %s", i.ID)
+		}
+
+		result = hours
+	}
+
+	if i.Status != GetRemoteServerItemStatusRunning {
+		return result
+	}
+
+	if runningSince, err := i.getRunningSince(); err == nil {
+		result += getHoursInMonth(runningSince, now, monthStart, monthEnd)
+	}
+
+	return result
+}
+
+// labels with running hours of months that are added on power off of running server.
+func (i *GetRemoteServerItem) getRunningHoursLabels(now time.Time) map[string]string {
+	result := make(map[string]string)
+
+	if i.Status != GetRemoteServerItemStatusRunning {
+		return result
+	}
+
+	runningSince, err := i.getRunningSince()
+	if err != nil {
+		return result
+	}
+
+	usage := CostUsage{}
+	usage.AddRunningTime(runningSince, now)
+
+	for month := range usage.RunningHours {
+		monthStart, err := time.Parse(costMonthFormat, month)
+		if err != nil {
+			continue
+		}
+
+		hours := i.GetRunningHours(now, monthStart, monthStart.AddDate(0, 1, 0))
+
+		result[getRunningHoursLabel(monthStart)] = strconv.FormatFloat(hours, 'f', 2, 64)
+	}
+
+	return result
+}
+
 func (i *GetRemoteServerItem) IsStaled() bool {
 	lastPowerOnTime, err := i.GetLastPowerOnTime()
 	if err != nil {
 		return false
 	}
 
-	return time.Since(lastPowerOnTime) > staledTime
+	staledDays := config.Get().RemoteServer.StaledDays
+
+	return staledDays > 0 && time.Since(lastPowerOnTime) > time.Duration(staledDays)*config.HoursInDay*time.Hour
+}
+
+func (i *GetRemoteServerItem) GetOwner() string {
+	return i.Labels[ownerLabel]
+}
+
+// returns time when owner was warned about staled server, warning before last power on is ignored.
+func (i *GetRemoteServerItem) GetStaledWarningTime() (time.Time, bool) {
+	warningTime, err := utils.UnixToTime(i.Labels[staledWarningTimeLabel])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	lastPowerOnTime, err := i.GetLastPowerOnTime()
+	if err != nil || lastPowerOnTime.After(warningTime) {
+		return time.Time{}, false
+	}
+
+	return warningTime, true
 }
 
-var errNoHetznerCloudToken = errors.New("no hetzner cloud token, please set it in config file")
+var errNoRemoteServerProviders = errors.New("no remote server providers, please set it in config file")
 
-// return all remote servers.
+// return remote servers of all providers.
 func GetRemoteServers(ctx context.Context) ([]*GetRemoteServerItem, error) {
 	ctx, span := telemetry.Start(ctx, "api.GetRemoteServers")
 	defer span.End()
 
-	if len(config.Get().RemoteServer.HetznerToken) == 0 {
-		return nil, errNoHetznerCloudToken
+	providers := client.GetRemoteServerProviders()
+	if len(providers) == 0 {
+		return nil, errNoRemoteServerProviders
 	}
 
-	hcloundClient := client.GetHcloudClient()
-
 	result := make([]*GetRemoteServerItem, 0)
 
-	servers, err := hcloundClient.Server.All(ctx)
-	if err != nil {
-		return result, errors.Wrap(err, "can not get servers")
-	}
+	for _, providerName := range providers {
+		provider, err := client.GetRemoteServerProvider(providerName)
+		if err != nil {
+			return nil, err
+		}
 
-	for _, server := range servers {
-		status := GetRemoteServerItemStatusStoped
+		servers, err := provider.ListServers(ctx)
+		if err != nil {
+			// servers of other providers are still returned
+			log.WithError(err).Errorf("can not get servers of %s", providerName)
 
-		if server.Status == hcloud.ServerStatusRunning {
-			status = GetRemoteServerItemStatusRunning
+			continue
+		}
+
+		for _, server := range servers {
+			result = append(result, newRemoteServerItem(ctx, providerName, server))
 		}
+	}
 
-		serverName := server.Name
+	return result, nil
+}
+
+func newRemoteServerItem(ctx context.Context, cloud string, server *remoteserver.Server) *GetRemoteServerItem {
+	serverName := server.Name
+
+	if owner, ok := server.Labels[ownerLabel]; ok {
+		serverName = owner
+	}
+
+	item := &GetRemoteServerItem{
+		Cloud:           cloud,
+		ID:              server.ID,
+		Name:            serverName,
+		ServerName:      server.Name,
+		Status:          GetRemoteServerItemStatus(server.Status),
+		Created:         server.Created,
+		IPv4:            server.IPv4,
+		ServerType:      server.ServerType,
+		Labels:          server.Labels,
+		FormattedLabels: getRemoteServerLabels(server.Labels),
+	}
 
-		if owner, ok := server.Labels["owner"]; ok {
-			serverName = owner
+	links := make([]*config.OtherLink, len(config.Get().RemoteServer.Links))
+	for id, link := range config.Get().RemoteServer.Links {
+		links[id] = &config.OtherLink{
+			Name:        link.Name,
+			Description: link.Description,
 		}
 
-		item := &GetRemoteServerItem{
-			Cloud:           "hcloud",
-			ID:              strconv.Itoa(server.ID),
-			Name:            serverName,
-			Status:          status,
-			Created:         server.Created,
-			IPv4:            server.PublicNet.IPv4.IP.String(),
-			Labels:          server.Labels,
-			FormattedLabels: getRemoteServerLabels(server.Labels),
+		urlFormatted, err := utils.GetTemplatedResult(ctx, link.URL, item)
+		if err != nil {
+			log.WithError(err).Errorf("error parsing link %s", link.URL)
+			links[id].URL = link.URL
+		} else {
+			links[id].URL = string(urlFormatted)
 		}
+	}
 
-		links := make([]*config.OtherLink, len(config.Get().RemoteServer.Links))
-		for id, link := range config.Get().RemoteServer.Links {
-			links[id] = &config.OtherLink{
-				Name:        link.Name,
-				Description: link.Description,
-			}
+	item.Links = links
 
-			urlFormatted, err := utils.GetTemplatedResult(ctx, link.URL, item)
-			if err != nil {
-				log.WithError(err).Errorf("error parsing link %s", link.URL)
-				links[id].URL = link.URL
-			} else {
-				links[id].URL = string(urlFormatted)
-			}
-		}
+	if item.IsStaled() {
+		item.FormattedLabels = append(item.FormattedLabels, &GetRemoteServerLabel{
+			Key:         "staled",
+			Value:       "true",
+			Description: "server is staled",
+		})
+	}
 
-		item.Links = links
+	return item
+}
 
-		if item.IsStaled() {
-			item.FormattedLabels = append(item.FormattedLabels, &GetRemoteServerLabel{
-				Key:         "staled",
-				Value:       "true",
-				Description: "server is staled",
-			})
-		}
+// returns server by ID.
+func GetRemoteServer(ctx context.Context, cloud, id string) (*GetRemoteServerItem, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetRemoteServer")
+	defer span.End()
 
-		result = append(result, item)
+	provider, err := client.GetRemoteServerProvider(cloud)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	servers, err := provider.ListServers(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get servers")
+	}
+
+	for _, server := range servers {
+		if server.ID == id {
+			return newRemoteServerItem(ctx, cloud, server), nil
+		}
+	}
+
+	return nil, errors.Wrap(remoteserver.ErrNotFound, id)
 }
 
 func getRemoteServerLabels(labels map[string]string) []*GetRemoteServerLabel {
@@ -189,8 +329,24 @@ func getRemoteServerLabels(labels map[string]string) []*GetRemoteServerLabel {
 			item.Value, item.Description = formatUnixTime(v)
 		}
 
-		if item.Key == lastPowerOnTimeLabel {
+		if k == scheduleLabel {
+			schedule, ok := newRemoteServerSchedule(labels)
+			if !ok {
+				continue
+			}
+
+			item.Value, item.Description = schedule.String(), schedule.Timezone
+		}
+
+		switch item.Key {
+		case lastPowerOnTimeLabel:
 			item.Key = badgeLastStarted
+		case staledWarningTimeLabel:
+			item.Key = "Staled warning"
+		case idleWarningTimeLabel:
+			item.Key = "Idle warning"
+		case scheduleLabel:
+			item.Key = "Schedule"
 		}
 
 		result = append(result, item)
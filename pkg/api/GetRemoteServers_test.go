@@ -0,0 +1,78 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+)
+
+func TestRemoteServerStaled(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	day := 24 * time.Hour
+
+	type testCase struct {
+		Labels   map[string]string
+		Staled   bool
+		Warned   bool
+		Scenario string
+	}
+
+	testCases := []testCase{
+		{
+			Labels:   map[string]string{"lastPowerOnTime": utils.TimeToUnix(now.Add(-day))},
+			Scenario: "server was used recently",
+		},
+		{
+			Labels:   map[string]string{"lastPowerOnTime": utils.TimeToUnix(now.Add(-20 * day))},
+			Staled:   true,
+			Scenario: "server was not used",
+		},
+		{
+			Labels: map[string]string{
+				"lastPowerOnTime":   utils.TimeToUnix(now.Add(-20 * day)),
+				"staledWarningTime": utils.TimeToUnix(now.Add(-day)),
+			},
+			Staled:   true,
+			Warned:   true,
+			Scenario: "owner was warned",
+		},
+		{
+			Labels: map[string]string{
+				"lastPowerOnTime":   utils.TimeToUnix(now.Add(-day)),
+				"staledWarningTime": utils.TimeToUnix(now.Add(-2 * day)),
+			},
+			Scenario: "server was powered on after warning",
+		},
+	}
+
+	for _, testCase := range testCases {
+		server := api.GetRemoteServerItem{
+			Created: now.Add(-30 * day),
+			Labels:  testCase.Labels,
+		}
+
+		if staled := server.IsStaled(); staled != testCase.Staled {
+			t.Errorf("%s: want staled %t, got %t", testCase.Scenario, testCase.Staled, staled)
+		}
+
+		if _, warned := server.GetStaledWarningTime(); warned != testCase.Warned {
+			t.Errorf("%s: want warned %t, got %t", testCase.Scenario, testCase.Warned, warned)
+		}
+	}
+}
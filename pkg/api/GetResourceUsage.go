@@ -0,0 +1,348 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/cache"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/metrics"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const usageOverviewCacheKey = "kubernetes::usage::overview"
+
+// PodMetrics from metrics.k8s.io/v1beta1 API.
+type PodMetrics struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Containers        []ContainerMetrics `json:"containers"`
+}
+
+type ContainerMetrics struct {
+	Name  string              `json:"name"`
+	Usage corev1.ResourceList `json:"usage"`
+}
+
+type PodMetricsList struct {
+	Items []PodMetrics `json:"items"`
+}
+
+// requested and used CPU cores and memory bytes.
+type ResourceUsage struct {
+	CPURequests    float64
+	CPUUsage       float64
+	MemoryRequests float64
+	MemoryUsage    float64
+	// usage divided by requests, 0 if requests are not set
+	CPURatio    float64
+	MemoryRatio float64
+	// usage is less than OverRequestRatio of requests
+	CPUOverRequested    bool
+	MemoryOverRequested bool
+}
+
+func (u *ResourceUsage) add(other ResourceUsage) {
+	u.CPURequests += other.CPURequests
+	u.CPUUsage += other.CPUUsage
+	u.MemoryRequests += other.MemoryRequests
+	u.MemoryUsage += other.MemoryUsage
+}
+
+// calculate ratios and over-request flags.
+func (u *ResourceUsage) calculate(overRequestRatio float64) {
+	ratio := func(usage, requests float64) float64 {
+		if requests == 0 {
+			return 0
+		}
+
+		const percent = 100
+
+		return math.Round(usage/requests*percent) / percent
+	}
+
+	u.CPURatio = ratio(u.CPUUsage, u.CPURequests)
+	u.MemoryRatio = ratio(u.MemoryUsage, u.MemoryRequests)
+	u.CPUOverRequested = u.CPURequests > 0 && u.CPUUsage/u.CPURequests < overRequestRatio
+	u.MemoryOverRequested = u.MemoryRequests > 0 && u.MemoryUsage/u.MemoryRequests < overRequestRatio
+}
+
+func (u *ResourceUsage) IsOverRequested() bool {
+	return u.CPUOverRequested || u.MemoryOverRequested
+}
+
+type ContainerUsage struct {
+	ResourceUsage
+	Name string
+}
+
+type PodUsage struct {
+	ResourceUsage
+	Environment string
+	Name        string
+	// name of deployment or statefulset of pod
+	Workload   string
+	Containers []*ContainerUsage
+}
+
+type EnvironmentUsage struct {
+	ResourceUsage
+	ID        string
+	Cluster   string
+	Namespace string
+	Pods      []*PodUsage
+}
+
+// usage of all environments.
+type UsageOverview struct {
+	Environments []*EnvironmentUsage
+	// pods with biggest usage in all environments
+	TopCPUPods    []*PodUsage
+	TopMemoryPods []*PodUsage
+	OverRequested []*PodUsage
+}
+
+// returns deployment or statefulset of pod, name of pod if pod has no owner.
+func getPodWorkload(pod corev1.Pod) string {
+	for _, owner := range pod.OwnerReferences {
+		// replicaset name is deployment name with pod-template-hash
+		if hash, ok := pod.Labels["pod-template-hash"]; ok && owner.Kind == "ReplicaSet" {
+			return strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+
+		return owner.Name
+	}
+
+	return pod.Name
+}
+
+// join requests of pods with usage from metrics, pods without metrics are ignored.
+func newEnvironmentUsage(pods []corev1.Pod, podMetrics []PodMetrics, overRequestRatio float64) *EnvironmentUsage {
+	result := EnvironmentUsage{
+		Pods: make([]*PodUsage, 0),
+	}
+
+	usageByPod := make(map[string]map[string]corev1.ResourceList)
+
+	for _, item := range podMetrics {
+		containers := make(map[string]corev1.ResourceList)
+
+		for _, container := range item.Containers {
+			containers[container.Name] = container.Usage
+		}
+
+		usageByPod[item.Name] = containers
+	}
+
+	for _, pod := range pods {
+		containersUsage, ok := usageByPod[pod.Name]
+		if !ok {
+			continue
+		}
+
+		podUsage := PodUsage{
+			Name:       pod.Name,
+			Workload:   getPodWorkload(pod),
+			Containers: make([]*ContainerUsage, 0, len(pod.Spec.Containers)),
+		}
+
+		for _, container := range pod.Spec.Containers {
+			usage := containersUsage[container.Name]
+
+			containerUsage := ContainerUsage{
+				Name: container.Name,
+				ResourceUsage: ResourceUsage{
+					CPURequests:    container.Resources.Requests.Cpu().AsApproximateFloat64(),
+					CPUUsage:       usage.Cpu().AsApproximateFloat64(),
+					MemoryRequests: container.Resources.Requests.Memory().AsApproximateFloat64(),
+					MemoryUsage:    usage.Memory().AsApproximateFloat64(),
+				},
+			}
+
+			containerUsage.calculate(overRequestRatio)
+			podUsage.add(containerUsage.ResourceUsage)
+			podUsage.Containers = append(podUsage.Containers, &containerUsage)
+		}
+
+		podUsage.calculate(overRequestRatio)
+		result.add(podUsage.ResourceUsage)
+		result.Pods = append(result.Pods, &podUsage)
+	}
+
+	result.calculate(overRequestRatio)
+
+	slices.SortFunc(result.Pods, func(a, b *PodUsage) int {
+		return cmp.Or(cmp.Compare(b.CPUUsage, a.CPUUsage), cmp.Compare(a.Name, b.Name))
+	})
+
+	return &result
+}
+
+// actual usage of pods and containers in environment.
+func (e *Environment) GetResourceUsage(ctx context.Context) (*EnvironmentUsage, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetResourceUsage")
+	defer span.End()
+
+	pods, err := GetCachedKubernetesPodsStatus(ctx, e.Cluster, e.Namespace, PodIsRunning)
+	if err != nil {
+		return nil, errors.Wrap(err, "error list pods")
+	}
+
+	podMetrics, err := GetCachedPodMetrics(ctx, e.Cluster, e.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newEnvironmentUsage(pods, podMetrics, config.Get().ResourceUsage.OverRequestRatio)
+	result.ID = e.ID
+	result.Cluster = e.Cluster
+	result.Namespace = e.Namespace
+
+	for _, pod := range result.Pods {
+		pod.Environment = e.ID
+	}
+
+	return result, nil
+}
+
+func newUsageOverview(environments []*EnvironmentUsage, topConsumers int) *UsageOverview {
+	result := UsageOverview{
+		Environments:  environments,
+		TopCPUPods:    make([]*PodUsage, 0),
+		TopMemoryPods: make([]*PodUsage, 0),
+		OverRequested: make([]*PodUsage, 0),
+	}
+
+	for _, environment := range environments {
+		result.TopCPUPods = append(result.TopCPUPods, environment.Pods...)
+		result.TopMemoryPods = append(result.TopMemoryPods, environment.Pods...)
+
+		for _, pod := range environment.Pods {
+			if pod.IsOverRequested() {
+				result.OverRequested = append(result.OverRequested, pod)
+			}
+		}
+	}
+
+	slices.SortFunc(result.Environments, func(a, b *EnvironmentUsage) int {
+		return cmp.Or(cmp.Compare(b.CPUUsage, a.CPUUsage), cmp.Compare(a.ID, b.ID))
+	})
+
+	slices.SortFunc(result.TopCPUPods, func(a, b *PodUsage) int {
+		return cmp.Compare(b.CPUUsage, a.CPUUsage)
+	})
+
+	slices.SortFunc(result.TopMemoryPods, func(a, b *PodUsage) int {
+		return cmp.Compare(b.MemoryUsage, a.MemoryUsage)
+	})
+
+	// biggest unused requests first
+	slices.SortFunc(result.OverRequested, func(a, b *PodUsage) int {
+		return cmp.Compare(b.CPURequests-b.CPUUsage, a.CPURequests-a.CPUUsage)
+	})
+
+	limit := func(pods []*PodUsage) []*PodUsage {
+		if len(pods) > topConsumers {
+			return pods[:topConsumers]
+		}
+
+		return pods
+	}
+
+	result.TopCPUPods = limit(result.TopCPUPods)
+	result.TopMemoryPods = limit(result.TopMemoryPods)
+
+	return &result
+}
+
+// usage of all environments with top consumers, environments without metrics are ignored.
+func GetUsageOverview(ctx context.Context) (*UsageOverview, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetUsageOverview")
+	defer span.End()
+
+	cacheValue := UsageOverview{}
+
+	if err := cache.Client().Get(ctx, usageOverviewCacheKey, &cacheValue); err == nil {
+		metrics.CacheHits.WithLabelValues("GetUsageOverview").Inc()
+
+		return &cacheValue, nil
+	}
+
+	environments, err := GetEnvironments(ctx, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing environments")
+	}
+
+	environmentsUsage := make([]*EnvironmentUsage, 0, len(environments))
+
+	for _, environment := range environments {
+		usage, err := environment.GetResourceUsage(ctx)
+		if err != nil {
+			log.WithError(err).WithField("environment", environment.ID).Warn("error getting resource usage")
+
+			continue
+		}
+
+		environmentsUsage = append(environmentsUsage, usage)
+	}
+
+	result := newUsageOverview(environmentsUsage, config.Get().ResourceUsage.TopConsumers)
+
+	_ = cache.Client().Set(ctx, usageOverviewCacheKey, result, cache.MiddleTTL)
+
+	return result, nil
+}
+
+// update prometheus gauges with usage and requests of pods.
+func UpdateUsageMetrics(ctx context.Context) error {
+	ctx, span := telemetry.Start(ctx, "api.UpdateUsageMetrics")
+	defer span.End()
+
+	overview, err := GetUsageOverview(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting usage overview")
+	}
+
+	// remove deleted pods
+	metrics.PodResourceUsage.Reset()
+	metrics.PodResourceRequests.Reset()
+	metrics.PodOverRequested.Reset()
+
+	for _, environment := range overview.Environments {
+		for _, pod := range environment.Pods {
+			labels := []string{environment.Cluster, environment.Namespace, pod.Name, pod.Workload}
+
+			metrics.PodResourceUsage.WithLabelValues(append(labels, "cpu")...).Set(pod.CPUUsage)
+			metrics.PodResourceUsage.WithLabelValues(append(labels, "memory")...).Set(pod.MemoryUsage)
+			metrics.PodResourceRequests.WithLabelValues(append(labels, "cpu")...).Set(pod.CPURequests)
+			metrics.PodResourceRequests.WithLabelValues(append(labels, "memory")...).Set(pod.MemoryRequests)
+
+			overRequested := 0.0
+			if pod.IsOverRequested() {
+				overRequested = 1
+			}
+
+			metrics.PodOverRequested.WithLabelValues(labels...).Set(overRequested)
+		}
+	}
+
+	return nil
+}
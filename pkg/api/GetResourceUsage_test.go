@@ -0,0 +1,122 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newUsagePod(name, cpu, memory string, owner metav1.OwnerReference, labels map[string]string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(cpu),
+							corev1.ResourceMemory: resource.MustParse(memory),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newUsageMetrics(name, cpu, memory string) api.PodMetrics {
+	return api.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Containers: []api.ContainerMetrics{
+			{
+				Name: "app",
+				Usage: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		},
+	}
+}
+
+func TestResourceUsage(t *testing.T) {
+	t.Parallel()
+
+	pods := []corev1.Pod{
+		newUsagePod("backend-5d8f7c-abcde", "1", "1Gi",
+			metav1.OwnerReference{Kind: "ReplicaSet", Name: "backend-5d8f7c"},
+			map[string]string{"pod-template-hash": "5d8f7c"},
+		),
+		newUsagePod("mysql-0", "500m", "512Mi",
+			metav1.OwnerReference{Kind: "StatefulSet", Name: "mysql"},
+			nil,
+		),
+		newUsagePod("without-metrics", "1", "1Gi",
+			metav1.OwnerReference{Kind: "StatefulSet", Name: "without-metrics"},
+			nil,
+		),
+	}
+
+	podMetrics := []api.PodMetrics{
+		newUsageMetrics("backend-5d8f7c-abcde", "100m", "256Mi"),
+		newUsageMetrics("mysql-0", "400m", "256Mi"),
+	}
+
+	usage := api.NewEnvironmentUsage(pods, podMetrics, 0.2)
+
+	if len(usage.Pods) != 2 {
+		t.Fatalf("want 2 pods, got %d", len(usage.Pods))
+	}
+
+	mysql, backend := usage.Pods[0], usage.Pods[1]
+
+	if mysql.Workload != "mysql" || backend.Workload != "backend" {
+		t.Fatalf("unexpected workloads %s, %s", mysql.Workload, backend.Workload)
+	}
+
+	if backend.CPURatio != 0.1 || backend.MemoryRatio != 0.25 {
+		t.Fatalf("unexpected ratios of backend %f, %f", backend.CPURatio, backend.MemoryRatio)
+	}
+
+	if !backend.CPUOverRequested || backend.MemoryOverRequested || !backend.IsOverRequested() {
+		t.Fatal("backend must be over-requested by CPU")
+	}
+
+	if mysql.IsOverRequested() {
+		t.Fatal("mysql must not be over-requested")
+	}
+
+	if usage.CPURequests != 1.5 || usage.CPUUsage != 0.5 {
+		t.Fatalf("unexpected environment CPU %f, %f", usage.CPURequests, usage.CPUUsage)
+	}
+
+	overview := api.NewUsageOverview([]*api.EnvironmentUsage{usage}, 1)
+
+	if len(overview.TopCPUPods) != 1 || overview.TopCPUPods[0] != mysql {
+		t.Fatal("mysql must be top CPU consumer")
+	}
+
+	if len(overview.OverRequested) != 1 || overview.OverRequested[0] != backend {
+		t.Fatal("backend must be over-requested")
+	}
+}
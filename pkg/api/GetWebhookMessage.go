@@ -13,7 +13,13 @@ limitations under the License.
 package api
 
 import (
+	"context"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/webhook"
+	log "github.com/sirupsen/logrus"
 )
 
 func (e *Environment) NewWebhookMessage(event types.Event) types.WebhookMessage {
@@ -25,3 +31,36 @@ func (e *Environment) NewWebhookMessage(event types.Event) types.WebhookMessage
 		Properties: make(map[string]string),
 	}
 }
+
+// send event to webhooks, errors will be only logged.
+func (e *Environment) SendWebhookEvent(ctx context.Context, message types.WebhookMessage) {
+	sendWebhookEvent(ctx, message)
+}
+
+// event of remote server is sent to webhooks with remote-servers ID, owner of server is user of event.
+func (i *GetRemoteServerItem) NewWebhookMessage(event types.Event) types.WebhookMessage {
+	return types.WebhookMessage{
+		Event:   event,
+		Name:    i.ServerName,
+		Cluster: config.RemoteServerWebhookID,
+		Properties: map[string]string{
+			"user":   i.GetOwner(),
+			"cloud":  i.Cloud,
+			"server": i.ID,
+		},
+	}
+}
+
+func sendWebhookEvent(ctx context.Context, message types.WebhookMessage) {
+	ctx, span := telemetry.Start(ctx, "api.SendWebhookEvent")
+	defer span.End()
+
+	// add user that initiated event
+	if security, ok := ctx.Value(types.ContextSecurityKey).(types.ContextSecurity); ok && len(message.Properties["user"]) == 0 {
+		message.Properties["user"] = security.Owner
+	}
+
+	if err := webhook.NewEvent(ctx, message); err != nil {
+		log.WithError(err).Errorf("error while sending webhook %s", message.Event)
+	}
+}
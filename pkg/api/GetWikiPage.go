@@ -67,7 +67,7 @@ func GetWikiPage(ctx context.Context, input *GetWikiPageInput) (*GetWikiPageItem
 		return nil, errors.Wrap(err, "input.Validate")
 	}
 
-	project, err := GetCachedGitlabProject(ctx, input.ProjectID)
+	project, err := GetCachedProject(ctx, input.ProjectID)
 	if err != nil {
 		return nil, errors.Wrap(err, "can not get project")
 	}
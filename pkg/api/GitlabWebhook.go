@@ -0,0 +1,264 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/cache"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	gitlabBranchRefPrefix = "refs/heads/"
+	gitlabEmptyCommitSHA  = "0000000000000000000000000000000000000000"
+)
+
+// pipeline status from webhook is used only if it was updated recently, otherwise status is polled.
+const gitlabWebhookPipelineTTL = cache.MiddleTTL
+
+var errProcessGitlabPushEvent = errors.New("error updating environments")
+
+// pipelines are not saved with noop cache, warning is logged once.
+var gitlabWebhookCacheWarning sync.Once //nolint:gochecknoglobals
+
+// last pipeline of project in environment, received from GitLab webhook.
+type GitlabWebhookPipeline struct {
+	ProjectID  string
+	PipelineID int64
+	Ref        string
+	Status     string
+	URL        string
+	Updated    string
+}
+
+func getGitlabWebhookPipelineKey(environmentID, projectID string) string {
+	return fmt.Sprintf("gitlab::webhook::pipeline::%s::%s", environmentID, projectID)
+}
+
+// save pipeline in cache, cache is shared between replicas and survives restarts.
+func setGitlabWebhookPipeline(ctx context.Context, environmentID string, pipeline *GitlabWebhookPipeline) {
+	ctx, span := telemetry.Start(ctx, "api.setGitlabWebhookPipeline")
+	defer span.End()
+
+	cacheKey := getGitlabWebhookPipelineKey(environmentID, pipeline.ProjectID)
+
+	// events can be received in any order, ignore older pipelines
+	current := GitlabWebhookPipeline{}
+	if err := cache.Client().Get(ctx, cacheKey, &current); err == nil && current.PipelineID > pipeline.PipelineID {
+		return
+	}
+
+	if err := cache.Client().Set(ctx, cacheKey, pipeline, gitlabWebhookPipelineTTL); err != nil {
+		log.WithError(err).Debug("error saving pipeline")
+
+		gitlabWebhookCacheWarning.Do(func() {
+			log.WithError(err).Warn("pipelines from Gitlab webhook are not saved, redis cache is required")
+		})
+	}
+}
+
+// returns last pipeline of project received from GitLab webhook,
+// nil if webhook was not received recently - status must be requested from GitLab API.
+func (e *Environment) GetGitlabWebhookPipeline(ctx context.Context, projectID string) *GitlabWebhookPipeline {
+	ctx, span := telemetry.Start(ctx, "api.GetGitlabWebhookPipeline")
+	defer span.End()
+
+	pipeline := GitlabWebhookPipeline{}
+
+	if err := cache.Client().Get(ctx, getGitlabWebhookPipelineKey(e.ID, projectID), &pipeline); err != nil {
+		return nil
+	}
+
+	return &pipeline
+}
+
+// remove saved pipelines of installed projects.
+func (e *Environment) deleteGitlabWebhookPipelines(ctx context.Context) {
+	ctx, span := telemetry.Start(ctx, "api.deleteGitlabWebhookPipelines")
+	defer span.End()
+
+	keys := make([]string, 0)
+
+	for key := range e.NamespaceAnnotations {
+		if projectID, ok := strings.CutPrefix(key, config.LabelInstalledProject+"-"); ok {
+			keys = append(keys, getGitlabWebhookPipelineKey(e.ID, projectID))
+		}
+	}
+
+	if len(keys) > 0 {
+		_ = cache.Client().Delete(ctx, keys...)
+	}
+}
+
+func IsGitlabPipelineFinished(status string) bool {
+	switch status {
+	case "success", "failed", "canceled", "skipped":
+		return true
+	default:
+		return false
+	}
+}
+
+func GetGitlabPipelineEventVariables(event *gitlab.PipelineEvent) map[string]string {
+	result := make(map[string]string, len(event.ObjectAttributes.Variables))
+
+	for _, variable := range event.ObjectAttributes.Variables {
+		result[variable.Key] = variable.Value
+	}
+
+	return result
+}
+
+// returns environment ID of pipeline variables, empty if pipeline was not created by kubernetes-manager.
+func GetGitlabPipelineEnvironmentID(variables map[string]string) string {
+	namespace := variables[gitlabNamespaceKey]
+	cluster := variables[gitlabClusterKey]
+
+	if len(namespace) == 0 || len(cluster) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%s", cluster, namespace)
+}
+
+// save status of environment pipeline and send event when pipeline is finished.
+func ProcessGitlabPipelineEvent(ctx context.Context, event *gitlab.PipelineEvent) error {
+	ctx, span := telemetry.Start(ctx, "api.ProcessGitlabPipelineEvent")
+	defer span.End()
+
+	variables := GetGitlabPipelineEventVariables(event)
+
+	environmentID := GetGitlabPipelineEnvironmentID(variables)
+
+	// pipeline was not created by kubernetes-manager
+	if len(environmentID) == 0 {
+		return nil
+	}
+
+	cluster, namespace, _ := strings.Cut(environmentID, ":")
+	projectID := strconv.FormatInt(event.Project.ID, 10)
+
+	setGitlabWebhookPipeline(ctx, environmentID, &GitlabWebhookPipeline{
+		ProjectID:  projectID,
+		PipelineID: event.ObjectAttributes.ID,
+		Ref:        event.ObjectAttributes.Ref,
+		Status:     event.ObjectAttributes.Status,
+		URL:        event.ObjectAttributes.URL,
+		Updated:    utils.TimeToString(time.Now()),
+	})
+
+	InvalidateCachedKubernetesResources(ctx, cluster, namespace)
+
+	if !IsGitlabPipelineFinished(event.ObjectAttributes.Status) {
+		return nil
+	}
+
+	environment, err := GetEnvironmentByID(ctx, environmentID)
+	if err != nil {
+		log.WithError(err).Warnf("environment %s not found", environmentID)
+
+		return nil
+	}
+
+	eventMessage := environment.NewWebhookMessage(types.EventPipelineFinished)
+	eventMessage.Reason = fmt.Sprintf("Pipeline %s ...", event.ObjectAttributes.Status)
+	eventMessage.Properties["slackEmoji"] = ":gear:"
+	eventMessage.Properties["projectID"] = projectID
+	eventMessage.Properties["project"] = event.Project.PathWithNamespace
+	eventMessage.Properties["ref"] = event.ObjectAttributes.Ref
+	eventMessage.Properties["status"] = event.ObjectAttributes.Status
+	eventMessage.Properties["pipeline"] = event.ObjectAttributes.URL
+
+	for _, operation := range []GitlabPipelineOperation{
+		GitlabPipelineOperationBuild,
+		GitlabPipelineOperationDeploy,
+		GitlabPipelineOperationDelete,
+		GitlabPipelineOperationSnapshot,
+	} {
+		if variables[string(operation)] == config.TrueValue {
+			eventMessage.Properties["operation"] = string(operation)
+		}
+	}
+
+	environment.SendWebhookEvent(ctx, eventMessage)
+
+	// delete pipeline removes installed project, tagfork branches are not used anymore
+	if variables[string(GitlabPipelineOperationDelete)] == config.TrueValue {
+		if err := environment.DeleteReleasedTagForkBranches(ctx); err != nil {
+			log.WithError(err).Error("error deleting tagfork branches")
+		}
+	}
+
+	return nil
+}
+
+// invalidate project cache and update environments that follows pushed branch.
+func ProcessGitlabPushEvent(ctx context.Context, event *gitlab.PushEvent) error {
+	ctx, span := telemetry.Start(ctx, "api.ProcessGitlabPushEvent")
+	defer span.End()
+
+	projectID := strconv.FormatInt(event.ProjectID, 10)
+
+	InvalidateCachedProject(ctx, projectID)
+
+	if !strings.HasPrefix(event.Ref, gitlabBranchRefPrefix) {
+		return nil
+	}
+
+	// branch was deleted
+	if event.After == gitlabEmptyCommitSHA {
+		return nil
+	}
+
+	if !config.Get().GitlabWebhook.AutoRedeploy {
+		return nil
+	}
+
+	environments, err := GetEnvironments(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "error getting environments")
+	}
+
+	pipelineErrors := make([]string, 0)
+
+	for _, environment := range environments {
+		results, err := environment.UpdateFollowedBranches(ctx, projectID)
+		if err != nil {
+			pipelineErrors = append(pipelineErrors, fmt.Sprintf("%s: %s", environment.ID, err.Error()))
+
+			continue
+		}
+
+		for _, result := range results {
+			log.Infof("environment %s updated %s %s", environment.ID, result.PipelineURL, result.Error)
+		}
+	}
+
+	if len(pipelineErrors) > 0 {
+		return errors.Wrap(errProcessGitlabPushEvent, strings.Join(pipelineErrors, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrorrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// name of ResourceQuota and LimitRange in namespace
+	namespaceResourcesName = config.Namespace
+	// spec from config is saved in annotation, LimitRange spec is changed by defaults in kubernetes
+	appliedSpecAnnotation = config.AnnotationPrefix + "applied-spec"
+)
+
+// used and hard limit of resource in ResourceQuota.
+type QuotaUsage struct {
+	Resource string
+	Hard     string
+	Used     string
+	// used percent of hard limit
+	Percent float64
+}
+
+// override replaces ResourceQuota and LimitRange from template.
+func mergeNamespaceResources(template, override *config.NamespaceResources) *config.NamespaceResources {
+	result := config.NamespaceResources{}
+
+	if template != nil {
+		result = *template
+	}
+
+	if override != nil {
+		if override.ResourceQuota != nil {
+			result.ResourceQuota = override.ResourceQuota
+		}
+
+		if override.LimitRange != nil {
+			result.LimitRange = override.LimitRange
+		}
+	}
+
+	return &result
+}
+
+func (e *Environment) getNamespaceResourcesOverride() *config.NamespaceResources {
+	value, ok := e.NamespaceAnnotations[config.LabelResources]
+	if !ok || len(value) == 0 {
+		return nil
+	}
+
+	result := config.NamespaceResources{}
+
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return nil
+	}
+
+	return &result
+}
+
+// ResourceQuota and LimitRange of environment from profile or NamespaceMeta with override of environment.
+func (e *Environment) GetNamespaceResources(ctx context.Context) *config.NamespaceResources {
+	template := config.GetNamespaceMeta(ctx, e.Namespace).Resources
+
+	if profile := e.getProjectProfile(); profile != nil && profile.Resources != nil {
+		template = profile.Resources
+	}
+
+	return mergeNamespaceResources(template, e.getNamespaceResourcesOverride())
+}
+
+// create, update or delete ResourceQuota and LimitRange in namespace.
+func (e *Environment) ApplyNamespaceResources(ctx context.Context) error {
+	ctx, span := telemetry.Start(ctx, "api.ApplyNamespaceResources")
+	defer span.End()
+
+	resources := e.GetNamespaceResources(ctx)
+
+	if err := e.applyResourceQuota(ctx, resources.ResourceQuota); err != nil {
+		return errors.Wrap(err, "error applying resource quota")
+	}
+
+	if err := e.applyLimitRange(ctx, resources.LimitRange); err != nil {
+		return errors.Wrap(err, "error applying limit range")
+	}
+
+	return nil
+}
+
+func newNamespaceResourcesMeta(spec any) (metav1.ObjectMeta, error) {
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return metav1.ObjectMeta{}, errors.Wrap(err, "error marshaling spec")
+	}
+
+	return metav1.ObjectMeta{
+		Name: namespaceResourcesName,
+		Labels: map[string]string{
+			config.Namespace: config.TrueValue,
+		},
+		Annotations: map[string]string{
+			appliedSpecAnnotation: string(specJSON),
+		},
+	}, nil
+}
+
+func (e *Environment) applyResourceQuota(ctx context.Context, spec *corev1.ResourceQuotaSpec) error {
+	resourceQuotas := e.clientset.CoreV1().ResourceQuotas(e.Namespace)
+
+	current, err := resourceQuotas.Get(ctx, namespaceResourcesName, metav1.GetOptions{})
+	if err != nil && !apierrorrs.IsNotFound(err) {
+		return errors.Wrap(err, "error getting resource quota")
+	}
+
+	exists := err == nil
+
+	if spec == nil {
+		if exists {
+			return resourceQuotas.Delete(ctx, namespaceResourcesName, metav1.DeleteOptions{})
+		}
+
+		return nil
+	}
+
+	meta, err := newNamespaceResourcesMeta(spec)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		_, err = resourceQuotas.Create(ctx, &corev1.ResourceQuota{ObjectMeta: meta, Spec: *spec}, metav1.CreateOptions{})
+
+		return err
+	}
+
+	if current.Annotations[appliedSpecAnnotation] == meta.Annotations[appliedSpecAnnotation] {
+		return nil
+	}
+
+	current.Annotations = meta.Annotations
+	current.Spec = *spec
+
+	_, err = resourceQuotas.Update(ctx, current, metav1.UpdateOptions{})
+
+	return err
+}
+
+func (e *Environment) applyLimitRange(ctx context.Context, spec *corev1.LimitRangeSpec) error {
+	limitRanges := e.clientset.CoreV1().LimitRanges(e.Namespace)
+
+	current, err := limitRanges.Get(ctx, namespaceResourcesName, metav1.GetOptions{})
+	if err != nil && !apierrorrs.IsNotFound(err) {
+		return errors.Wrap(err, "error getting limit range")
+	}
+
+	exists := err == nil
+
+	if spec == nil {
+		if exists {
+			return limitRanges.Delete(ctx, namespaceResourcesName, metav1.DeleteOptions{})
+		}
+
+		return nil
+	}
+
+	meta, err := newNamespaceResourcesMeta(spec)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		_, err = limitRanges.Create(ctx, &corev1.LimitRange{ObjectMeta: meta, Spec: *spec}, metav1.CreateOptions{})
+
+		return err
+	}
+
+	if current.Annotations[appliedSpecAnnotation] == meta.Annotations[appliedSpecAnnotation] {
+		return nil
+	}
+
+	current.Annotations = meta.Annotations
+	current.Spec = *spec
+
+	_, err = limitRanges.Update(ctx, current, metav1.UpdateOptions{})
+
+	return err
+}
+
+func newQuotaUsage(status corev1.ResourceQuotaStatus) []*QuotaUsage {
+	result := make([]*QuotaUsage, 0, len(status.Hard))
+
+	for resource, hard := range status.Hard {
+		used := status.Used[resource]
+
+		item := QuotaUsage{
+			Resource: string(resource),
+			Hard:     hard.String(),
+			Used:     used.String(),
+		}
+
+		if hardValue := hard.AsApproximateFloat64(); hardValue > 0 {
+			const percent = 100
+
+			item.Percent = math.Round(used.AsApproximateFloat64()/hardValue*percent*percent) / percent
+		}
+
+		result = append(result, &item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Resource < result[j].Resource
+	})
+
+	return result
+}
+
+// usage of ResourceQuota in namespace, empty if quota is not created.
+func (e *Environment) GetQuotaUsage(ctx context.Context) ([]*QuotaUsage, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetQuotaUsage")
+	defer span.End()
+
+	resourceQuota, err := e.clientset.CoreV1().ResourceQuotas(e.Namespace).Get(ctx, namespaceResourcesName, metav1.GetOptions{})
+	if apierrorrs.IsNotFound(err) {
+		return []*QuotaUsage{}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error getting resource quota")
+	}
+
+	return newQuotaUsage(resourceQuota.Status), nil
+}
+
+type SetNamespaceResourcesInput struct {
+	// nil - remove override, resources from profile or NamespaceMeta are used
+	Resources *config.NamespaceResources
+}
+
+// override ResourceQuota and LimitRange of environment, user must have namespace-resources permission.
+func (e *Environment) SetNamespaceResources(ctx context.Context, input *SetNamespaceResourcesInput) error {
+	ctx, span := telemetry.Start(ctx, "api.SetNamespaceResources")
+	defer span.End()
+
+	user := e.GetUser(ctx)
+
+	if !config.Get().IsAuthorized(config.PermissionNamespaceResources, user, e.ID) {
+		return errors.Errorf("user %s is not authorized to change resources of environment", user)
+	}
+
+	override := ""
+
+	if input.Resources != nil {
+		overrideJSON, err := json.Marshal(input.Resources)
+		if err != nil {
+			return errors.Wrap(err, "error marshaling resources")
+		}
+
+		override = string(overrideJSON)
+	}
+
+	annotations := map[string]string{
+		config.LabelResources: override,
+	}
+
+	if err := e.SaveNamespaceMeta(ctx, annotations, e.NamespaceLabels); err != nil {
+		return errors.Wrap(err, "error saving resources override")
+	}
+
+	return e.ApplyNamespaceResources(ctx)
+}
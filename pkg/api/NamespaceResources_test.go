@@ -0,0 +1,110 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestMergeNamespaceResources(t *testing.T) {
+	t.Parallel()
+
+	template := &config.NamespaceResources{
+		ResourceQuota: &corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("8"),
+			},
+		},
+		LimitRange: &corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type: corev1.LimitTypeContainer,
+					Default: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("500m"),
+					},
+				},
+			},
+		},
+	}
+
+	override := &config.NamespaceResources{
+		ResourceQuota: &corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("16"),
+			},
+		},
+	}
+
+	result := api.MergeNamespaceResources(template, override)
+
+	if cpu := result.ResourceQuota.Hard[corev1.ResourceRequestsCPU]; cpu.String() != "16" {
+		t.Fatalf("quota must be overridden, got %s", cpu.String())
+	}
+
+	if result.LimitRange != template.LimitRange {
+		t.Fatal("limit range must be used from template")
+	}
+
+	if empty := api.MergeNamespaceResources(nil, nil); empty.ResourceQuota != nil || empty.LimitRange != nil {
+		t.Fatal("resources must be empty")
+	}
+
+	if result := api.MergeNamespaceResources(nil, override); result.ResourceQuota != override.ResourceQuota {
+		t.Fatal("override must be used without template")
+	}
+}
+
+func TestNewQuotaUsage(t *testing.T) {
+	t.Parallel()
+
+	usage := api.NewQuotaUsage(corev1.ResourceQuotaStatus{
+		Hard: corev1.ResourceList{
+			corev1.ResourceRequestsMemory: resource.MustParse("4Gi"),
+			corev1.ResourceRequestsCPU:    resource.MustParse("8"),
+			corev1.ResourcePods:           resource.MustParse("0"),
+		},
+		Used: corev1.ResourceList{
+			corev1.ResourceRequestsMemory: resource.MustParse("1Gi"),
+			corev1.ResourceRequestsCPU:    resource.MustParse("2500m"),
+		},
+	})
+
+	if len(usage) != 3 {
+		t.Fatalf("must be 3 resources, got %d", len(usage))
+	}
+
+	// sorted by resource name
+	tests := []struct {
+		resource string
+		hard     string
+		used     string
+		percent  float64
+	}{
+		{resource: "pods", hard: "0", used: "0", percent: 0},
+		{resource: "requests.cpu", hard: "8", used: "2500m", percent: 31.25},
+		{resource: "requests.memory", hard: "4Gi", used: "1Gi", percent: 25},
+	}
+
+	for i, test := range tests {
+		item := usage[i]
+
+		if item.Resource != test.resource || item.Hard != test.hard || item.Used != test.used || item.Percent != test.percent {
+			t.Fatalf("unexpected usage %+v, expected %+v", item, test)
+		}
+	}
+}
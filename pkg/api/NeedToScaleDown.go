@@ -29,14 +29,8 @@ func (e *Environment) NeedToScaleDown(nowDate time.Time, diffHours int) bool {
 		now = now.Add(time.Duration(diffHours) * time.Hour)
 	}
 
-	// if exists annotation with scale down delay
-	if scaleDelayText, ok := e.NamespaceAnnotations[config.LabelScaleDownDelay]; ok {
-		// date time in correct format
-		scaleDelayTime, err := utils.StringToTime(scaleDelayText)
-		if err != nil {
-			return false
-		}
-
+	// if exists annotation with scale down delay in correct format
+	if scaleDelayTime, ok := e.getScaleDownDelay(); ok {
 		// for simulation if scaleDelayTime some date in past - remove false
 		if diffHours > 0 && time.Now().After(scaleDelayTime) {
 			return false
@@ -50,3 +44,18 @@ func (e *Environment) NeedToScaleDown(nowDate time.Time, diffHours int) bool {
 
 	return false
 }
+
+// returns time from scale down delay annotation, false if annotation not exists or invalid.
+func (e *Environment) getScaleDownDelay() (time.Time, bool) {
+	scaleDelayText, ok := e.NamespaceAnnotations[config.LabelScaleDownDelay]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	scaleDelayTime, err := utils.StringToTime(scaleDelayText)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return scaleDelayTime, true
+}
@@ -0,0 +1,252 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+var errNoReadyPod = errors.New("service has no ready pods")
+
+type PortForwardTarget struct {
+	Pod  string
+	Port int
+}
+
+// returns pod and port of service port or pod port.
+func (e *Environment) GetPortForwardTarget(ctx context.Context, service, pod string, port int) (*PortForwardTarget, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetPortForwardTarget")
+	defer span.End()
+
+	if len(pod) > 0 {
+		podInfo, err := e.clientset.CoreV1().Pods(e.Namespace).Get(ctx, pod, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "can not get pod")
+		}
+
+		if podInfo.Status.Phase != corev1.PodRunning {
+			return nil, errors.Errorf("pod %s is %s", pod, podInfo.Status.Phase)
+		}
+
+		return &PortForwardTarget{Pod: pod, Port: port}, nil
+	}
+
+	serviceInfo, err := e.clientset.CoreV1().Services(e.Namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get service")
+	}
+
+	var servicePort *corev1.ServicePort
+
+	for i := range serviceInfo.Spec.Ports {
+		if int(serviceInfo.Spec.Ports[i].Port) == port {
+			servicePort = &serviceInfo.Spec.Ports[i]
+		}
+	}
+
+	if servicePort == nil {
+		return nil, errors.Errorf("service %s has no port %d", service, port)
+	}
+
+	pods, err := e.clientset.CoreV1().Pods(e.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(serviceInfo.Spec.Selector).String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list pods")
+	}
+
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		targetPort, err := getPodTargetPort(&pods.Items[i], servicePort)
+		if err != nil {
+			return nil, err
+		}
+
+		return &PortForwardTarget{Pod: pods.Items[i].Name, Port: targetPort}, nil
+	}
+
+	return nil, errors.Wrap(errNoReadyPod, service)
+}
+
+// returns container port of service port, named ports are resolved from pod containers.
+func getPodTargetPort(pod *corev1.Pod, servicePort *corev1.ServicePort) (int, error) {
+	if servicePort.TargetPort.Type == intstr.Int {
+		if servicePort.TargetPort.IntVal == 0 {
+			return int(servicePort.Port), nil
+		}
+
+		return int(servicePort.TargetPort.IntVal), nil
+	}
+
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == servicePort.TargetPort.StrVal {
+				return int(containerPort.ContainerPort), nil
+			}
+		}
+	}
+
+	return 0, errors.Errorf("pod %s has no port %s", pod.Name, servicePort.TargetPort.StrVal)
+}
+
+// forward data of stream to pod port, every session is recorded to audit log.
+func (e *Environment) PortForward(ctx context.Context, target *PortForwardTarget, stream io.ReadWriter) error {
+	ctx, span := telemetry.Start(ctx, "api.PortForward")
+	defer span.End()
+
+	user := e.GetUser(ctx)
+
+	if !config.Get().IsAuthorized(config.PermissionPortForward, user, e.ID) {
+		return errors.New("user has no permission to port-forward")
+	}
+
+	started := time.Now()
+
+	auditFields := log.Fields{
+		"audit":       config.PermissionPortForward,
+		"user":        user,
+		"environment": e.ID,
+		"pod":         target.Pod,
+		"port":        target.Port,
+	}
+
+	log.WithFields(auditFields).Info("port-forward session started")
+
+	err := e.portForward(ctx, target, stream)
+
+	auditFields["duration"] = time.Since(started).Round(time.Second).String()
+
+	if err != nil {
+		auditFields["error"] = err.Error()
+	}
+
+	log.WithFields(auditFields).Info("port-forward session closed")
+
+	return err
+}
+
+func (e *Environment) portForward(ctx context.Context, target *PortForwardTarget, stream io.ReadWriter) error {
+	restconfig, err := client.GetRestConfig(e.Cluster)
+	if err != nil {
+		return errors.New("can not get client config for cluster")
+	}
+
+	req := e.clientset.CoreV1().RESTClient().
+		Post().
+		Namespace(e.Namespace).
+		Resource("pods").
+		Name(target.Pod).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(restconfig)
+	if err != nil {
+		return errors.Wrap(err, "can not create transport")
+	}
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	streamConn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return errors.Wrap(err, "can not dial pod")
+	}
+	defer streamConn.Close()
+
+	go func() {
+		<-ctx.Done()
+
+		_ = streamConn.Close()
+	}()
+
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(target.Port))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+
+	errorStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return errors.Wrap(err, "can not create error stream")
+	}
+
+	// error stream is used only to read errors
+	_ = errorStream.Close()
+
+	streamErrors := make(chan error, 1)
+
+	go func() {
+		message, err := io.ReadAll(errorStream)
+
+		switch {
+		case err != nil:
+			streamErrors <- errors.Wrap(err, "error reading error stream")
+		case len(message) > 0:
+			streamErrors <- errors.New(string(message))
+		default:
+			streamErrors <- nil
+		}
+	}()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+
+	dataStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return errors.Wrap(err, "can not create data stream")
+	}
+
+	copyDone := make(chan struct{}, 2) //nolint:mnd
+
+	go func() {
+		_, _ = io.Copy(dataStream, stream)
+		_ = dataStream.Close()
+		copyDone <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(stream, dataStream)
+		copyDone <- struct{}{}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-copyDone:
+			return nil
+		case err := <-streamErrors:
+			if err != nil {
+				return err
+			}
+
+			// error stream was closed without errors, wait for data stream
+			streamErrors = nil
+		}
+	}
+}
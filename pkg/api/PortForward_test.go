@@ -0,0 +1,59 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestGetPodTargetPort(t *testing.T) {
+	t.Parallel()
+
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Ports: []corev1.ContainerPort{{Name: "mysql", ContainerPort: 3307}},
+			}},
+		},
+	}
+
+	type test struct {
+		servicePort corev1.ServicePort
+		want        int
+	}
+
+	tests := []test{
+		{servicePort: corev1.ServicePort{Port: 3306}, want: 3306},
+		{servicePort: corev1.ServicePort{Port: 3306, TargetPort: intstr.FromInt32(3308)}, want: 3308},
+		{servicePort: corev1.ServicePort{Port: 3306, TargetPort: intstr.FromString("mysql")}, want: 3307},
+	}
+
+	for _, test := range tests {
+		got, err := api.GetPodTargetPort(pod, &test.servicePort)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != test.want {
+			t.Fatalf("want=%d,got=%d", test.want, got)
+		}
+	}
+
+	if _, err := api.GetPodTargetPort(pod, &corev1.ServicePort{TargetPort: intstr.FromString("unknown")}); err == nil {
+		t.Fatal("unknown named port must be error")
+	}
+}
@@ -0,0 +1,265 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// prestop webhook is sent when server will be powered off in this duration
+	remoteServerPrestopDuration = time.Hour
+	// time when owner was warned that server is idle
+	idleWarningTimeLabel = "idleWarningTime"
+)
+
+var errRemoteServerMetricsNotSupported = errors.New("provider does not support metrics")
+
+type RemoteServerScaleAction string
+
+const (
+	RemoteServerScaleNone     RemoteServerScaleAction = ""
+	RemoteServerScalePrestop  RemoteServerScaleAction = "Prestop"
+	RemoteServerScalePowerOn  RemoteServerScaleAction = "PowerOn"
+	RemoteServerScalePowerOff RemoteServerScaleAction = "PowerOff"
+)
+
+// returns time from label with unix time, false if label not exists or invalid.
+func (i *GetRemoteServerItem) getLabelTime(key string) (time.Time, bool) {
+	value, ok := i.Labels[key]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	result, err := utils.UnixToTime(value)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return result, true
+}
+
+func (i *GetRemoteServerItem) GetSchedule() (*RemoteServerSchedule, bool) {
+	return newRemoteServerSchedule(i.Labels)
+}
+
+// time when running server will be powered off, server works till end of working hours
+// or till scale down delay, zero time if server must be powered off now.
+func (i *GetRemoteServerItem) GetPowerOffTime(now time.Time) time.Time {
+	result := time.Time{}
+
+	if scaleDownDelay, ok := i.getLabelTime(config.LabelScaleDownDelayShort); ok {
+		result = scaleDownDelay
+	}
+
+	if schedule, ok := i.GetSchedule(); ok && schedule.IsWorkingTime(now) {
+		if _, powerOff, _ := schedule.GetWorkingHours(now); powerOff.After(result) {
+			result = powerOff
+		}
+	}
+
+	return result
+}
+
+// returns scheduled action of server, idle servers are checked with provider metrics.
+func (i *GetRemoteServerItem) GetScaleAction(now time.Time) RemoteServerScaleAction {
+	if i.Status == GetRemoteServerItemStatusStoped {
+		schedule, ok := i.GetSchedule()
+		if !ok || !schedule.IsWorkingTime(now) {
+			return RemoteServerScaleNone
+		}
+
+		powerOn, _, _ := schedule.GetWorkingHours(now)
+
+		// server that was powered off by user in working hours stays stopped
+		for _, label := range []string{lastPowerOnTimeLabel, lastPowerOffTimeLabel} {
+			if lastActionTime, ok := i.getLabelTime(label); ok && !lastActionTime.Before(powerOn) {
+				return RemoteServerScaleNone
+			}
+		}
+
+		return RemoteServerScalePowerOn
+	}
+
+	powerOffTime := i.GetPowerOffTime(now)
+
+	if !now.Before(powerOffTime) {
+		return RemoteServerScalePowerOff
+	}
+
+	if powerOffTime.Sub(now) <= remoteServerPrestopDuration {
+		return RemoteServerScalePrestop
+	}
+
+	return RemoteServerScaleNone
+}
+
+// idle detection is needed for running servers that were powered on more than IdleMinutes ago,
+// servers are not checked in working hours of schedule.
+func (i *GetRemoteServerItem) NeedIdleCheck(now time.Time) bool {
+	idleMinutes := config.Get().RemoteServer.IdleMinutes
+
+	if idleMinutes <= 0 || i.Status != GetRemoteServerItemStatusRunning {
+		return false
+	}
+
+	if schedule, ok := i.GetSchedule(); ok && schedule.IsWorkingTime(now) {
+		return false
+	}
+
+	lastPowerOnTime, err := i.GetLastPowerOnTime()
+	if err != nil {
+		return false
+	}
+
+	return now.Sub(lastPowerOnTime) >= time.Duration(idleMinutes)*time.Minute
+}
+
+// returns true if owner was warned that server is idle, warning is valid till next batch.
+func (i *GetRemoteServerItem) IsIdleWarned(now time.Time) bool {
+	warningTime, ok := i.getLabelTime(idleWarningTimeLabel)
+	if !ok {
+		return false
+	}
+
+	lastPowerOnTime, err := i.GetLastPowerOnTime()
+	if err != nil || lastPowerOnTime.After(warningTime) {
+		return false
+	}
+
+	idleDuration := time.Duration(config.Get().RemoteServer.IdleMinutes) * time.Minute
+
+	return now.Sub(warningTime) < idleDuration+config.Get().GetBatchShedulePeriod()
+}
+
+// power on and power off server by schedule, scale down delay and idle detection.
+func ScaleRemoteServer(ctx context.Context, server *GetRemoteServerItem) error {
+	ctx, span := telemetry.Start(ctx, "api.ScaleRemoteServer")
+	defer span.End()
+
+	now := time.Now()
+	log := log.WithField("server", server.ServerName)
+
+	switch server.GetScaleAction(now) {
+	case RemoteServerScalePrestop:
+		sendRemoteServerPrestop(ctx, server, "Will be powered off at "+utils.TimeToString(server.GetPowerOffTime(now)))
+
+		return nil
+	case RemoteServerScalePowerOn:
+		schedule, _ := server.GetSchedule()
+		_, powerOff, _ := schedule.GetWorkingHours(now)
+
+		log.Info("power on server by schedule")
+
+		return SetRemoteServerAction(ctx, SetRemoteServerActionInput{
+			Cloud:          server.Cloud,
+			ID:             server.ID,
+			Action:         SetRemoteServerStatusPowerOn,
+			scaleDownDelay: powerOff,
+		})
+	case RemoteServerScalePowerOff:
+		log.Info("scaledown server")
+
+		return powerOffRemoteServer(ctx, server)
+	case RemoteServerScaleNone:
+	}
+
+	if !server.NeedIdleCheck(now) {
+		return nil
+	}
+
+	idle, err := isRemoteServerIdle(ctx, server, now)
+	if errors.Is(err, errRemoteServerMetricsNotSupported) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "error checking idle server")
+	}
+
+	if !idle {
+		return nil
+	}
+
+	// owner is warned first, server is powered off on next batch if it is still idle
+	if !server.IsIdleWarned(now) {
+		labels := map[string]string{
+			idleWarningTimeLabel: utils.TimeToUnix(now),
+		}
+
+		if err := SetRemoteServerLabels(ctx, server.Cloud, server.ID, labels); err != nil {
+			return errors.Wrap(err, "error set labels")
+		}
+
+		sendRemoteServerPrestop(ctx, server, "Server is idle and will be powered off soon...")
+
+		return nil
+	}
+
+	log.Info("power off idle server")
+
+	return powerOffRemoteServer(ctx, server)
+}
+
+func powerOffRemoteServer(ctx context.Context, server *GetRemoteServerItem) error {
+	err := SetRemoteServerAction(ctx, SetRemoteServerActionInput{
+		Cloud:  server.Cloud,
+		ID:     server.ID,
+		Action: SetRemoteServerStatusPowerOff,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error power off server %s", server.ID)
+	}
+
+	return nil
+}
+
+func sendRemoteServerPrestop(ctx context.Context, server *GetRemoteServerItem, reason string) {
+	eventMessage := server.NewWebhookMessage(types.EventPrestop)
+	eventMessage.Reason = reason
+	eventMessage.Properties["slackEmoji"] = ":warning:"
+
+	sendWebhookEvent(ctx, eventMessage)
+}
+
+// server is idle when average CPU usage for IdleMinutes is less than IdleCPUPercent.
+func isRemoteServerIdle(ctx context.Context, server *GetRemoteServerItem, now time.Time) (bool, error) {
+	provider, err := client.GetRemoteServerProvider(server.Cloud)
+	if err != nil {
+		return false, err
+	}
+
+	metricsProvider, ok := provider.(remoteserver.MetricsProvider)
+	if !ok {
+		return false, errors.Wrap(errRemoteServerMetricsNotSupported, server.Cloud)
+	}
+
+	idleMinutes := config.Get().RemoteServer.IdleMinutes
+
+	cpuUsage, err := metricsProvider.GetCPUUsage(ctx, server.ID, now.Add(-time.Duration(idleMinutes)*time.Minute))
+	if err != nil {
+		return false, errors.Wrap(err, "error getting cpu usage")
+	}
+
+	log.WithField("server", server.ServerName).Debugf("cpu usage %.2f%%", cpuUsage)
+
+	return cpuUsage < config.Get().RemoteServer.IdleCPUPercent, nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+)
+
+func TestRemoteServerScaleAction(t *testing.T) {
+	t.Parallel()
+
+	// Monday
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	schedule := map[string]string{
+		"schedule":         "0800-1800-12345",
+		"scheduleTimezone": "UTC",
+	}
+
+	withLabels := func(labels map[string]string, add map[string]string) map[string]string {
+		result := make(map[string]string)
+
+		maps.Copy(result, labels)
+		maps.Copy(result, add)
+
+		return result
+	}
+
+	type testCase struct {
+		Status   api.GetRemoteServerItemStatus
+		Labels   map[string]string
+		Now      time.Time
+		Action   api.RemoteServerScaleAction
+		Scenario string
+	}
+
+	testCases := []testCase{
+		{
+			Status:   api.GetRemoteServerItemStatusStoped,
+			Labels:   schedule,
+			Action:   api.RemoteServerScalePowerOn,
+			Scenario: "stopped server in working hours",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusStoped,
+			Labels:   withLabels(schedule, map[string]string{"lastPowerOffTime": utils.TimeToUnix(now.Add(-time.Hour))}),
+			Action:   api.RemoteServerScaleNone,
+			Scenario: "server was powered off by user in working hours",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusStoped,
+			Labels:   withLabels(schedule, map[string]string{"lastPowerOffTime": utils.TimeToUnix(now.Add(-14 * time.Hour))}),
+			Action:   api.RemoteServerScalePowerOn,
+			Scenario: "server was powered off yesterday",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusStoped,
+			Labels:   schedule,
+			Now:      now.AddDate(0, 0, 5),
+			Action:   api.RemoteServerScaleNone,
+			Scenario: "stopped server on weekend",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusStoped,
+			Labels:   withLabels(schedule, map[string]string{"scheduleTimezone": "Asia.Tokyo"}),
+			Action:   api.RemoteServerScaleNone,
+			Scenario: "stopped server after working hours in timezone of owner",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusStoped,
+			Labels:   map[string]string{},
+			Action:   api.RemoteServerScaleNone,
+			Scenario: "stopped server without schedule",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusRunning,
+			Labels:   map[string]string{},
+			Action:   api.RemoteServerScalePowerOff,
+			Scenario: "running server without schedule and delay",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusRunning,
+			Labels:   map[string]string{"scaleDownDelay": utils.TimeToUnix(now.Add(3 * time.Hour))},
+			Action:   api.RemoteServerScaleNone,
+			Scenario: "scale down delay is active",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusRunning,
+			Labels:   map[string]string{"scaleDownDelay": utils.TimeToUnix(now.Add(30 * time.Minute))},
+			Action:   api.RemoteServerScalePrestop,
+			Scenario: "scale down delay ends soon",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusRunning,
+			Labels:   schedule,
+			Action:   api.RemoteServerScaleNone,
+			Scenario: "running server in working hours",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusRunning,
+			Labels:   schedule,
+			Now:      now.Add(7*time.Hour + 30*time.Minute),
+			Action:   api.RemoteServerScalePrestop,
+			Scenario: "working hours end soon",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusRunning,
+			Labels:   withLabels(schedule, map[string]string{"scaleDownDelay": utils.TimeToUnix(now.Add(10 * time.Hour))}),
+			Now:      now.Add(7*time.Hour + 30*time.Minute),
+			Action:   api.RemoteServerScaleNone,
+			Scenario: "scale down delay is after working hours",
+		},
+		{
+			Status:   api.GetRemoteServerItemStatusRunning,
+			Labels:   schedule,
+			Now:      now.Add(9 * time.Hour),
+			Action:   api.RemoteServerScalePowerOff,
+			Scenario: "running server after working hours",
+		},
+	}
+
+	for _, testCase := range testCases {
+		server := api.GetRemoteServerItem{
+			Status: testCase.Status,
+			Labels: testCase.Labels,
+		}
+
+		testNow := testCase.Now
+		if testNow.IsZero() {
+			testNow = now
+		}
+
+		if action := server.GetScaleAction(testNow); action != testCase.Action {
+			t.Errorf("%s: want action %q, got %q", testCase.Scenario, testCase.Action, action)
+		}
+	}
+}
+
+func TestRemoteServerSchedule(t *testing.T) {
+	t.Parallel()
+
+	valid := api.RemoteServerSchedule{
+		PowerOn:  "08:00",
+		PowerOff: "20:00",
+		Weekdays: []time.Weekday{time.Monday, time.Friday},
+		Timezone: "America/New_York",
+	}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := valid.String(); text != "08:00-20:00 Mon,Fri" {
+		t.Fatalf("unexpected schedule %s", text)
+	}
+
+	invalid := []api.RemoteServerSchedule{
+		{PowerOn: "20:00", PowerOff: "08:00", Weekdays: valid.Weekdays, Timezone: "UTC"},
+		{PowerOn: "08:00", PowerOff: "20:00", Timezone: "UTC"},
+		{PowerOn: "08:00", PowerOff: "20:00", Weekdays: valid.Weekdays, Timezone: "Etc/GMT+3"},
+		{PowerOn: "8am", PowerOff: "20:00", Weekdays: valid.Weekdays, Timezone: "UTC"},
+	}
+
+	for _, schedule := range invalid {
+		if err := schedule.Validate(); err == nil {
+			t.Errorf("schedule %s %s must be invalid", schedule.String(), schedule.Timezone)
+		}
+	}
+}
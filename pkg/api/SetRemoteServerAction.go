@@ -15,7 +15,7 @@ package api
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"maps"
 	"time"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
@@ -30,75 +30,89 @@ type SetRemoteServerStatusAction string
 const (
 	SetRemoteServerStatusPowerOn  SetRemoteServerStatusAction = "PowerOn"
 	SetRemoteServerStatusPowerOff SetRemoteServerStatusAction = "PowerOff"
+	SetRemoteServerStatusReboot   SetRemoteServerStatusAction = "Reboot"
 )
 
 func (a SetRemoteServerStatusAction) Validate() error {
-	if a == SetRemoteServerStatusPowerOn {
+	switch a {
+	case SetRemoteServerStatusPowerOn, SetRemoteServerStatusPowerOff, SetRemoteServerStatusReboot:
 		return nil
+	default:
+		return errors.New("unknown status")
 	}
-
-	if a == SetRemoteServerStatusPowerOff {
-		return nil
-	}
-
-	return errors.New("unknown status")
 }
 
 type SetRemoteServerActionInput struct {
 	Cloud  string
 	ID     string
 	Action SetRemoteServerStatusAction
+	// scale down delay after power on, default delay from config if empty
+	scaleDownDelay time.Time
 }
 
-// return all remote servers.
+// power on, power off or reboot remote server.
 func SetRemoteServerAction(ctx context.Context, input SetRemoteServerActionInput) error {
 	ctx, span := telemetry.Start(ctx, "api.SetRemoteServerAction")
 	defer span.End()
 
-	if input.Cloud != "hcloud" {
-		return errors.New("cloud not supported")
-	}
-
 	if err := input.Action.Validate(); err != nil {
 		return errors.Wrapf(err, "error validate action %s", input.Action)
 	}
 
-	hcloundClient := client.GetHcloudClient()
-
-	id, err := strconv.Atoi(input.ID)
+	provider, err := client.GetRemoteServerProvider(input.Cloud)
 	if err != nil {
-		return errors.New("can not parse id")
+		return err
 	}
 
-	server, _, err := hcloundClient.Server.GetByID(ctx, id)
-	if err != nil {
-		return errors.Wrap(err, "can not get server")
-	}
+	now := time.Now()
 
 	labels := map[string]string{
-		fmt.Sprintf("last%sTime", string(input.Action)): utils.TimeToUnix(time.Now()),
+		fmt.Sprintf("last%sTime", string(input.Action)): utils.TimeToUnix(now),
 	}
 
-	if input.Action == SetRemoteServerStatusPowerOff {
-		_, _, err = hcloundClient.Server.Poweroff(ctx, server)
-		if err != nil {
-			return errors.Wrap(err, "can power off server")
+	switch input.Action {
+	case SetRemoteServerStatusPowerOff:
+		if err := addRunningHoursLabels(ctx, input, labels, now); err != nil {
+			return err
 		}
-	}
 
-	if input.Action == SetRemoteServerStatusPowerOn {
-		_, _, err = hcloundClient.Server.Poweron(ctx, server)
-		if err != nil {
-			return errors.Wrap(err, "can power on server")
+		err = provider.PowerOff(ctx, input.ID)
+	case SetRemoteServerStatusPowerOn:
+		err = provider.PowerOn(ctx, input.ID)
+
+		if input.scaleDownDelay.IsZero() {
+			labels[config.LabelScaleDownDelayShort] = config.Get().GetScaleDownDelay().TimeToUnix()
+		} else {
+			labels[config.LabelScaleDownDelayShort] = utils.TimeToUnix(input.scaleDownDelay)
 		}
+	case SetRemoteServerStatusReboot:
+		err = provider.Reboot(ctx, input.ID)
+	}
 
-		labels[config.LabelScaleDownDelayShort] = config.Get().GetScaleDownDelay().TimeToUnix()
+	if err != nil {
+		return errors.Wrapf(err, "error %s server", input.Action)
 	}
 
-	err = SetRemoteServerLabels(ctx, server, labels)
+	err = SetRemoteServerLabels(ctx, input.Cloud, input.ID, labels)
 	if err != nil {
 		return errors.Wrap(err, "error updating server")
 	}
 
 	return nil
 }
+
+// running hours are saved on power off for cost of servers that are not billed when stopped.
+func addRunningHoursLabels(ctx context.Context, input SetRemoteServerActionInput, labels map[string]string, now time.Time) error {
+	if isBilledWhenStopped(input.Cloud) {
+		return nil
+	}
+
+	server, err := GetRemoteServer(ctx, input.Cloud, input.ID)
+	if err != nil {
+		return err
+	}
+
+	maps.Copy(labels, server.getRunningHoursLabels(now))
+
+	return nil
+}
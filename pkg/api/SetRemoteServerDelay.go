@@ -14,10 +14,8 @@ package api
 
 import (
 	"context"
-	"strconv"
 	"time"
 
-	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
@@ -35,32 +33,16 @@ func SetRemoteServerDelay(ctx context.Context, input SetRemoteServerDelayInput)
 	ctx, span := telemetry.Start(ctx, "api.SetRemoteServerDelay")
 	defer span.End()
 
-	if input.Cloud != "hcloud" {
-		return errors.New("cloud not supported")
-	}
-
 	duration, err := time.ParseDuration(input.Duration)
 	if err != nil {
 		return errors.New("error parse duration")
 	}
 
-	hcloundClient := client.GetHcloudClient()
-
-	id, err := strconv.Atoi(input.ID)
-	if err != nil {
-		return errors.New("can not parse id")
-	}
-
-	server, _, err := hcloundClient.Server.GetByID(ctx, id)
-	if err != nil {
-		return errors.Wrap(err, "can not get server")
-	}
-
 	labels := map[string]string{
 		config.LabelScaleDownDelayShort: utils.TimeToUnix(time.Now().Add(duration)),
 	}
 
-	err = SetRemoteServerLabels(ctx, server, labels)
+	err = SetRemoteServerLabels(ctx, input.Cloud, input.ID, labels)
 	if err != nil {
 		return errors.Wrap(err, "error set labels")
 	}
@@ -14,33 +14,23 @@ package api
 
 import (
 	"context"
-	"maps"
 
-	"github.com/hetznercloud/hcloud-go/hcloud"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/pkg/errors"
 )
 
-func SetRemoteServerLabels(ctx context.Context, server *hcloud.Server, newLabels map[string]string) error {
+// add labels to remote server.
+func SetRemoteServerLabels(ctx context.Context, cloud, id string, labels map[string]string) error {
 	ctx, span := telemetry.Start(ctx, "api.SetRemoteServerLabels")
 	defer span.End()
 
-	labels := server.Labels
-	if labels == nil {
-		labels = make(map[string]string)
-	}
-
-	maps.Copy(labels, newLabels)
-
-	opts := hcloud.ServerUpdateOpts{
-		Labels: labels,
+	provider, err := client.GetRemoteServerProvider(cloud)
+	if err != nil {
+		return err
 	}
 
-	hcloundClient := client.GetHcloudClient()
-
-	_, _, err := hcloundClient.Server.Update(ctx, server, opts)
-	if err != nil {
+	if err := provider.SetLabels(ctx, id, labels); err != nil {
 		return errors.Wrap(err, "error updating server")
 	}
 
@@ -0,0 +1,221 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+)
+
+const (
+	scheduleLabel         = "schedule"
+	scheduleTimezoneLabel = "scheduleTimezone"
+	scheduleTimeFormat    = "15:04"
+	// format of time in label value, labels can not contain ":"
+	scheduleLabelTimeFormat = "1504"
+	scheduleLabelParts      = 3
+)
+
+// working hours of remote server in timezone of owner.
+type RemoteServerSchedule struct {
+	// time of power on, for example 08:00
+	PowerOn string
+	// time of power off, for example 20:00
+	PowerOff string
+	// days of week when server is working, 0 - Sunday
+	Weekdays []time.Weekday
+	// IANA timezone, for example Europe/Berlin
+	Timezone string
+}
+
+func (s *RemoteServerSchedule) Validate() error {
+	powerOn, err := time.Parse(scheduleTimeFormat, s.PowerOn)
+	if err != nil {
+		return errors.Wrap(err, "invalid PowerOn")
+	}
+
+	powerOff, err := time.Parse(scheduleTimeFormat, s.PowerOff)
+	if err != nil {
+		return errors.Wrap(err, "invalid PowerOff")
+	}
+
+	if !powerOff.After(powerOn) {
+		return errors.New("PowerOff must be after PowerOn")
+	}
+
+	if len(s.Weekdays) == 0 {
+		return errors.New("Weekdays are empty")
+	}
+
+	for _, weekday := range s.Weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return errors.Errorf("invalid weekday %d", weekday)
+		}
+	}
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return errors.Wrap(err, "invalid Timezone")
+	}
+
+	// timezone is saved in label
+	if timezone := scheduleTimezoneToLabel(s.Timezone); remoteserver.LabelValue(timezone) != timezone {
+		return errors.Errorf("timezone %s is not supported", s.Timezone)
+	}
+
+	return nil
+}
+
+// returns working hours of day, false if day is not working.
+func (s *RemoteServerSchedule) GetWorkingHours(now time.Time) (time.Time, time.Time, bool) {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	localNow := now.In(location)
+
+	if !slices.Contains(s.Weekdays, localNow.Weekday()) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	parseTime := func(value string) (time.Time, error) {
+		return time.ParseInLocation("2006-01-02 "+scheduleTimeFormat, localNow.Format("2006-01-02 ")+value, location)
+	}
+
+	powerOn, err := parseTime(s.PowerOn)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	powerOff, err := parseTime(s.PowerOff)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return powerOn, powerOff, true
+}
+
+func (s *RemoteServerSchedule) IsWorkingTime(now time.Time) bool {
+	powerOn, powerOff, ok := s.GetWorkingHours(now)
+
+	return ok && !now.Before(powerOn) && now.Before(powerOff)
+}
+
+func (s *RemoteServerSchedule) String() string {
+	weekdays := make([]string, len(s.Weekdays))
+
+	for i, weekday := range s.Weekdays {
+		weekdays[i] = weekday.String()[:3]
+	}
+
+	return fmt.Sprintf("%s-%s %s", s.PowerOn, s.PowerOff, strings.Join(weekdays, ","))
+}
+
+// labels of schedule, empty values removes schedule.
+func (s *RemoteServerSchedule) labels() map[string]string {
+	if s == nil {
+		return map[string]string{
+			scheduleLabel:         "",
+			scheduleTimezoneLabel: "",
+		}
+	}
+
+	weekdays := make([]string, len(s.Weekdays))
+
+	for i, weekday := range s.Weekdays {
+		weekdays[i] = fmt.Sprint(int(weekday))
+	}
+
+	schedule := strings.Join([]string{
+		strings.ReplaceAll(s.PowerOn, ":", ""),
+		strings.ReplaceAll(s.PowerOff, ":", ""),
+		strings.Join(weekdays, ""),
+	}, "-")
+
+	return map[string]string{
+		scheduleLabel:         schedule,
+		scheduleTimezoneLabel: scheduleTimezoneToLabel(s.Timezone),
+	}
+}
+
+// labels can not contain "/", IANA timezones do not contain "."
+func scheduleTimezoneToLabel(timezone string) string {
+	return strings.ReplaceAll(timezone, "/", ".")
+}
+
+// parse schedule from server labels, false if schedule is not set or invalid.
+func newRemoteServerSchedule(labels map[string]string) (*RemoteServerSchedule, bool) {
+	parts := strings.Split(labels[scheduleLabel], "-")
+	if len(parts) != scheduleLabelParts {
+		return nil, false
+	}
+
+	formatTime := func(value string) string {
+		t, err := time.Parse(scheduleLabelTimeFormat, value)
+		if err != nil {
+			return value
+		}
+
+		return t.Format(scheduleTimeFormat)
+	}
+
+	schedule := RemoteServerSchedule{
+		PowerOn:  formatTime(parts[0]),
+		PowerOff: formatTime(parts[1]),
+		Weekdays: make([]time.Weekday, 0, len(parts[2])),
+		Timezone: strings.ReplaceAll(labels[scheduleTimezoneLabel], ".", "/"),
+	}
+
+	for _, weekday := range parts[2] {
+		schedule.Weekdays = append(schedule.Weekdays, time.Weekday(weekday-'0'))
+	}
+
+	if err := schedule.Validate(); err != nil {
+		return nil, false
+	}
+
+	return &schedule, true
+}
+
+type SetRemoteServerScheduleInput struct {
+	Cloud string
+	ID    string
+	// nil - remove schedule
+	Schedule *RemoteServerSchedule
+}
+
+// set working hours of remote server, server is powered on and powered off by batch.
+func SetRemoteServerSchedule(ctx context.Context, input SetRemoteServerScheduleInput) error {
+	ctx, span := telemetry.Start(ctx, "api.SetRemoteServerSchedule")
+	defer span.End()
+
+	if input.Schedule != nil {
+		if err := input.Schedule.Validate(); err != nil {
+			return errors.Wrap(err, "error validate schedule")
+		}
+	}
+
+	err := SetRemoteServerLabels(ctx, input.Cloud, input.ID, input.Schedule.labels())
+	if err != nil {
+		return errors.Wrap(err, "error set labels")
+	}
+
+	return nil
+}
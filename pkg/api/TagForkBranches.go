@@ -0,0 +1,253 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const tagForkBranchPrefix = "tagfork-"
+
+var errDeleteTagForkBranches = errors.New("error deleting tagfork branches")
+
+func getTagForkLabel(projectID string) string {
+	return fmt.Sprintf("%s-%s", config.LabelTagForkBranches, projectID)
+}
+
+func isTagForkBranch(branch string) bool {
+	return strings.HasPrefix(branch, tagForkBranchPrefix)
+}
+
+// branch name contains creation time, it used to detect orphaned branches:
+// tagfork-<unix time>-<tag>-<random>.
+func newTagForkBranchName(tag string, created time.Time) string {
+	return fmt.Sprintf("%s%d-%s-%s", tagForkBranchPrefix, created.Unix(), tag, utils.RandomString(config.TemporaryTokenRandLength))
+}
+
+// returns creation time of tagfork branch, branches created without time in name returns false.
+func getTagForkBranchCreated(branch string) (time.Time, bool) {
+	created, _, ok := strings.Cut(strings.TrimPrefix(branch, tagForkBranchPrefix), "-")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	createdUnix, err := strconv.ParseInt(created, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(createdUnix, 0), true
+}
+
+// add branch to list of tagfork branches that was created for environment.
+func (e *Environment) addTagForkBranch(annotations map[string]string, projectID, branch string) {
+	label := getTagForkLabel(projectID)
+
+	branches := e.getTagForkBranches()[projectID]
+	if !slices.Contains(branches, branch) {
+		branches = append(branches, branch)
+	}
+
+	annotations[label] = strings.Join(branches, ",")
+}
+
+// returns tagfork branches of environment by project ID,
+// installed tagfork branches also returns for environments that was created without records.
+func (e *Environment) getTagForkBranches() map[string][]string {
+	result := make(map[string][]string)
+
+	add := func(projectID, branch string) {
+		if isTagForkBranch(branch) && !slices.Contains(result[projectID], branch) {
+			result[projectID] = append(result[projectID], branch)
+		}
+	}
+
+	for key, value := range e.NamespaceAnnotations {
+		if projectID, ok := strings.CutPrefix(key, config.LabelTagForkBranches+"-"); ok {
+			for branch := range strings.SplitSeq(value, ",") {
+				add(projectID, branch)
+			}
+		}
+
+		if projectID, ok := strings.CutPrefix(key, config.LabelInstalledProject+"-"); ok {
+			add(projectID, value)
+		}
+	}
+
+	return result
+}
+
+// delete tagfork branches of environment, if projectID is empty - branches of all projects will be deleted.
+func (e *Environment) DeleteTagForkBranches(ctx context.Context, projectID string) error {
+	ctx, span := telemetry.Start(ctx, "api.DeleteTagForkBranches")
+	defer span.End()
+
+	tagForkBranches := e.getTagForkBranches()
+	if len(tagForkBranches) == 0 {
+		return nil
+	}
+
+	if e.scmProvider == nil {
+		return errNoSCMProvider
+	}
+
+	deleteErrors := make([]string, 0)
+
+	for branchProjectID, branches := range tagForkBranches {
+		if len(projectID) > 0 && branchProjectID != projectID {
+			continue
+		}
+
+		for _, branch := range branches {
+			if err := deleteBranch(ctx, e.scmProvider, branchProjectID, branch); err != nil {
+				deleteErrors = append(deleteErrors, err.Error())
+			}
+		}
+	}
+
+	if len(deleteErrors) > 0 {
+		return errors.Wrap(errDeleteTagForkBranches, strings.Join(deleteErrors, "\n"))
+	}
+
+	return nil
+}
+
+// delete tagfork branches of projects that are not installed in environment,
+// installed project annotation is removed by delete pipeline when it finished.
+func (e *Environment) DeleteReleasedTagForkBranches(ctx context.Context) error {
+	ctx, span := telemetry.Start(ctx, "api.DeleteReleasedTagForkBranches")
+	defer span.End()
+
+	releasedAnnotations := make([]string, 0)
+	deleteErrors := make([]string, 0)
+
+	for projectID := range e.getTagForkBranches() {
+		if _, ok := e.NamespaceAnnotations[fmt.Sprintf("%s-%s", config.LabelInstalledProject, projectID)]; ok {
+			continue
+		}
+
+		if err := e.DeleteTagForkBranches(ctx, projectID); err != nil {
+			deleteErrors = append(deleteErrors, err.Error())
+
+			continue
+		}
+
+		releasedAnnotations = append(releasedAnnotations, getTagForkLabel(projectID))
+	}
+
+	if len(releasedAnnotations) > 0 {
+		if err := e.DeleteNamespaceAnnotations(ctx, releasedAnnotations); err != nil {
+			deleteErrors = append(deleteErrors, err.Error())
+		}
+	}
+
+	if len(deleteErrors) > 0 {
+		return errors.Wrap(errDeleteTagForkBranches, strings.Join(deleteErrors, "\n"))
+	}
+
+	return nil
+}
+
+func deleteBranch(ctx context.Context, scmProvider scm.Provider, projectID, branch string) error {
+	ctx, span := telemetry.Start(ctx, "api.deleteBranch")
+	defer span.End()
+
+	if err := scmProvider.DeleteBranch(ctx, projectID, branch); err != nil {
+		// branch was already deleted
+		if errors.Is(err, scm.ErrNotFound) {
+			return nil
+		}
+
+		return errors.Wrapf(err, "can not delete branch %s in project %s", branch, projectID)
+	}
+
+	log.Infof("branch %s deleted in project %s", branch, projectID)
+
+	return nil
+}
+
+// delete tagfork branches that are not used in any environment,
+// branch age is calculated from creation time in branch name, branches without it are ignored.
+func DeleteOrphanedTagForkBranches(ctx context.Context, olderThanDays int) error {
+	ctx, span := telemetry.Start(ctx, "api.DeleteOrphanedTagForkBranches")
+	defer span.End()
+
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return errNoSCMProvider
+	}
+
+	environments, err := GetEnvironments(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "error getting environments")
+	}
+
+	usedBranches := make(map[string][]string)
+
+	for _, environment := range environments {
+		for projectID, branches := range environment.getTagForkBranches() {
+			usedBranches[projectID] = append(usedBranches[projectID], branches...)
+		}
+	}
+
+	projects, err := GetCachedProjectsByTopic(ctx, *config.Get().ExternalServicesTopic)
+	if err != nil {
+		return errors.Wrap(err, "error getting projects")
+	}
+
+	maxCreated := time.Now().AddDate(0, 0, -olderThanDays)
+	deleteErrors := make([]string, 0)
+
+	for _, project := range projects {
+		projectID := strconv.FormatInt(project.ID, 10)
+
+		branches, err := scmProvider.ListBranches(ctx, projectID)
+		if err != nil {
+			return errors.Wrapf(err, "can not list branches in project %s", projectID)
+		}
+
+		for _, branch := range branches {
+			if !isTagForkBranch(branch.Name) || slices.Contains(usedBranches[projectID], branch.Name) {
+				continue
+			}
+
+			// new branches can be not recorded in environment yet
+			if created, ok := getTagForkBranchCreated(branch.Name); !ok || created.After(maxCreated) {
+				continue
+			}
+
+			if err := deleteBranch(ctx, scmProvider, projectID, branch.Name); err != nil {
+				deleteErrors = append(deleteErrors, err.Error())
+			}
+		}
+	}
+
+	if len(deleteErrors) > 0 {
+		return errors.Wrap(errDeleteTagForkBranches, strings.Join(deleteErrors, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+)
+
+func TestDeleteTagForkBranches(t *testing.T) {
+	t.Parallel()
+
+	// environment without tagfork branches, nothing to delete
+	environment := &api.Environment{
+		NamespaceAnnotations: map[string]string{
+			config.LabelInstalledProject + "-1": "main",
+		},
+	}
+
+	if err := environment.DeleteTagForkBranches(context.TODO(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// environment with tagfork branches, must use scm provider
+	environment = &api.Environment{
+		NamespaceAnnotations: map[string]string{
+			config.LabelInstalledProject + "-1": "tagfork-v1.0.0-abcde",
+			config.LabelTagForkBranches + "-2":  "tagfork-v1.0.0-abcde,tagfork-v1.0.1-abcde",
+		},
+	}
+
+	if err := environment.DeleteTagForkBranches(context.TODO(), ""); err == nil {
+		t.Fatal("must be error without scm provider")
+	}
+}
+
+func TestDeleteReleasedTagForkBranches(t *testing.T) {
+	t.Parallel()
+
+	// all projects with tagfork branches are installed, nothing to delete
+	environment := &api.Environment{
+		NamespaceAnnotations: map[string]string{
+			config.LabelInstalledProject + "-1": "tagfork-1700000000-v1.0.0-abcde",
+			config.LabelInstalledProject + "-2": "main",
+			config.LabelTagForkBranches + "-2":  "tagfork-1700000000-v1.0.0-abcde",
+		},
+	}
+
+	if err := environment.DeleteReleasedTagForkBranches(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	// project was deleted, branches must be deleted with scm provider
+	environment = &api.Environment{
+		NamespaceAnnotations: map[string]string{
+			config.LabelInstalledProject + "-1": "main",
+			config.LabelTagForkBranches + "-2":  "tagfork-1700000000-v1.0.0-abcde",
+		},
+	}
+
+	if err := environment.DeleteReleasedTagForkBranches(context.TODO()); err == nil {
+		t.Fatal("must be error without scm provider")
+	}
+}
@@ -14,38 +14,40 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"slices"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/cache"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/metrics"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/pkg/errors"
-	gitlab "gitlab.com/gitlab-org/api/client-go"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func GetCachedGitlabProject(ctx context.Context, projectID string) (*gitlab.Project, error) {
-	ctx, span := telemetry.Start(ctx, "api.GetCachedGitlabProject")
+func GetCachedProject(ctx context.Context, projectID string) (*scm.Project, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetCachedProject")
 	defer span.End()
 
-	cacheKey := "gitlab::project::" + projectID
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return nil, errNoSCMProvider
+	}
+
+	cacheKey := "scm::project::" + projectID
 
-	var cacheValue gitlab.Project
+	var cacheValue scm.Project
 
 	if err := cache.Client().Get(ctx, cacheKey, &cacheValue); err == nil {
-		metrics.CacheHits.WithLabelValues("GetCachedGitlabProject").Inc()
+		metrics.CacheHits.WithLabelValues("GetCachedProject").Inc()
 
 		return &cacheValue, nil
 	}
 
-	project, _, err := client.GetGitlabClient().Projects.GetProject(
-		projectID,
-		&gitlab.GetProjectOptions{},
-		gitlab.WithContext(ctx),
-	)
+	project, err := scmProvider.GetProject(ctx, projectID)
 	if err != nil {
 		return nil, errors.Wrap(err, "can not get project")
 	}
@@ -55,24 +57,25 @@ func GetCachedGitlabProject(ctx context.Context, projectID string) (*gitlab.Proj
 	return project, nil
 }
 
-func GetCachedGitlabPipelineVariables(ctx context.Context, projectID string, pipeline int64) ([]*gitlab.PipelineVariable, error) {
-	ctx, span := telemetry.Start(ctx, "api.GetCachedGitlabPipelineVariables")
+func GetCachedPipelineVariables(ctx context.Context, projectID, pipelineID string) (map[string]string, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetCachedPipelineVariables")
 	defer span.End()
 
-	cacheKey := fmt.Sprintf("gitlab::project::%s::pipeline::%d::variables", projectID, pipeline)
-	cacheValue := make([]*gitlab.PipelineVariable, 0)
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return nil, errNoSCMProvider
+	}
+
+	cacheKey := fmt.Sprintf("scm::project::%s::pipeline::%s::variables", projectID, pipelineID)
+	cacheValue := make(map[string]string)
 
 	if err := cache.Client().Get(ctx, cacheKey, &cacheValue); err == nil {
-		metrics.CacheHits.WithLabelValues("GetCachedGitlabPipelineVariables").Inc()
+		metrics.CacheHits.WithLabelValues("GetCachedPipelineVariables").Inc()
 
 		return cacheValue, nil
 	}
 
-	pipelineVars, _, err := client.GetGitlabClient().Pipelines.GetPipelineVariables(
-		projectID,
-		pipeline,
-		gitlab.WithContext(ctx),
-	)
+	pipelineVars, err := scmProvider.GetPipelineVariables(ctx, projectID, pipelineID)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get project pipeline variables")
 	}
@@ -82,44 +85,28 @@ func GetCachedGitlabPipelineVariables(ctx context.Context, projectID string, pip
 	return pipelineVars, nil
 }
 
-func GetCachedGitlabProjectsByTopic(ctx context.Context, topic string) ([]*gitlab.Project, error) {
-	ctx, span := telemetry.Start(ctx, "api.GetCachedGitlabProjectsByTopic")
+func GetCachedProjectsByTopic(ctx context.Context, topic string) ([]*scm.Project, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetCachedProjectsByTopic")
 	defer span.End()
 
-	cacheKey := "gitlab::projects::topic::" + topic
-	cacheValue := make([]*gitlab.Project, 0)
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return nil, errNoSCMProvider
+	}
+
+	cacheKey := "scm::projects::topic::" + topic
+	cacheValue := make([]*scm.Project, 0)
 
 	err := cache.Client().Get(ctx, cacheKey, &cacheValue)
 	if err == nil {
-		metrics.CacheHits.WithLabelValues("GetCachedGitlabProjectsByTopic").Inc()
+		metrics.CacheHits.WithLabelValues("GetCachedProjectsByTopic").Inc()
 
 		return cacheValue, nil
 	}
 
-	projects := make([]*gitlab.Project, 0)
-	pageNumber := int64(1)
-
-	for ctx.Err() == nil {
-		page, resp, err := client.GetGitlabClient().Projects.ListProjects(
-			&gitlab.ListProjectsOptions{
-				Topic: gitlab.Ptr(topic),
-				ListOptions: gitlab.ListOptions{
-					Page: pageNumber,
-				},
-			},
-			gitlab.WithContext(ctx),
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "can not list projects")
-		}
-
-		projects = append(projects, page...)
-
-		if resp.CurrentPage >= resp.TotalPages {
-			break
-		}
-
-		pageNumber++
+	projects, err := scmProvider.ListProjectsByTopic(ctx, topic)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list projects")
 	}
 
 	_ = cache.Client().Set(ctx, cacheKey, projects, cache.HighTTL)
@@ -217,3 +204,59 @@ func GetCachedPersistentVolumeClaims(ctx context.Context, cluster, namespace str
 
 	return pvc.Items, nil
 }
+
+// remove cached project, must be called when project was changed.
+func InvalidateCachedProject(ctx context.Context, projectID string) {
+	ctx, span := telemetry.Start(ctx, "api.InvalidateCachedProject")
+	defer span.End()
+
+	_ = cache.Client().Delete(ctx, "scm::project::"+projectID)
+}
+
+// remove cached pods and pvcs of namespace, must be called after deploy.
+func InvalidateCachedKubernetesResources(ctx context.Context, cluster, namespace string) {
+	ctx, span := telemetry.Start(ctx, "api.InvalidateCachedKubernetesResources")
+	defer span.End()
+
+	_ = cache.Client().Delete(ctx,
+		fmt.Sprintf("kubernetes::pods::%s::%s::%s", cluster, namespace, ""),
+		fmt.Sprintf("kubernetes::pvcs::%s::%s", cluster, namespace),
+	)
+}
+
+// metrics of pods from metrics.k8s.io API, metrics-server must be installed in cluster.
+func GetCachedPodMetrics(ctx context.Context, cluster, namespace string) ([]PodMetrics, error) {
+	ctx, span := telemetry.Start(ctx, "api.GetCachedPodMetrics")
+	defer span.End()
+
+	cacheKey := fmt.Sprintf("kubernetes::podmetrics::%s::%s", cluster, namespace)
+	cacheValue := make([]PodMetrics, 0)
+
+	if err := cache.Client().Get(ctx, cacheKey, &cacheValue); err == nil {
+		metrics.CacheHits.WithLabelValues("GetCachedPodMetrics").Inc()
+
+		return cacheValue, nil
+	}
+
+	clientset, err := client.GetClientset(cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get clientset")
+	}
+
+	data, err := clientset.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1", "namespaces", namespace, "pods").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get pod metrics")
+	}
+
+	podMetrics := PodMetricsList{}
+
+	if err := json.Unmarshal(data, &podMetrics); err != nil {
+		return nil, errors.Wrap(err, "can not parse pod metrics")
+	}
+
+	_ = cache.Client().Set(ctx, cacheKey, podMetrics.Items, cache.LowTTL)
+
+	return podMetrics.Items, nil
+}
@@ -14,14 +14,12 @@ package api
 
 import (
 	"context"
-	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/pkg/errors"
-	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 const (
@@ -63,68 +61,71 @@ type CreateGitlabPipelineInput struct {
 	ProjectID string
 	Ref       string
 	Operation GitlabPipelineOperation
-	Variables []*gitlab.PipelineVariableOptions
+	Variables []*scm.Variable
 }
 
 func (e *Environment) CreateGitlabPipeline(ctx context.Context, input *CreateGitlabPipelineInput) (string, error) {
 	ctx, span := telemetry.Start(ctx, "api.CreateGitlabPipeline")
 	defer span.End()
 
-	if e.gitlabClient == nil {
-		return "", errNoGitlabClient
+	pipeline, err := e.createGitlabPipeline(ctx, input)
+	if err != nil {
+		return "", err
 	}
 
-	if e.IsSystemNamespace() {
-		return "", errors.New("can not create pipeline in system namespace")
+	return pipeline.WebURL, nil
+}
+
+func (e *Environment) createGitlabPipeline(ctx context.Context, input *CreateGitlabPipelineInput) (*scm.Pipeline, error) {
+	ctx, span := telemetry.Start(ctx, "api.createGitlabPipeline")
+	defer span.End()
+
+	if e.scmProvider == nil {
+		return nil, errNoSCMProvider
 	}
 
-	projectIDInt, err := strconv.Atoi(input.ProjectID)
-	if err != nil {
-		return "", errors.Wrap(err, "can not convert to number")
+	if e.IsSystemNamespace() {
+		return nil, errors.New("can not create pipeline in system namespace")
 	}
 
 	// ensure that pipeline can be created only for branches
-	_, tagResp, _ := e.gitlabClient.Tags.GetTag(projectIDInt, input.Ref, gitlab.WithContext(ctx))
-	if tagResp.StatusCode != http.StatusNotFound {
-		return "", errors.New("pipeline can not be created for tag")
+	if _, err := e.scmProvider.GetTag(ctx, input.ProjectID, input.Ref); err == nil {
+		return nil, errors.New("pipeline can not be created for tag")
+	} else if !errors.Is(err, scm.ErrNotFound) {
+		return nil, errors.Wrap(err, "can not get tag")
 	}
 
-	variables := make([]*gitlab.PipelineVariableOptions, 0)
+	variables := make([]*scm.Variable, 0)
 
-	variables = append(variables, &gitlab.PipelineVariableOptions{
-		Key:          gitlab.Ptr(string(input.Operation)),
-		Value:        gitlab.Ptr("true"),
-		VariableType: gitlab.Ptr(gitlab.EnvVariableType),
+	variables = append(variables, &scm.Variable{
+		Key:   string(input.Operation),
+		Value: "true",
 	})
 
-	variables = append(variables, &gitlab.PipelineVariableOptions{
-		Key:          gitlab.Ptr(gitlabNamespaceKey),
-		Value:        gitlab.Ptr(e.Namespace),
-		VariableType: gitlab.Ptr(gitlab.EnvVariableType),
+	variables = append(variables, &scm.Variable{
+		Key:   gitlabNamespaceKey,
+		Value: e.Namespace,
 	})
 
-	variables = append(variables, &gitlab.PipelineVariableOptions{
-		Key:          gitlab.Ptr(gitlabClusterKey),
-		Value:        gitlab.Ptr(e.Cluster),
-		VariableType: gitlab.Ptr(gitlab.EnvVariableType),
+	variables = append(variables, &scm.Variable{
+		Key:   gitlabClusterKey,
+		Value: e.Cluster,
 	})
 
 	if projectProfile := e.getProjectProfile(); projectProfile != nil {
 		for key, value := range projectProfile.PipelineVariables {
-			variables = append(variables, &gitlab.PipelineVariableOptions{
-				Key:          gitlab.Ptr(key),
-				Value:        gitlab.Ptr(value),
-				VariableType: gitlab.Ptr(gitlab.EnvVariableType),
+			variables = append(variables, &scm.Variable{
+				Key:   key,
+				Value: value,
 			})
 		}
 	}
 
 	if clusterProfile := e.getClusterProfile(); clusterProfile != nil {
 		for key, value := range clusterProfile.PipelineVariables {
-			variables = append(variables, &gitlab.PipelineVariableOptions{
-				Key:          gitlab.Ptr(key),
-				Value:        gitlab.Ptr(value),
-				VariableType: gitlab.Ptr(gitlab.EnvVariableType),
+			variables = append(variables, &scm.Variable{
+				Key:   key,
+				Value: value,
 			})
 		}
 	}
@@ -143,32 +144,27 @@ func (e *Environment) CreateGitlabPipeline(ctx context.Context, input *CreateGit
 		keyFormatted = strings.ToUpper(keyFormatted)
 		keyFormatted = strings.ReplaceAll(keyFormatted, "-", "_")
 
-		variables = append(variables, &gitlab.PipelineVariableOptions{
-			Key:          gitlab.Ptr(keyFormatted),
-			Value:        gitlab.Ptr(value),
-			VariableType: gitlab.Ptr(gitlab.EnvVariableType),
+		variables = append(variables, &scm.Variable{
+			Key:   keyFormatted,
+			Value: value,
 		})
 	}
 
 	if user := e.GetUser(ctx); user != "" {
-		variables = append(variables, &gitlab.PipelineVariableOptions{
-			Key:          gitlab.Ptr(gitlabVariablePrefix + "USER"),
-			Value:        gitlab.Ptr(user),
-			VariableType: gitlab.Ptr(gitlab.EnvVariableType),
+		variables = append(variables, &scm.Variable{
+			Key:   gitlabVariablePrefix + "USER",
+			Value: user,
 		})
 	}
 
-	pipeline, _, err := e.gitlabClient.Pipelines.CreatePipeline(
-		projectIDInt,
-		&gitlab.CreatePipelineOptions{
-			Ref:       &input.Ref,
-			Variables: &variables,
-		},
-		gitlab.WithContext(ctx),
-	)
+	pipeline, err := e.scmProvider.TriggerPipeline(ctx, &scm.TriggerPipelineInput{
+		ProjectID: input.ProjectID,
+		Ref:       input.Ref,
+		Variables: variables,
+	})
 	if err != nil {
-		return "", errors.Wrap(err, "can not create pipeline")
+		return nil, errors.Wrap(err, "can not create pipeline")
 	}
 
-	return pipeline.WebURL, nil
+	return pipeline, nil
 }
@@ -15,27 +15,30 @@ package api
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
 	"github.com/pkg/errors"
-	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 var errCreateGitlabPipelinesByServicesError = errors.New("error creating pipelines")
 
 // return branch name if tag exists.
-func (e *Environment) createBranchIfTag(ctx context.Context, pid int, ref string) (string, error) {
+func (e *Environment) createBranchIfTag(ctx context.Context, projectID, ref string) (string, error) {
 	ctx, span := telemetry.Start(ctx, "api.createBranchIfTag")
 	defer span.End()
 
-	tag, tagResp, err := e.gitlabClient.Tags.GetTag(pid, ref, gitlab.WithContext(ctx))
+	if e.scmProvider == nil {
+		return "", errNoSCMProvider
+	}
 
-	if tagResp.StatusCode == http.StatusNotFound {
+	tag, err := e.scmProvider.GetTag(ctx, projectID, ref)
+	if errors.Is(err, scm.ErrNotFound) {
 		return ref, nil
 	}
 
@@ -43,51 +46,70 @@ func (e *Environment) createBranchIfTag(ctx context.Context, pid int, ref string
 		return "", errors.Wrap(err, "can not get tag")
 	}
 
-	gitlabBranch, _, err := e.gitlabClient.Branches.CreateBranch(
-		pid,
-		&gitlab.CreateBranchOptions{
-			Branch: gitlab.Ptr("tagfork-" + ref + "-" + utils.RandomString(config.TemporaryTokenRandLength)),
-			Ref:    gitlab.Ptr(tag.Commit.ID),
-		},
-		gitlab.WithContext(ctx),
+	branch, err := e.scmProvider.CreateBranch(
+		ctx,
+		projectID,
+		newTagForkBranchName(ref, time.Now()),
+		tag.CommitSHA,
 	)
 	if err != nil {
 		return "", errors.Wrap(err, "can not create branch")
 	}
 
-	return gitlabBranch.Name, nil
+	return branch.Name, nil
 }
 
-func (e *Environment) CreateGitlabPipelinesByServices(ctx context.Context, services string, op GitlabPipelineOperation) error {
+// create pipelines for all services, returns created pipelines urls.
+func (e *Environment) CreateGitlabPipelinesByServices(ctx context.Context, services string, op GitlabPipelineOperation) ([]string, error) {
 	ctx, span := telemetry.Start(ctx, "api.CreateGitlabPipelinesByServices")
 	defer span.End()
 
 	if len(services) == 0 {
-		return errors.New("no services was selected")
+		return nil, errors.New("no services was selected")
 	}
 
 	if err := op.Check(); err != nil {
-		return errors.Wrap(err, "operation error")
+		return nil, errors.Wrap(err, "operation error")
+	}
+
+	// two deploys in waves will overwrite status of each other
+	if e.IsDeployRunning(time.Now()) {
+		return nil, errDeployRunning
 	}
 
 	environmentServices, err := ParseEnvironmentServices(services, nil)
 	if err != nil {
-		return errors.Wrap(err, "error parsing services")
+		return nil, errors.Wrap(err, "error parsing services")
+	}
+
+	waves, err := GetDeployWaves(environmentServices, e.getProjectProfile())
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting deploy waves")
+	}
+
+	annotations := e.NamespaceAnnotations
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	// status of previous deploy in waves
+	if len(waves) == 1 {
+		annotations[config.LabelDeployStatus] = ""
 	}
 
 	// create branches for tags
 	for i, environmentService := range environmentServices {
-		ref, err := e.createBranchIfTag(ctx, environmentService.ProjectID, environmentService.Ref)
+		ref, err := e.createBranchIfTag(ctx, environmentService.GeProjectID(), environmentService.Ref)
 		if err != nil {
-			return errors.Wrap(err, "error creating branch")
+			return nil, errors.Wrap(err, "error creating branch")
 		}
 
-		environmentServices[i].Ref = ref
-	}
+		// record created branch, it will be deleted with environment
+		if ref != environmentService.Ref {
+			e.addTagForkBranch(annotations, environmentService.GeProjectID(), ref)
+		}
 
-	annotations := e.NamespaceAnnotations
-	if annotations == nil {
-		annotations = make(map[string]string)
+		environmentServices[i].Ref = ref
 	}
 
 	for _, environmentService := range environmentServices {
@@ -98,45 +120,45 @@ func (e *Environment) CreateGitlabPipelinesByServices(ctx context.Context, servi
 
 	err = e.SaveNamespaceMeta(ctx, annotations, e.NamespaceLabels)
 	if err != nil {
-		return errors.Wrap(err, "error saving namespace annotations")
+		return nil, errors.Wrap(err, "error saving namespace annotations")
 	}
 
-	var (
-		wg   sync.WaitGroup
-		lock sync.Mutex
-	)
-
 	pipelineErrors := make([]string, 0)
+	pipelineURLs := make([]string, 0)
 
-	wg.Add(len(environmentServices))
-
-	for _, environmentService := range environmentServices {
-		go func(e *Environment, environmentService *EnvironmentServices) {
-			defer wg.Done()
+	// services from next waves will be deployed after first wave succeeded
+	firstWave := e.createWavePipelines(ctx, waves[0], op)
 
-			var resultText string
+	for _, pipeline := range firstWave {
+		if len(pipeline.Error) > 0 {
+			pipelineErrors = append(pipelineErrors, pipeline.Error)
+		} else {
+			pipelineURLs = append(pipelineURLs, pipeline.PipelineURL)
+		}
+	}
 
-			_, err := e.CreateGitlabPipeline(ctx, &CreateGitlabPipelineInput{
-				ProjectID: environmentService.GeProjectID(),
-				Ref:       environmentService.Ref,
-				Operation: op,
-			})
-			if err != nil {
-				resultText = err.Error()
+	if len(pipelineErrors) > 0 {
+		return pipelineURLs, errors.Wrap(errCreateGitlabPipelinesByServicesError, strings.Join(pipelineErrors, "\n"))
+	}
 
-				lock.Lock()
-				defer lock.Unlock()
+	if len(waves) > 1 {
+		status := &DeployStatus{
+			Operation: op,
+			Status:    DeployStatusRunning,
+			Waves:     make([][]*DeployWavePipeline, len(waves)),
+			Created:   utils.TimeToString(time.Now()),
+		}
 
-				pipelineErrors = append(pipelineErrors, resultText)
-			}
-		}(e, environmentService)
-	}
+		status.Waves[0] = firstWave
 
-	wg.Wait()
+		if err := e.saveDeployStatus(ctx, status); err != nil {
+			return pipelineURLs, errors.Wrap(err, "error saving deploy status")
+		}
 
-	if len(pipelineErrors) > 0 {
-		return errors.Wrap(errCreateGitlabPipelinesByServicesError, strings.Join(pipelineErrors, "\n"))
+		go e.runDeployWaves(context.WithoutCancel(ctx), status, waves)
 	}
 
-	return nil
+	sort.Strings(pipelineURLs)
+
+	return pipelineURLs, nil
 }
@@ -16,8 +16,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
 )
 
 type DeleteALLResultOperation struct {
@@ -28,6 +30,7 @@ type DeleteALLResult struct {
 	HasErrors                     bool
 	DeleteNamespaceResult         DeleteALLResultOperation
 	DeleteClusterRolesAndBindings DeleteALLResultOperation
+	DeleteTagForkBranches         DeleteALLResultOperation
 }
 
 func (t *DeleteALLResult) JSON() string {
@@ -45,6 +48,7 @@ func (e *Environment) DeleteALL(ctx context.Context) *DeleteALLResult {
 
 	deleteNamespace := make(chan error)
 	deleteClusterRolesAndBindings := make(chan error)
+	deleteTagForkBranches := make(chan error)
 
 	go func() {
 		deleteNamespace <- e.DeleteNamespace(ctx)
@@ -54,6 +58,10 @@ func (e *Environment) DeleteALL(ctx context.Context) *DeleteALLResult {
 		deleteClusterRolesAndBindings <- e.DeleteClusterRolesAndBindings(ctx)
 	}()
 
+	go func() {
+		deleteTagForkBranches <- e.DeleteTagForkBranches(ctx, "")
+	}()
+
 	result := DeleteALLResult{
 		DeleteNamespaceResult: DeleteALLResultOperation{
 			Result: fmt.Sprintf("Namespace %s deleted", e.Namespace),
@@ -61,6 +69,9 @@ func (e *Environment) DeleteALL(ctx context.Context) *DeleteALLResult {
 		DeleteClusterRolesAndBindings: DeleteALLResultOperation{
 			Result: fmt.Sprintf("Cluster role and binding in namespace %s deleted", e.Namespace),
 		},
+		DeleteTagForkBranches: DeleteALLResultOperation{
+			Result: "Tagfork branches deleted",
+		},
 	}
 
 	err := <-deleteNamespace
@@ -79,5 +90,22 @@ func (e *Environment) DeleteALL(ctx context.Context) *DeleteALLResult {
 		}
 	}
 
+	err = <-deleteTagForkBranches
+	if err != nil {
+		result.HasErrors = true
+		result.DeleteTagForkBranches = DeleteALLResultOperation{
+			Result: err.Error(),
+		}
+	}
+
+	e.deleteGitlabWebhookPipelines(ctx)
+
+	eventMessage := e.NewWebhookMessage(types.EventDeleted)
+	eventMessage.Reason = "Environment deleted ..."
+	eventMessage.Properties["slackEmoji"] = ":wastebasket:"
+	eventMessage.Properties["hasErrors"] = strconv.FormatBool(result.HasErrors)
+
+	e.SendWebhookEvent(ctx, eventMessage)
+
 	return &result
 }
@@ -18,11 +18,11 @@ import (
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	gitlab "gitlab.com/gitlab-org/api/client-go"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -30,7 +30,7 @@ import (
 
 type Environment struct {
 	clientset               *kubernetes.Clientset
-	gitlabClient            *gitlab.Client
+	scmProvider             scm.Provider
 	ID                      string
 	Cluster                 string
 	Namespace               string
@@ -48,6 +48,8 @@ type Environment struct {
 	HostsInternal           []string
 	PodsInfo                *PodsInfo
 	NamespaceBadges         []*EnvironmentBadge
+	// usage of ResourceQuota, filled only in environment info
+	QuotaUsage []*QuotaUsage `json:",omitempty"`
 }
 
 // GetEnvironments list all kubernetes-manager environments.
@@ -124,7 +126,7 @@ func (e *Environment) loadFromNamespace(ctx context.Context, namespace corev1.Na
 	}
 
 	e.ID = fmt.Sprintf("%s:%s", e.Cluster, namespace.Name)
-	e.gitlabClient = client.GetGitlabClient()
+	e.scmProvider = client.GetSCMProvider()
 
 	e.Namespace = namespace.Name
 	e.NamespaceStatus = string(namespace.Status.Phase)
@@ -17,4 +17,5 @@ import "errors"
 var (
 	errIsSystemNamespace = errors.New("this namespace is system")
 	errNoGitlabClient    = errors.New("no gitlab client")
+	errNoSCMProvider     = errors.New("no source control provider")
 )
@@ -0,0 +1,54 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+)
+
+// helpers for external tests.
+func (e *Environment) SetSCMProvider(scmProvider scm.Provider) {
+	e.scmProvider = scmProvider
+}
+
+func (e *Environment) GetFollowedBranchesUpdates(ctx context.Context, projectID string, now time.Time) ([]*FollowBranchResult, map[string]string, error) {
+	return e.getFollowedBranchesUpdates(ctx, projectID, now)
+}
+
+var NewDeployProgressWorkload = newDeployProgressWorkload
+
+func CopyLogLines(prefix string, r io.Reader, w io.Writer) error {
+	return copyLogLines(prefix, r, &logWriter{w: w})
+}
+
+var GetPodTargetPort = getPodTargetPort
+
+var NewDebugContainerStatus = newDebugContainerStatus
+
+var NewContainerActionResult = newContainerActionResult
+
+var NewEnvironmentUsage = newEnvironmentUsage
+
+var NewUsageOverview = newUsageOverview
+
+var MergeNamespaceResources = mergeNamespaceResources
+
+var NewQuotaUsage = newQuotaUsage
+
+func (i *GetRemoteServerItem) GetRunningHoursLabels(now time.Time) map[string]string {
+	return i.getRunningHoursLabels(now)
+}
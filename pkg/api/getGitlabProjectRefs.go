@@ -22,7 +22,6 @@ import (
 	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/pkg/errors"
-	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 type GetGitlabProjectBranchItem struct {
@@ -41,60 +40,38 @@ func GetGitlabProjectRefs(ctx context.Context, opts *GetGitlabProjectRefsOpt) ([
 	ctx, span := telemetry.Start(ctx, "api.GetGitlabProjectRefs")
 	defer span.End()
 
-	gitlabClient := client.GetGitlabClient()
+	scmProvider := client.GetSCMProvider()
 
-	if gitlabClient == nil {
-		return nil, errNoGitlabClient
+	if scmProvider == nil {
+		return nil, errNoSCMProvider
 	}
 
 	// to slug ref name - use simple logic
 	// replace all unknown symbols to '-'
 	slugRegexp := regexp.MustCompile(`[^a-zA-Z0-9]`)
 
-	const (
-		gitlabListPerPage = 100
-	)
-
 	result := make([]*GetGitlabProjectBranchItem, 0)
-	currentPage := int64(0)
 
 	// add all project branches
-	for {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-
-		currentPage++
-
-		gitBranches, _, err := gitlabClient.Branches.ListBranches(
-			opts.ProjectID,
-			&gitlab.ListBranchesOptions{
-				ListOptions: gitlab.ListOptions{
-					Page:    currentPage,
-					PerPage: gitlabListPerPage,
-				},
-			},
-			gitlab.WithContext(ctx),
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "can not list branches")
-		}
+	branches, err := scmProvider.ListBranches(ctx, opts.ProjectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list branches")
+	}
 
-		if len(gitBranches) == 0 {
-			break
-		}
+	for _, branch := range branches {
+		result = append(result, &GetGitlabProjectBranchItem{
+			Name:    branch.Name,
+			Slug:    strings.ToLower(slugRegexp.ReplaceAllString(branch.Name, "-")),
+			updated: branch.Updated,
+		})
+	}
 
-		for _, gitBranch := range gitBranches {
-			result = append(result, &GetGitlabProjectBranchItem{
-				Name:    gitBranch.Name,
-				Slug:    strings.ToLower(slugRegexp.ReplaceAllString(gitBranch.Name, "-")),
-				updated: gitBranch.Commit.CommittedDate,
-			})
+	// sort branches by updated date, branches without date will be last
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].updated == nil || result[j].updated == nil {
+			return result[j].updated == nil && result[i].updated != nil
 		}
-	}
 
-	// sort branches by updated date
-	sort.Slice(result, func(i, j int) bool {
 		return result[i].updated.After(*result[j].updated)
 	})
 
@@ -109,28 +86,16 @@ func GetGitlabProjectRefs(ctx context.Context, opts *GetGitlabProjectRefsOpt) ([
 	}
 
 	// add project tags
-	orderBy := "updated"
-
-	gitTags, _, err := gitlabClient.Tags.ListTags(
-		opts.ProjectID,
-		&gitlab.ListTagsOptions{
-			ListOptions: gitlab.ListOptions{
-				Page:    0,
-				PerPage: opts.MaxTags,
-			},
-			OrderBy: &orderBy,
-		},
-		gitlab.WithContext(ctx),
-	)
+	tags, err := scmProvider.ListTags(ctx, opts.ProjectID, opts.MaxTags)
 	if err != nil {
 		return nil, errors.Wrap(err, "can not list tags")
 	}
 
-	for _, gitTag := range gitTags {
+	for _, tag := range tags {
 		result = append(result, &GetGitlabProjectBranchItem{
-			Name:    gitTag.Name,
-			Slug:    strings.ToLower(slugRegexp.ReplaceAllString(gitTag.Name, "-")),
-			updated: gitTag.Commit.CommittedDate,
+			Name:    tag.Name,
+			Slug:    strings.ToLower(slugRegexp.ReplaceAllString(tag.Name, "-")),
+			updated: tag.Updated,
 		})
 	}
 
@@ -57,7 +57,7 @@ func GetGitlabProjects(ctx context.Context, input *GetGitlabProjectsInput) ([]*G
 	ctx, span := telemetry.Start(ctx, "api.GetGitlabProjects")
 	defer span.End()
 
-	projects, err := GetCachedGitlabProjectsByTopic(ctx, *config.Get().ExternalServicesTopic)
+	projects, err := GetCachedProjectsByTopic(ctx, *config.Get().ExternalServicesTopic)
 	if err != nil {
 		return nil, errors.Wrap(err, "can not list projects")
 	}
@@ -94,7 +94,7 @@ func GetGitlabProjects(ctx context.Context, input *GetGitlabProjectsInput) ([]*G
 	for _, project := range projects {
 		item := GetGitlabProjectsItem{
 			ProjectID:      project.ID,
-			Name:           project.NameWithNamespace,
+			Name:           project.Name,
 			Description:    project.Description,
 			DefaultBranch:  project.DefaultBranch,
 			WebURL:         project.WebURL,
@@ -32,11 +32,7 @@ func (e *Environment) GetGitlabProjectsInfo(ctx context.Context, projectID, bran
 	ctx, span := telemetry.Start(ctx, "api.GetGitlabProjectsInfo")
 	defer span.End()
 
-	if e.gitlabClient == nil {
-		return nil, errNoGitlabClient
-	}
-
-	project, err := GetCachedGitlabProject(ctx, projectID)
+	project, err := GetCachedProject(ctx, projectID)
 	if err != nil {
 		return nil, errors.Wrap(err, "can not get project")
 	}
@@ -50,7 +46,7 @@ func (e *Environment) GetGitlabProjectsInfo(ctx context.Context, projectID, bran
 		return nil, errors.Wrap(err, "can not get pipelines")
 	}
 
-	projectImagePrefix := project.PathWithNamespace
+	projectImagePrefix := project.Path
 	projectSetting := config.Get().GetProjectSetting(projectID)
 
 	if projectSetting != nil && len(projectSetting.ImagePrefix) > 0 {
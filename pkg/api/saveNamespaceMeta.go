@@ -21,6 +21,7 @@ import (
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 )
 
 func (e *Environment) SaveNamespaceMeta(ctx context.Context, annotation map[string]string, labels map[string]string) error {
@@ -62,3 +63,74 @@ func (e *Environment) SaveNamespaceMeta(ctx context.Context, annotation map[stri
 
 	return nil
 }
+
+// remove annotations from namespace, annotations with null value are removed by merge patch.
+func (e *Environment) DeleteNamespaceAnnotations(ctx context.Context, keys []string) error {
+	ctx, span := telemetry.Start(ctx, "api.DeleteNamespaceAnnotations")
+	defer span.End()
+
+	annotations := make(map[string]*string, len(keys))
+
+	for _, key := range keys {
+		annotations[key] = nil
+	}
+
+	payload := map[string]any{
+		"metadata": map[string]any{
+			"annotations": annotations,
+		},
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling payload")
+	}
+
+	namespaces := e.clientset.CoreV1().Namespaces()
+
+	_, err = namespaces.Patch(ctx, e.Namespace, types.MergePatchType, payloadBytes, metav1.PatchOptions{})
+	if err != nil {
+		return errors.Wrap(err, "can not delete annotations")
+	}
+
+	for _, key := range keys {
+		delete(e.NamespaceAnnotations, key)
+	}
+
+	return nil
+}
+
+// update annotation that can be changed by several replicas, update is called with current value
+// and is repeated if namespace was changed after it was read.
+func (e *Environment) UpdateNamespaceAnnotation(ctx context.Context, key string, update func(value string) (string, error)) error {
+	ctx, span := telemetry.Start(ctx, "api.UpdateNamespaceAnnotation")
+	defer span.End()
+
+	namespaces := e.clientset.CoreV1().Namespaces()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		namespace, err := namespaces.Get(ctx, e.Namespace, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrap(err, "can not get namespace")
+		}
+
+		value, err := update(namespace.Annotations[key])
+		if err != nil {
+			return err
+		}
+
+		if namespace.Annotations == nil {
+			namespace.Annotations = make(map[string]string)
+		}
+
+		namespace.Annotations[key] = value
+
+		// namespace has resourceVersion from get, update fails with conflict if namespace was changed
+		updatedNamespace, err := namespaces.Update(ctx, namespace, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+
+		return e.loadFromNamespace(ctx, *updatedNamespace)
+	})
+}
@@ -28,9 +28,13 @@ func (e *Environment) ScaleALL(ctx context.Context, replicas int32) error {
 	ctx, span := telemetry.Start(ctx, "api.ScaleALL")
 	defer span.End()
 
+	var podInfo *PodsInfo
+
 	// if scaleDown do nothing if environment have not pods
 	if replicas == 0 {
-		podInfo, err := e.GetPodsInfo(ctx)
+		var err error
+
+		podInfo, err = e.GetPodsInfo(ctx)
 		if err != nil {
 			return errors.Wrap(err, "error while getting pods info")
 		}
@@ -83,6 +87,8 @@ func (e *Environment) ScaleALL(ctx context.Context, replicas int32) error {
 	if err != nil {
 		hasError = true
 		result.ErrProcessScale = err.Error()
+	} else if err := e.saveCostUsage(ctx, replicas, podInfo); err != nil {
+		log.WithError(err).Error("error saving cost usage")
 	}
 
 	if hasError {
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/stream"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
 	"github.com/pkg/errors"
@@ -33,6 +34,21 @@ func (e *Environment) ScaleNamespace(ctx context.Context, replicas int32) error
 	ctx, span := telemetry.Start(ctx, "api.ScaleNamespace")
 	defer span.End()
 
+	err := e.scaleNamespace(ctx, replicas)
+
+	// send scale result to environment stream
+	data := stream.ScaleData{Replicas: replicas}
+
+	if err != nil {
+		data.Error = err.Error()
+	}
+
+	stream.Publish(e.ID, stream.EventScale, data)
+
+	return err
+}
+
+func (e *Environment) scaleNamespace(ctx context.Context, replicas int32) error {
 	var wg sync.WaitGroup
 
 	var syncErrors sync.Map
@@ -225,10 +225,20 @@ func processCreateNewBranch(ctx context.Context, input *StartNewEnvironmentInput
 		return nil, errors.Wrap(err, "error creating namespace")
 	}
 
-	if err := environment.CreateGitlabPipelinesByServices(ctx, input.Services, GitlabPipelineOperationBuild); err != nil {
+	pipelineURLs, err := environment.CreateGitlabPipelinesByServices(ctx, input.Services, GitlabPipelineOperationBuild)
+	if err != nil {
 		return nil, errors.Wrap(err, "error creating gitlab pipelines")
 	}
 
+	eventMessage := environment.NewWebhookMessage(types.EventCreated)
+	eventMessage.Reason = "Environment created ..."
+	eventMessage.Properties["slackEmoji"] = ":rocket:"
+	eventMessage.Properties["profile"] = input.Profile
+	eventMessage.Properties["services"] = input.Services
+	eventMessage.Properties["pipelines"] = strings.Join(pipelineURLs, "\n")
+
+	environment.SendWebhookEvent(ctx, eventMessage)
+
 	return environment, nil
 }
 
@@ -374,5 +384,10 @@ func NewEnvironment(ctx context.Context, input *StartNewEnvironmentInput) (*Envi
 		return nil, errors.Wrap(err, "error creating new namespace")
 	}
 
+	// environment is created even if quota can not be applied, it will be applied in batch
+	if err := environment.ApplyNamespaceResources(ctx); err != nil {
+		log.WithError(err).Errorf("error applying resources to namespace %s", namespace.Name)
+	}
+
 	return environment, nil
 }
@@ -20,7 +20,6 @@ import (
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
-	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/webhook"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -116,7 +115,7 @@ func scaleDownALL(ctx context.Context) error {
 		}
 	}
 
-	// scaledown servers
+	// power on and power off servers
 	servers, err := api.GetRemoteServers(ctx)
 	if err != nil {
 		return errors.Wrap(err, "error listing servers")
@@ -132,31 +131,7 @@ func scaleDownALL(ctx context.Context) error {
 			ctx, cancel := context.WithTimeout(ctx, maxScaleDownDuration)
 			defer cancel()
 
-			log := log.WithField("server", server.Name)
-			// calculate is delay is active
-			if delay, ok := server.Labels[config.LabelScaleDownDelayShort]; ok {
-				scaleDelayTime, err := utils.UnixToTime(delay)
-				if err != nil {
-					return errors.Wrap(err, "error parse scale delay time")
-				} else if time.Now().Before(scaleDelayTime) {
-					log.Info("scale down delay is active")
-
-					return nil
-				}
-			}
-
-			log.Info("scaledown server")
-
-			err := api.SetRemoteServerAction(ctx, api.SetRemoteServerActionInput{
-				Cloud:  server.Cloud,
-				ID:     server.ID,
-				Action: api.SetRemoteServerStatusPowerOff,
-			})
-			if err != nil {
-				return errors.Wrapf(err, "error power off server %s", server.ID)
-			}
-
-			return nil
+			return api.ScaleRemoteServer(ctx, server)
 		}(server)
 		if err != nil {
 			log.WithError(err).Error()
@@ -200,6 +175,26 @@ func Execute(ctx context.Context) error {
 			log.WithError(err).Error()
 		}
 
+		// reconcile ResourceQuota and LimitRange with profile
+		if err := environment.ApplyNamespaceResources(ctx); err != nil {
+			log.WithError(err).Error("error applying namespace resources")
+		}
+
+		// deploy in waves was interrupted by restart
+		if err := environment.FailStaledDeploy(ctx); err != nil {
+			log.WithError(err).Error("error saving deploy status")
+		}
+
+		// delete tagfork branches of deleted services
+		if err := environment.DeleteReleasedTagForkBranches(ctx); err != nil {
+			log.WithError(err).Error()
+		}
+
+		// deploy new commits in followed branches
+		if _, err := environment.UpdateFollowedBranches(ctx, ""); err != nil {
+			log.WithError(err).Error()
+		}
+
 		reason, description := environment.IsStaled(0)
 
 		log.WithField("reason", reason).Debug(description)
@@ -211,5 +206,23 @@ func Execute(ctx context.Context) error {
 		}
 	}
 
+	if err := api.DeleteStaledRemoteServers(ctx); err != nil {
+		log.WithError(err).Error()
+	}
+
+	if err := api.UpdateCostMetrics(ctx); err != nil {
+		log.WithError(err).Error()
+	}
+
+	if err := api.UpdateUsageMetrics(ctx); err != nil {
+		log.WithError(err).Error()
+	}
+
+	if days := config.Get().TagFork.RemoveOrphanedAfterDays; days > 0 {
+		if err := api.DeleteOrphanedTagForkBranches(ctx, days); err != nil {
+			log.WithError(err).Error()
+		}
+	}
+
 	return nil
 }
@@ -30,6 +30,10 @@ func (p *Provider) Set(_ context.Context, _ string, _ any, _ time.Duration) erro
 	return errNotImplemented
 }
 
+func (p *Provider) Delete(_ context.Context, _ ...string) error {
+	return errNotImplemented
+}
+
 func (p *Provider) FlushALL(_ context.Context) error {
 	return errNotImplemented
 }
@@ -126,6 +126,21 @@ func (p *Provider) Set(ctx context.Context, key string, value any, ttl time.Dura
 	return nil
 }
 
+func (p *Provider) Delete(ctx context.Context, keys ...string) error {
+	ctx, span := telemetry.Start(ctx, "cache.redis.Delete")
+	defer span.End()
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := p.client.Del(ctx, keys...).Err(); err != nil {
+		return errors.Wrap(err, "p.client.Del")
+	}
+
+	return nil
+}
+
 func (p *Provider) FlushALL(ctx context.Context) error {
 	ctx, span := telemetry.Start(ctx, "cache.redis.FlushALL")
 	defer span.End()
@@ -15,9 +15,15 @@ package client
 import (
 	"net/http"
 
-	"github.com/hetznercloud/hcloud-go/hcloud"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/metrics"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver/aws"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver/azure"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver/hcloud"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm/github"
+	scmgitlab "github.com/maksim-paskal/kubernetes-manager/pkg/scm/gitlab"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/sentry"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -30,9 +36,11 @@ import (
 
 var (
 	gitlabClient *gitlab.Client
-	hcloudClient *hcloud.Client
+	scmProvider  scm.Provider
 	sentryClient *sentry.Client
 
+	remoteServerProviders map[string]remoteserver.Provider
+
 	clientsetCluster  map[string]*kubernetes.Clientset
 	restconfigCluster map[string]*rest.Config
 )
@@ -42,11 +50,26 @@ var gitlabHTTPClient = &http.Client{
 	Transport: metrics.NewInstrumenter("gitlab").InstrumentedRoundTripper(),
 }
 
+var githubHTTPClient = &http.Client{
+	Jar:       nil,
+	Transport: metrics.NewInstrumenter("github").InstrumentedRoundTripper(),
+}
+
 var hcloudHTTPClient = &http.Client{
 	Jar:       nil,
 	Transport: metrics.NewInstrumenter("hcloud").InstrumentedRoundTripper(),
 }
 
+var awsHTTPClient = &http.Client{
+	Jar:       nil,
+	Transport: metrics.NewInstrumenter("aws").InstrumentedRoundTripper(),
+}
+
+var azureHTTPClient = &http.Client{
+	Jar:       nil,
+	Transport: metrics.NewInstrumenter("azure").InstrumentedRoundTripper(),
+}
+
 var sentryHTTPClient = &http.Client{
 	Jar:       nil,
 	Transport: metrics.NewInstrumenter("sentry").InstrumentedRoundTripper(),
@@ -58,6 +81,11 @@ func GetGitlabClient() *gitlab.Client {
 	return gitlabClient
 }
 
+// returns source control provider, nil if provider is not configured.
+func GetSCMProvider() scm.Provider {
+	return scmProvider
+}
+
 func GetSentryClient() *sentry.Client {
 	return sentryClient
 }
@@ -98,8 +126,27 @@ func GetInclusterClientset() (*kubernetes.Clientset, error) {
 	return clientset, nil
 }
 
-func GetHcloudClient() *hcloud.Client {
-	return hcloudClient
+// returns remote server provider by name from config.
+func GetRemoteServerProvider(name string) (remoteserver.Provider, error) {
+	provider, ok := remoteServerProviders[name]
+	if !ok {
+		return nil, errors.Errorf("remote server provider %s not found", name)
+	}
+
+	return provider, nil
+}
+
+// returns names of remote server providers in config order.
+func GetRemoteServerProviders() []string {
+	result := make([]string, 0, len(remoteServerProviders))
+
+	for _, provider := range config.Get().RemoteServer.GetProviders() {
+		if _, ok := remoteServerProviders[provider.Name]; ok {
+			result = append(result, provider.Name)
+		}
+	}
+
+	return result
 }
 
 func Init() error {
@@ -119,6 +166,10 @@ func Init() error {
 		}
 	}
 
+	if err := initSCMProvider(); err != nil {
+		return errors.Wrap(err, "can not create source control provider")
+	}
+
 	if config.Get().Sentry != nil {
 		sentryClient = sentry.NewClient(config.Get().Sentry.Endpoint)
 
@@ -170,10 +221,91 @@ func Init() error {
 		restconfigCluster[kubernetesEndpoints.Name] = restconfig
 	}
 
-	hcloudClient = hcloud.NewClient(
-		hcloud.WithToken(config.Get().RemoteServer.HetznerToken),
-		hcloud.WithHTTPClient(hcloudHTTPClient),
-	)
+	if err := initRemoteServerProviders(); err != nil {
+		return errors.Wrap(err, "can not create remote server providers")
+	}
+
+	return nil
+}
+
+func initRemoteServerProviders() error {
+	remoteServerProviders = make(map[string]remoteserver.Provider)
+
+	for _, providerConfig := range config.Get().RemoteServer.GetProviders() {
+		var (
+			provider remoteserver.Provider
+			err      error
+		)
+
+		switch remoteserver.ProviderType(providerConfig.Type) {
+		case remoteserver.ProviderHcloud:
+			hcloudConfig := hcloud.ProviderConfig{}
+
+			if err := remoteserver.DecodeConfig(providerConfig.Config, &hcloudConfig); err != nil {
+				return errors.Wrap(err, providerConfig.Name)
+			}
+
+			provider = hcloud.NewProvider(hcloudConfig, hcloudHTTPClient)
+		case remoteserver.ProviderAWS:
+			awsConfig := aws.ProviderConfig{}
+
+			if err := remoteserver.DecodeConfig(providerConfig.Config, &awsConfig); err != nil {
+				return errors.Wrap(err, providerConfig.Name)
+			}
+
+			provider, err = aws.NewProvider(awsConfig, awsHTTPClient)
+		case remoteserver.ProviderAzure:
+			azureConfig := azure.ProviderConfig{}
+
+			if err := remoteserver.DecodeConfig(providerConfig.Config, &azureConfig); err != nil {
+				return errors.Wrap(err, providerConfig.Name)
+			}
+
+			provider, err = azure.NewProvider(azureConfig, azureHTTPClient)
+		default:
+			return errors.Errorf("unknown remote server provider type %s", providerConfig.Type)
+		}
+
+		if err != nil {
+			return errors.Wrap(err, providerConfig.Name)
+		}
+
+		remoteServerProviders[providerConfig.Name] = provider
+	}
+
+	return nil
+}
+
+func initSCMProvider() error {
+	switch scm.ProviderName(config.Get().SCM.Provider) {
+	case scm.ProviderGithub:
+		githubConfig := config.Get().SCM.GitHub
+
+		provider := github.NewProvider(githubConfig.URL)
+		provider.Token = githubConfig.Token
+		provider.Owner = githubConfig.Owner
+		provider.Workflow = githubConfig.Workflow
+		provider.RunNameVariables = githubConfig.RunNameVariables
+		provider.HTTPClient = githubHTTPClient
+
+		scmProvider = provider
+	case scm.ProviderGitlab:
+		// provider is optional as gitlab client
+		if gitlabClient == nil {
+			return nil
+		}
+
+		provider, err := scmgitlab.NewProvider(gitlabClient)
+		if err != nil {
+			return err
+		}
+
+		provider.TriggerUser = *config.Get().GitlabTokenUser
+
+		scmProvider = provider
+	default:
+		return errors.Errorf("unknown provider %s", config.Get().SCM.Provider)
+	}
 
 	return nil
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
@@ -46,10 +47,19 @@ const (
 	HoursInDay     = 24
 	KeyValueLength = 2
 
+	// web requests are cancelled after this timeout
+	RequestTimeoutSeconds = 60
+
 	TrueValue = "true"
 
 	LabelScaleDownDelayShort = "scaleDownDelay"
 
+	// webhooks with this ID receive events of remote servers
+	RemoteServerWebhookID = "remote-servers"
+
+	// name of remote server provider created from HetznerToken
+	RemoteServerProviderHcloud = "hcloud"
+
 	Namespace             = "kubernetes-manager"
 	AnnotationPrefix      = Namespace + "/"
 	FilterLabels          = Namespace + "=true"
@@ -71,6 +81,15 @@ const (
 	LabelGitSyncOrigin    = Namespace + "/git-sync-origin"
 	LabelGitSyncBranch    = Namespace + "/git-sync-branch"
 	LabelDescription      = Namespace + "/description"
+	LabelFollowBranch     = Namespace + "/follow-branch"
+	LabelFollowBranchSHA  = Namespace + "/follow-branch-sha"
+	LabelFollowBranchLast = Namespace + "/follow-branch-last-update"
+	LabelFollowBranchInfo = Namespace + "/follow-branch-result"
+	LabelTagForkBranches  = Namespace + "/tagfork"
+	LabelDeployStatus     = Namespace + "/deploy-status"
+	LabelAutotestQueue    = Namespace + "/autotest-queue"
+	LabelCostUsage        = Namespace + "/cost-usage"
+	LabelResources        = Namespace + "/resources-override"
 
 	HeaderOwner = "X-Owner"
 )
@@ -165,7 +184,10 @@ type ProjectProfile struct {
 	Exclude           string // project ids to exclude (comma separated) or * for all
 	Include           string // project ids to include (comma separated)
 	IncludeNamespaced string // project ids to include (comma separated) for namespaced
+	Dependencies      string // deploy project after dependency project (comma separated format projectId=dependencyProjectId)
 	PipelineVariables map[string]string
+	// ResourceQuota and LimitRange of environments with profile, resources from NamespaceMeta are used if empty
+	Resources *NamespaceResources
 }
 
 func (p *ProjectProfile) Validate() error {
@@ -195,6 +217,10 @@ func (p *ProjectProfile) Validate() error {
 		return errors.Errorf("invalid Include, valid (%s) got (%s)", re.String(), p.Include)
 	}
 
+	if re := regexp.MustCompile(`^\d+=\d+(,\d+=\d+)*$`); len(p.Dependencies) > 0 && !re.MatchString(p.Dependencies) {
+		return errors.Errorf("invalid Dependencies, valid (%s) got (%s)", re.String(), p.Dependencies)
+	}
+
 	return nil
 }
 
@@ -255,6 +281,30 @@ func (p *ProjectProfile) GetProjectSelectedBranch(projectID int64) string {
 	return ""
 }
 
+// returns project ids that must be deployed before project.
+func (p *ProjectProfile) GetProjectDependencies(projectID int64) []string {
+	result := make([]string, 0)
+
+	if len(p.Dependencies) == 0 {
+		return result
+	}
+
+	for dependency := range strings.SplitSeq(p.Dependencies, ",") {
+		dependencyData := strings.Split(dependency, "=")
+		if len(dependencyData) != KeyValueLength {
+			log.Errorf("invalid dependency format %s", dependency)
+
+			continue
+		}
+
+		if dependencyData[0] == strconv.FormatInt(projectID, 10) {
+			result = append(result, dependencyData[1])
+		}
+	}
+
+	return result
+}
+
 func (p *ProjectProfile) GetProjectSortPriority(projectID int64) int {
 	if len(p.SortPriorities) == 0 {
 		return p.DefaultPriority
@@ -287,6 +337,13 @@ type NamespaceMeta struct {
 	Pattern     string
 	Labels      map[string]string
 	Annotations map[string]string
+	Resources   *NamespaceResources
+}
+
+// ResourceQuota and LimitRange that are created in namespace of environment.
+type NamespaceResources struct {
+	ResourceQuota *corev1.ResourceQuotaSpec
+	LimitRange    *corev1.LimitRangeSpec
 }
 
 func (n *NamespaceMeta) GetTemplatedValue(ctx context.Context) *NamespaceMeta {
@@ -316,6 +373,137 @@ type WebHook struct {
 	Config   any
 	IDs      []string
 	Events   []types.Event
+	// price per hour of cloud resources that are started and stopped by webhook (resources with kubernetes-manager tags),
+	// used in cost estimation of environments
+	CostPerHour float64
+}
+
+type GitlabWebhook struct {
+	// secret token of GitLab webhook, if empty webhook endpoint is disabled
+	Token string
+	// create deploy pipeline in environments that follows pushed branch
+	AutoRedeploy bool
+}
+
+type FollowBranch struct {
+	// minimal number of new commits in branch to create deploy pipeline
+	MinCommitsBehind int
+	// minimal interval between deploy pipelines of one project in environment
+	MinIntervalMinutes int
+}
+
+type TagFork struct {
+	// remove tagfork branches that are not used in environments, 0 - disabled
+	RemoveOrphanedAfterDays int
+}
+
+type DeployWaves struct {
+	// interval between checks of pipelines statuses in wave
+	PollIntervalSeconds int
+	// max time of all waves, next waves will not be created after timeout
+	TimeoutMinutes int
+}
+
+const (
+	// interactive terminal in container.
+	PermissionTerminal = "terminal"
+	// TCP connections to services and pods.
+	PermissionPortForward = "port-forward"
+	// ephemeral debug containers in pods.
+	PermissionDebugContainer = "debug-container"
+	// create remote servers from templates, pattern matches template name.
+	PermissionRemoteServerCreate = "remote-server-create"
+	// delete remote servers of other users.
+	PermissionRemoteServerDelete = "remote-server-delete"
+	// override ResourceQuota and LimitRange of environment.
+	PermissionNamespaceResources = "namespace-resources"
+	// everyone has permission.
+	AllUsers = "*"
+)
+
+// rule grants permission to users in environments.
+type AuthorizationRule struct {
+	Permission string
+	// users that have permission, * - all users
+	Users []string
+	// regexp of environment IDs (cluster:namespace), empty - all environments
+	Pattern string
+}
+
+func (r *AuthorizationRule) Match(permission, user, environmentID string) bool {
+	if r.Permission != permission || len(user) == 0 {
+		return false
+	}
+
+	if !slices.Contains(r.Users, AllUsers) && !slices.Contains(r.Users, user) {
+		return false
+	}
+
+	if len(r.Pattern) == 0 {
+		return true
+	}
+
+	matched, err := regexp.MatchString(r.Pattern, environmentID)
+	if err != nil {
+		log.WithError(err).Warnf("invalid authorization pattern %s", r.Pattern)
+
+		return false
+	}
+
+	return matched
+}
+
+// permission is granted if some rule matches user and environment, without rules permission is denied.
+func (t *Type) IsAuthorized(permission, user, environmentID string) bool {
+	for _, rule := range t.AuthorizationRules {
+		if rule.Match(permission, user, environmentID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Terminal struct {
+	// command that is executed in container
+	Command []string
+	// session is closed when user sends no input
+	IdleTimeoutMinutes int
+	// max duration of session
+	MaxDurationMinutes int
+}
+
+type DebugContainer struct {
+	// images that can be used in ephemeral debug containers
+	Images []string
+	// time to wait for debug container to start, must be less than web request timeout
+	StartTimeoutSeconds int
+}
+
+func (d *DebugContainer) Validate() error {
+	if d.StartTimeoutSeconds <= 0 || d.StartTimeoutSeconds >= RequestTimeoutSeconds {
+		return errors.Errorf("StartTimeoutSeconds must be between 1 and %d", RequestTimeoutSeconds-1)
+	}
+
+	return nil
+}
+
+type GitHub struct {
+	// GitHub API URL, for GitHub Enterprise use https://<host>/api/v3
+	URL   string
+	Token string
+	// organization or user that owns repositories
+	Owner string
+	// workflow file that is used to create pipelines, workflow must have workflow_dispatch trigger
+	Workflow string
+	// pipeline variables that are added to workflow run name, only these variables can be read from runs
+	RunNameVariables []string
+}
+
+type SCM struct {
+	// source control provider: gitlab or github
+	Provider string
+	GitHub   GitHub
 }
 
 type Snapshot struct {
@@ -323,9 +511,144 @@ type Snapshot struct {
 	Ref       string
 }
 
+type RemoteServerProvider struct {
+	// unique name of provider, returned as cloud of server
+	Name string
+	// hcloud, aws or azure
+	Type string
+	// credentials and filters of provider
+	Config any
+}
+
+// template of developer server in Hetzner Cloud.
+type RemoteServerTemplate struct {
+	Name        string
+	Description string
+	// name of provider with hcloud type, hcloud by default
+	Provider   string
+	Image      string
+	ServerType string
+	Location   string
+	// names of SSH keys in Hetzner Cloud project
+	SSHKeys []string
+	// cloud-init user data, template with .Name, .Owner and .Template
+	UserData string
+	Labels   map[string]string
+}
+
+func (t *RemoteServerTemplate) Validate() error {
+	if len(t.Name) == 0 {
+		return errors.New("name is empty")
+	}
+
+	if len(t.Image) == 0 || len(t.ServerType) == 0 {
+		return errors.New("image and servertype are required")
+	}
+
+	return nil
+}
+
+func (t *RemoteServerTemplate) GetProvider() string {
+	if len(t.Provider) == 0 {
+		return RemoteServerProviderHcloud
+	}
+
+	return t.Provider
+}
+
 type RemoteServer struct {
+	// token of Hetzner Cloud, adds hcloud provider
 	HetznerToken string
+	Providers    []*RemoteServerProvider
 	Links        []*OtherLink
+	Templates    []*RemoteServerTemplate
+	// server is staled when it was not powered on for this days
+	StaledDays int
+	// staled server is deleted after owner was warned, 0 - staled servers are not deleted
+	DeleteStaledAfterDays int
+	// running server is idle when average CPU usage for IdleMinutes is less than IdleCPUPercent,
+	// 0 - idle servers are not powered off
+	IdleMinutes    int
+	IdleCPUPercent float64
+}
+
+func (r *RemoteServer) GetProvider(name string) *RemoteServerProvider {
+	for _, provider := range r.GetProviders() {
+		if provider.Name == name {
+			return provider
+		}
+	}
+
+	return nil
+}
+
+func (r *RemoteServer) GetTemplate(name string) *RemoteServerTemplate {
+	for _, template := range r.Templates {
+		if template.Name == name {
+			return template
+		}
+	}
+
+	return nil
+}
+
+// providers from config, HetznerToken is used as hcloud provider.
+func (r *RemoteServer) GetProviders() []*RemoteServerProvider {
+	if len(r.HetznerToken) == 0 {
+		return r.Providers
+	}
+
+	for _, provider := range r.Providers {
+		if provider.Name == RemoteServerProviderHcloud {
+			return r.Providers
+		}
+	}
+
+	hcloudProvider := RemoteServerProvider{
+		Name: RemoteServerProviderHcloud,
+		Type: RemoteServerProviderHcloud,
+		Config: map[string]string{
+			"Token": r.HetznerToken,
+		},
+	}
+
+	return append([]*RemoteServerProvider{&hcloudProvider}, r.Providers...)
+}
+
+// prices of resources for cost estimation.
+type Cost struct {
+	// currency of prices, only for display
+	Currency string
+	// price of 1 requested vCPU per hour
+	CPUHour float64
+	// price of 1 GiB of requested memory per hour
+	MemoryGiBHour float64
+	// price of 1 GiB of persistent volume per month
+	StorageGiBMonth float64
+	// price per hour of remote server types, for example cx22
+	ServerTypeHour map[string]float64
+}
+
+func (c *Cost) Validate() error {
+	if c.CPUHour < 0 || c.MemoryGiBHour < 0 || c.StorageGiBMonth < 0 {
+		return errors.New("prices must not be negative")
+	}
+
+	for serverType, price := range c.ServerTypeHour {
+		if price < 0 {
+			return errors.New("price of server type must not be negative: " + serverType)
+		}
+	}
+
+	return nil
+}
+
+// settings of resource usage from metrics-server.
+type ResourceUsage struct {
+	// pod is over-requested when usage is less than this part of requests
+	OverRequestRatio float64
+	// size of top consumers lists
+	TopConsumers int
 }
 
 type AutotestCustomActionEnvType string
@@ -374,6 +697,73 @@ type AutotestAction struct {
 	Release string
 	Ref     string
 }
+
+// automatic run of test, test is started only in environments that are not scaled down.
+type AutotestSchedule struct {
+	// test type from Actions
+	Test string
+	// cron expression (minute hour day-of-month month day-of-week), empty - no scheduled runs
+	Cron string
+	// timezone of cron expression, UTC if empty
+	Timezone string
+	// run test after successful deploy pipeline in environment
+	AfterDeploy bool
+	// regexp of environment IDs (cluster:namespace), empty - all environments of autotest
+	Pattern string
+}
+
+func (s *AutotestSchedule) Validate(a *Autotest) error {
+	if a.GetActionByTest(s.Test) == nil {
+		return errors.New("unknown test " + s.Test)
+	}
+
+	if len(s.Cron) > 0 {
+		if _, err := utils.ParseCron(s.Cron); err != nil {
+			return errors.Wrap(err, "invalid Cron")
+		}
+	}
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return errors.Wrap(err, "invalid Timezone")
+	}
+
+	if _, err := regexp.Compile(s.Pattern); err != nil {
+		return errors.Wrap(err, "invalid Pattern")
+	}
+
+	return nil
+}
+
+// returns true if cron expression matches time.
+func (s *AutotestSchedule) IsScheduled(now time.Time) bool {
+	if len(s.Cron) == 0 {
+		return false
+	}
+
+	cron, err := utils.ParseCron(s.Cron)
+	if err != nil {
+		return false
+	}
+
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return false
+	}
+
+	return cron.Match(now.In(location))
+}
+
+// returns true if schedule is enabled for environment.
+func (s *AutotestSchedule) MatchEnvironment(environmentID string) bool {
+	if len(s.Pattern) == 0 {
+		return true
+	}
+
+	matched, err := regexp.MatchString(s.Pattern, environmentID)
+
+	return err == nil && matched
+}
+
 type Autotest struct {
 	Pattern           string
 	ProjectID         int
@@ -382,6 +772,9 @@ type Autotest struct {
 	Actions           []*AutotestAction
 	CustomAction      *AutotestCustomAction
 	FilterByNamespace bool
+	// paths of JUnit reports in job artifacts, for example build/junit.xml
+	JUnitArtifacts []string
+	Schedules      []*AutotestSchedule
 }
 
 func (a *Autotest) GetActionByTest(test string) *AutotestAction {
@@ -438,6 +831,59 @@ var config = Type{
 
 	ScaleDownDelay: NewScaleDownDelayOpts(),
 
+	GitlabWebhook: GitlabWebhook{
+		Token: os.Getenv("GITLAB_WEBHOOK_TOKEN"),
+	},
+
+	SCM: SCM{
+		Provider: "gitlab",
+		GitHub: GitHub{
+			URL:      "https://api.github.com",
+			Token:    os.Getenv("GITHUB_TOKEN"),
+			Workflow: "kubernetes-manager.yml",
+			RunNameVariables: []string{
+				"CLUSTER",
+				"NAMESPACE",
+				"OWNER",
+				"RELEASE",
+				"TEST",
+				"TEST_CLUSTER",
+				"TEST_NAMESPACE",
+			},
+		},
+	},
+
+	Terminal: Terminal{
+		Command:            []string{"/bin/sh", "-c", "command -v bash >/dev/null && exec bash || exec sh"},
+		IdleTimeoutMinutes: 15,  //nolint:mnd
+		MaxDurationMinutes: 120, //nolint:mnd
+	},
+
+	DebugContainer: DebugContainer{
+		Images:              []string{"nicolaka/netshoot:latest", "busybox:latest"},
+		StartTimeoutSeconds: 45, //nolint:mnd
+	},
+
+	DeployWaves: DeployWaves{
+		PollIntervalSeconds: 30,  //nolint:mnd
+		TimeoutMinutes:      120, //nolint:mnd
+	},
+
+	FollowBranch: FollowBranch{
+		MinCommitsBehind:   1,
+		MinIntervalMinutes: 30, //nolint:mnd
+	},
+
+	RemoteServer: RemoteServer{
+		StaledDays:     14, //nolint:mnd
+		IdleCPUPercent: 5,  //nolint:mnd
+	},
+
+	ResourceUsage: ResourceUsage{
+		OverRequestRatio: 0.2, //nolint:mnd
+		TopConsumers:     10,  //nolint:mnd
+	},
+
 	Cache: &Cache{
 		Type: "noop",
 	},
@@ -469,7 +915,18 @@ type Type struct {
 	PodNamespace               *string
 	WebHooks                   []WebHook
 	Snapshots                  Snapshot
+	GitlabWebhook              GitlabWebhook
+	SCM                        SCM
+	FollowBranch               FollowBranch
+	TagFork                    TagFork
+	DeployWaves                DeployWaves
+	AuthorizationRules         []*AuthorizationRule
+	Terminal                   Terminal
+	DebugContainer             DebugContainer
+	ContainerActions           []*ContainerAction
 	RemoteServer               RemoteServer
+	Cost                       Cost
+	ResourceUsage              ResourceUsage
 	Autotests                  []*Autotest
 	ScaleDownDelay             *ScaleDownDelayOpts
 	WikiPages                  []*WikiPage
@@ -550,6 +1007,10 @@ func loadDefaults() {
 		config.ProjectProfiles = []*ProjectProfile{&defaultProfile}
 	}
 
+	if len(config.ContainerActions) == 0 {
+		config.ContainerActions = defaultContainerActions()
+	}
+
 	for id := range config.ProjectProfiles {
 		if len(config.ProjectProfiles[id].NamespaceNameType) == 0 {
 			config.ProjectProfiles[id].NamespaceNameType = ProjectProfileNameTypeJiraIssue
@@ -611,6 +1072,80 @@ func CheckConfig() error {
 		}
 	}
 
+	for _, rule := range config.AuthorizationRules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return errors.Wrap(err, "error while validating authorization rule: "+rule.Permission)
+		}
+	}
+
+	for _, autotest := range config.Autotests {
+		for _, schedule := range autotest.Schedules {
+			if err := schedule.Validate(autotest); err != nil {
+				return errors.Wrap(err, "error while validating autotest schedule: "+autotest.Pattern)
+			}
+		}
+	}
+
+	remoteServerProviders := make(map[string]bool)
+
+	for _, provider := range config.RemoteServer.GetProviders() {
+		if len(provider.Name) == 0 {
+			return errors.New("remote server provider name is empty")
+		}
+
+		if remoteServerProviders[provider.Name] {
+			return errors.New("duplicate remote server provider: " + provider.Name)
+		}
+
+		remoteServerProviders[provider.Name] = true
+	}
+
+	remoteServerTemplates := make(map[string]bool)
+
+	for _, template := range config.RemoteServer.Templates {
+		if err := template.Validate(); err != nil {
+			return errors.Wrap(err, "error while validating remote server template: "+template.Name)
+		}
+
+		if remoteServerTemplates[template.Name] {
+			return errors.New("duplicate remote server template: " + template.Name)
+		}
+
+		remoteServerTemplates[template.Name] = true
+	}
+
+	if config.RemoteServer.IdleMinutes > 0 && config.RemoteServer.IdleCPUPercent <= 0 {
+		return errors.New("remote server IdleCPUPercent must be positive")
+	}
+
+	if err := config.DebugContainer.Validate(); err != nil {
+		return errors.Wrap(err, "error while validating debug container")
+	}
+
+	if err := config.Cost.Validate(); err != nil {
+		return errors.Wrap(err, "error while validating cost")
+	}
+
+	for _, webhook := range config.WebHooks {
+		if webhook.CostPerHour < 0 {
+			return errors.New("webhook CostPerHour must not be negative: " + webhook.Provider)
+		}
+	}
+
+	containerActions := make(map[string]bool)
+
+	for _, action := range config.ContainerActions {
+		if err := action.Validate(); err != nil {
+			return errors.Wrap(err, "error while validating container action: "+action.Name)
+		}
+
+		if containerActions[action.Name] {
+			return errors.New("duplicate container action: " + action.Name)
+		}
+
+		containerActions[action.Name] = true
+	}
+
 	if scaleDownDelay := config.GetScaleDownDelay(); scaleDownDelay == nil {
 		return errors.New("invalid scale down delay")
 	}
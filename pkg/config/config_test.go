@@ -16,6 +16,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
 )
@@ -98,3 +99,106 @@ func TestFormatedLinks(t *testing.T) {
 		t.Fatalf("want=%s,got=%s", want, formatedLinks.LogsURL)
 	}
 }
+
+func TestIsAuthorized(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Type{
+		AuthorizationRules: []*config.AuthorizationRule{
+			{Permission: config.PermissionTerminal, Users: []string{"user1"}},
+			{Permission: config.PermissionTerminal, Users: []string{config.AllUsers}, Pattern: "^dev:"},
+		},
+	}
+
+	type test struct {
+		user          string
+		environmentID string
+		want          bool
+	}
+
+	tests := []test{
+		{user: "user1", environmentID: "prod:test", want: true},
+		{user: "user2", environmentID: "prod:test", want: false},
+		{user: "user2", environmentID: "dev:test", want: true},
+		// request without user
+		{user: "", environmentID: "dev:test", want: false},
+	}
+
+	for _, test := range tests {
+		if got := cfg.IsAuthorized(config.PermissionTerminal, test.user, test.environmentID); got != test.want {
+			t.Fatalf("user=%s,environment=%s,want=%t,got=%t", test.user, test.environmentID, test.want, got)
+		}
+	}
+
+	if cfg.IsAuthorized("unknown", "user1", "prod:test") {
+		t.Fatal("permission without rules must be denied")
+	}
+}
+
+func TestAutotestSchedule(t *testing.T) {
+	t.Parallel()
+
+	autotest := config.Autotest{
+		Actions: []*config.AutotestAction{{Test: "smoke"}},
+	}
+
+	schedule := config.AutotestSchedule{
+		Test:     "smoke",
+		Cron:     "0 3 * * 1-5",
+		Timezone: "Europe/Berlin",
+		Pattern:  "^dev:",
+	}
+
+	if err := schedule.Validate(&autotest); err != nil {
+		t.Fatal(err)
+	}
+
+	// 03:00 in Berlin on monday
+	if now := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC); !schedule.IsScheduled(now) {
+		t.Fatalf("schedule must match %s", now)
+	}
+
+	if now := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC); schedule.IsScheduled(now) {
+		t.Fatalf("schedule must not match %s", now)
+	}
+
+	if !schedule.MatchEnvironment("dev:test") || schedule.MatchEnvironment("prod:test") {
+		t.Fatal("schedule must match only dev environments")
+	}
+
+	for _, invalid := range []config.AutotestSchedule{
+		{Test: "unknown"},
+		{Test: "smoke", Cron: "* * *"},
+		{Test: "smoke", Timezone: "Unknown/Zone"},
+	} {
+		if err := invalid.Validate(&autotest); err == nil {
+			t.Fatalf("schedule %+v must be invalid", invalid)
+		}
+	}
+}
+
+func TestRemoteServerProviders(t *testing.T) {
+	t.Parallel()
+
+	remoteServer := config.RemoteServer{
+		HetznerToken: "token",
+		Providers: []*config.RemoteServerProvider{
+			{Name: "aws-dev", Type: "aws"},
+		},
+	}
+
+	providers := remoteServer.GetProviders()
+
+	if len(providers) != 2 || providers[0].Name != config.RemoteServerProviderHcloud || providers[1].Name != "aws-dev" {
+		t.Fatalf("unexpected providers %+v", providers)
+	}
+
+	remoteServer.Providers = append(remoteServer.Providers, &config.RemoteServerProvider{
+		Name: config.RemoteServerProviderHcloud,
+		Type: "hcloud",
+	})
+
+	if providers := remoteServer.GetProviders(); len(providers) != 2 {
+		t.Fatalf("hcloud provider from config must be used, got %d providers", len(providers))
+	}
+}
@@ -0,0 +1,288 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"bytes"
+	b64 "encoding/base64"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type ContainerActionParamType string
+
+const (
+	ContainerActionParamString ContainerActionParamType = "string"
+	ContainerActionParamInt    ContainerActionParamType = "int"
+	ContainerActionParamBool   ContainerActionParamType = "bool"
+	// value is encoded to base64 before it is passed to command, used for multiline text.
+	ContainerActionParamBase64 ContainerActionParamType = "base64"
+)
+
+type ContainerActionOutput string
+
+const (
+	ContainerActionOutputText ContainerActionOutput = "text"
+	ContainerActionOutputJSON ContainerActionOutput = "json"
+)
+
+type ContainerActionParam struct {
+	Name        string
+	Type        ContainerActionParamType
+	Description string
+	Required    bool
+	// value that is used when param is not set
+	Default string
+	// regexp that value must match
+	Pattern string
+}
+
+// returns value of param that is safe to use in shell command.
+func (p *ContainerActionParam) GetValue(value string) (string, error) {
+	if len(value) == 0 {
+		value = p.Default
+	}
+
+	if len(value) == 0 && p.Required {
+		return "", errors.Errorf("param %s is required", p.Name)
+	}
+
+	if len(value) > 0 && len(p.Pattern) > 0 {
+		matched, err := regexp.MatchString(p.Pattern, value)
+		if err != nil {
+			return "", errors.Wrap(err, "invalid pattern of param "+p.Name)
+		}
+
+		if !matched {
+			return "", errors.Errorf("param %s must match %s", p.Name, p.Pattern)
+		}
+	}
+
+	switch p.Type {
+	case ContainerActionParamString, "":
+	case ContainerActionParamInt:
+		if len(value) > 0 {
+			if _, err := strconv.Atoi(value); err != nil {
+				return "", errors.Errorf("param %s must be integer", p.Name)
+			}
+		}
+	case ContainerActionParamBool:
+		if len(value) > 0 {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return "", errors.Errorf("param %s must be boolean", p.Name)
+			}
+
+			value = strconv.FormatBool(parsed)
+		}
+	case ContainerActionParamBase64:
+		value = b64.StdEncoding.EncodeToString([]byte(value))
+	default:
+		return "", errors.Errorf("unknown type %s of param %s", p.Type, p.Name)
+	}
+
+	return shellQuote(value), nil
+}
+
+// command in container, actions are shown only for containers that match image pattern and pod selector.
+type ContainerAction struct {
+	Name        string
+	Description string
+	// regexp of container image, empty - all images
+	ImagePattern string
+	// label selector of pod, empty - all pods
+	PodSelector string
+	// template of command, params are available as {{ .Params.<name> }}
+	Command string
+	Params  []*ContainerActionParam
+	// text or json
+	Output ContainerActionOutput
+	// permission in authorization rules, empty - all users
+	Permission string
+}
+
+func (a *ContainerAction) Validate() error {
+	if len(a.Name) == 0 {
+		return errors.New("name is empty")
+	}
+
+	if len(a.Command) == 0 {
+		return errors.New("command is empty")
+	}
+
+	if _, err := regexp.Compile(a.ImagePattern); err != nil {
+		return errors.Wrap(err, "invalid ImagePattern")
+	}
+
+	if _, err := labels.Parse(a.PodSelector); err != nil {
+		return errors.Wrap(err, "invalid PodSelector")
+	}
+
+	if _, err := template.New(a.Name).Parse(a.Command); err != nil {
+		return errors.Wrap(err, "invalid Command")
+	}
+
+	if a.Output != "" && a.Output != ContainerActionOutputText && a.Output != ContainerActionOutputJSON {
+		return errors.New("invalid Output: " + string(a.Output))
+	}
+
+	for _, param := range a.Params {
+		if _, err := regexp.Compile(param.Pattern); err != nil {
+			return errors.Wrap(err, "invalid Pattern of param "+param.Name)
+		}
+	}
+
+	return nil
+}
+
+// returns true if action can be executed in container with image and pod labels.
+func (a *ContainerAction) Match(image string, podLabels map[string]string) bool {
+	if len(a.ImagePattern) > 0 {
+		matched, err := regexp.MatchString(a.ImagePattern, image)
+		if err != nil || !matched {
+			return false
+		}
+	}
+
+	if len(a.PodSelector) > 0 {
+		selector, err := labels.Parse(a.PodSelector)
+		if err != nil || !selector.Matches(labels.Set(podLabels)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// returns command with quoted params, unknown params are not allowed.
+func (a *ContainerAction) GetCommand(params map[string]string) (string, error) {
+	templateParams := make(map[string]string)
+
+	for name := range params {
+		if a.getParam(name) == nil {
+			return "", errors.Errorf("unknown param %s", name)
+		}
+	}
+
+	for _, param := range a.Params {
+		value, err := param.GetValue(params[param.Name])
+		if err != nil {
+			return "", err
+		}
+
+		templateParams[param.Name] = value
+	}
+
+	tmpl, err := template.New(a.Name).Option("missingkey=error").Parse(a.Command)
+	if err != nil {
+		return "", errors.Wrap(err, "can not parse command")
+	}
+
+	var command bytes.Buffer
+
+	err = tmpl.Execute(&command, struct{ Params map[string]string }{Params: templateParams})
+	if err != nil {
+		return "", errors.Wrap(err, "can not execute command template")
+	}
+
+	return command.String(), nil
+}
+
+func (a *ContainerAction) getParam(name string) *ContainerActionParam {
+	for _, param := range a.Params {
+		if param.Name == name {
+			return param
+		}
+	}
+
+	return nil
+}
+
+func (t *Type) GetContainerAction(name string) *ContainerAction {
+	for _, action := range t.ContainerActions {
+		if action.Name == name {
+			return action
+		}
+	}
+
+	return nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// actions that use scripts in /kubernetes-manager of container.
+func defaultContainerActions() []*ContainerAction {
+	return []*ContainerAction{
+		{
+			Name:        "xdebug-info",
+			Description: "Show xdebug status, 0 - disabled",
+			Command:     "/kubernetes-manager/xdebugInfo",
+		},
+		{
+			Name:        "enable-xdebug",
+			Description: "Enable xdebug",
+			Command:     "/kubernetes-manager/enableXdebug",
+		},
+		{
+			Name:        "php-settings",
+			Description: "Show php-fpm settings",
+			Command:     "/kubernetes-manager/getPhpSettings",
+		},
+		{
+			Name:        "set-php-settings",
+			Description: "Save php-fpm settings",
+			Command:     "/kubernetes-manager/setPhpSettings {{ .Params.settings }}",
+			Params: []*ContainerActionParam{{
+				Name:     "settings",
+				Type:     ContainerActionParamBase64,
+				Required: true,
+			}},
+		},
+		{
+			Name:        "git-branch",
+			Description: "Show git branch",
+			Command:     "/kubernetes-manager/getGitBranch",
+		},
+		{
+			Name:        "git-public-key",
+			Description: "Show public key of git-sync",
+			Command:     "/kubernetes-manager/getGitPubKey",
+		},
+		{
+			Name:        "git-sync-init",
+			Description: "Enable git-sync",
+			Command:     "/kubernetes-manager/enableGit {{ .Params.origin }} {{ .Params.branch }}",
+			Params: []*ContainerActionParam{
+				{Name: "origin", Required: true},
+				{Name: "branch", Required: true},
+			},
+		},
+		{
+			Name:        "git-fetch",
+			Description: "Fetch changes of git-sync",
+			Command:     "/kubernetes-manager/gitFetch",
+		},
+		{
+			Name:        "clear-cache",
+			Description: "Clear cache",
+			Command:     "/kubernetes-manager/clearCache",
+		},
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config_test
+
+import (
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+)
+
+func TestContainerActionGetCommand(t *testing.T) {
+	t.Parallel()
+
+	action := config.ContainerAction{
+		Name:    "test",
+		Command: "/bin/test {{ .Params.name }} {{ .Params.count }} {{ .Params.enabled }} {{ .Params.text }}",
+		Params: []*config.ContainerActionParam{
+			{Name: "name", Required: true, Pattern: "^[a-z' ;]+$"},
+			{Name: "count", Type: config.ContainerActionParamInt, Default: "1"},
+			{Name: "enabled", Type: config.ContainerActionParamBool},
+			{Name: "text", Type: config.ContainerActionParamBase64},
+		},
+	}
+
+	if err := action.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	type test struct {
+		params  map[string]string
+		want    string
+		wantErr bool
+	}
+
+	tests := []test{
+		{
+			params: map[string]string{"name": "test", "enabled": "1", "text": "a\nb"},
+			want:   "/bin/test 'test' '1' 'true' 'YQpi'",
+		},
+		// shell injection
+		{
+			params: map[string]string{"name": "test'; rm"},
+			want:   `/bin/test 'test'\''; rm' '1' '' ''`,
+		},
+		{params: map[string]string{}, wantErr: true},
+		{params: map[string]string{"name": "Test"}, wantErr: true},
+		{params: map[string]string{"name": "test", "count": "one"}, wantErr: true},
+		{params: map[string]string{"name": "test", "enabled": "yes"}, wantErr: true},
+		{params: map[string]string{"name": "test", "unknown": "1"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := action.GetCommand(test.params)
+		if test.wantErr {
+			if err == nil {
+				t.Fatalf("params=%v must return error", test.params)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != test.want {
+			t.Fatalf("want=%s,got=%s", test.want, got)
+		}
+	}
+}
+
+func TestContainerActionMatch(t *testing.T) {
+	t.Parallel()
+
+	action := config.ContainerAction{
+		Name:         "test",
+		Command:      "/bin/test",
+		ImagePattern: "^php:",
+		PodSelector:  "app=backend,tier!=cache",
+	}
+
+	if err := action.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	type test struct {
+		image  string
+		labels map[string]string
+		want   bool
+	}
+
+	tests := []test{
+		{image: "php:8", labels: map[string]string{"app": "backend"}, want: true},
+		{image: "nginx:1", labels: map[string]string{"app": "backend"}, want: false},
+		{image: "php:8", labels: map[string]string{"app": "frontend"}, want: false},
+		{image: "php:8", labels: map[string]string{"app": "backend", "tier": "cache"}, want: false},
+	}
+
+	for _, test := range tests {
+		if got := action.Match(test.image, test.labels); got != test.want {
+			t.Fatalf("image=%s,labels=%v,want=%t,got=%t", test.image, test.labels, test.want, got)
+		}
+	}
+
+	invalid := config.ContainerAction{Name: "test", Command: "/bin/test", PodSelector: "app in"}
+
+	if err := invalid.Validate(); err == nil {
+		t.Fatal("invalid selector must return error")
+	}
+}
@@ -176,6 +176,36 @@ var (
 		Name:      "cache_remove_total",
 		Help:      "The total number of cache removes",
 	})
+
+	EnvironmentCost = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "environment_cost",
+		Help:      "Estimated cost of environment in current month",
+	}, []string{"cluster", "namespace", "creator", "profile"})
+
+	RemoteServerCost = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "remote_server_cost",
+		Help:      "Estimated cost of remote server in current month",
+	}, []string{"cloud", "server", "owner", "server_type"})
+
+	PodResourceUsage = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pod_resource_usage",
+		Help:      "Usage of pod from metrics-server, CPU in cores and memory in bytes",
+	}, []string{"cluster", "namespace", "pod", "workload", "resource"})
+
+	PodResourceRequests = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pod_resource_requests",
+		Help:      "Requests of pod, CPU in cores and memory in bytes",
+	}, []string{"cluster", "namespace", "pod", "workload", "resource"})
+
+	PodOverRequested = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pod_over_requested",
+		Help:      "1 if usage of pod is much less than requests",
+	}, []string{"cluster", "namespace", "pod", "workload"})
 )
 
 func LogRequest(operation string, startTime time.Time) {
@@ -15,6 +15,7 @@ package autotests
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"io"
 	"maps"
 	"net/http"
@@ -25,10 +26,12 @@ import (
 	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
@@ -43,6 +46,8 @@ type (
 		Pipelines        []*Pipeline
 		LastPipelines    []*Pipeline
 		HasMorePipelines bool
+		// tests that will be started when running pipelines are finished
+		Queue []*QueueItem
 	}
 	PipelineStatus string
 	Pipeline       struct {
@@ -61,6 +66,10 @@ type (
 		Test                 string
 		TestNamespace        string
 		PipelineEnv          map[string]string
+		// parsed JUnit reports of finished pipeline
+		TestResults *TestResults
+		// comparison with previous run of test in same namespace
+		TestResultsComparison *TestResultsComparison
 	}
 )
 
@@ -69,22 +78,24 @@ const (
 	envNameOwner     string = "OWNER"
 	envNameRelease   string = "RELEASE"
 	envNameNamespace string = "TEST_NAMESPACE"
+	envNameCluster   string = "TEST_CLUSTER"
 
 	pipelineStatusSuccess PipelineStatus = "success"
 	pipelineStatusRunning PipelineStatus = "running"
 	pipelineStatusPending PipelineStatus = "pending"
+	pipelineStatusFailed  PipelineStatus = "failed"
 
 	pipelineEnvSuffixFile string = "@FILE"
 )
 
 var (
-	errNotFound          = errors.New("for this environment autotests is not configured")
-	errPipelineIsRunning = errors.New("you must stop the current pipeline before starting a new one")
+	errNotFound      = errors.New("for this environment autotests is not configured")
+	errNoSCMProvider = errors.New("no source control provider")
 )
 
 const (
-	gitlabListPerPage             = 100
-	defaultGetAutotestDetailsSize = 10
+	pipelinesListLimit = 100
+	shortSHALength     = 8
 )
 
 func (d *Details) Normalize(a *config.Autotest) error {
@@ -95,6 +106,14 @@ func (d *Details) Normalize(a *config.Autotest) error {
 	return nil
 }
 
+func shortSHA(sha string) string {
+	if len(sha) > shortSHALength {
+		return sha[:shortSHALength]
+	}
+
+	return sha
+}
+
 func GetAutotestDetails(ctx context.Context, environment *api.Environment, size int) (*Details, error) {
 	ctx, span := telemetry.Start(ctx, "autotests.GetAutotestDetails")
 	defer span.End()
@@ -110,6 +129,7 @@ func GetAutotestDetails(ctx context.Context, environment *api.Environment, size
 		Actions:       autotestConfig.Actions,
 		Pipelines:     []*Pipeline{},
 		LastPipelines: []*Pipeline{},
+		Queue:         getQueue(environment),
 	}
 
 	// add defaults values if not set
@@ -117,17 +137,15 @@ func GetAutotestDetails(ctx context.Context, environment *api.Environment, size
 		return nil, errors.Wrap(err, "error normalizing")
 	}
 
-	gitlabClient := client.GetGitlabClient()
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return nil, errNoSCMProvider
+	}
 
-	pipelines, _, err := gitlabClient.Pipelines.ListProjectPipelines(
-		autotestConfig.ProjectID,
-		&gitlab.ListProjectPipelinesOptions{
-			ListOptions: gitlab.ListOptions{
-				PerPage: gitlabListPerPage,
-			},
-		},
-		gitlab.WithContext(ctx),
-	)
+	pipelines, err := scmProvider.ListPipelines(ctx, &scm.ListPipelinesInput{
+		ProjectID: strconv.Itoa(autotestConfig.ProjectID),
+		Limit:     pipelinesListLimit,
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting pipelines")
 	}
@@ -149,21 +167,25 @@ func GetAutotestDetails(ctx context.Context, environment *api.Environment, size
 			break
 		}
 
-		pipelineDuration := pipeline.UpdatedAt.Sub(*pipeline.CreatedAt)
-
 		item := &Pipeline{
-			PipelineID:           strconv.FormatInt(pipeline.ID, 10),
-			CommitShortSHA:       pipeline.SHA[:8],
-			Status:               PipelineStatus(pipeline.Status),
-			PipelineURL:          pipeline.WebURL,
-			PipelineRef:          pipeline.Ref,
-			PipelineCreated:      utils.TimeToString(*pipeline.CreatedAt),
-			PipelineCreatedHuman: utils.HumanizeDuration(utils.HumanizeDurationShort, time.Since(*pipeline.CreatedAt)),
-			PipelineEnv:          make(map[string]string),
-			PipelineDuration:     utils.HumanizeDuration(utils.HumanizeDurationShort, pipelineDuration),
+			PipelineID:     pipeline.ID,
+			CommitShortSHA: shortSHA(pipeline.SHA),
+			Status:         PipelineStatus(pipeline.Status),
+			PipelineURL:    pipeline.WebURL,
+			PipelineRef:    pipeline.Ref,
+			PipelineEnv:    make(map[string]string),
 		}
 
-		pipelineVariables, err := api.GetCachedGitlabPipelineVariables(ctx,
+		if pipeline.Created != nil {
+			item.PipelineCreated = utils.TimeToString(*pipeline.Created)
+			item.PipelineCreatedHuman = utils.HumanizeDuration(utils.HumanizeDurationShort, time.Since(*pipeline.Created))
+
+			if pipeline.Updated != nil {
+				item.PipelineDuration = utils.HumanizeDuration(utils.HumanizeDurationShort, pipeline.Updated.Sub(*pipeline.Created))
+			}
+		}
+
+		pipelineVariables, err := api.GetCachedPipelineVariables(ctx,
 			strconv.Itoa(autotestConfig.ProjectID),
 			pipeline.ID,
 		)
@@ -171,20 +193,12 @@ func GetAutotestDetails(ctx context.Context, environment *api.Environment, size
 			return nil, errors.Wrap(err, "error getting pipeline variables")
 		}
 
-		for _, pipelineVariable := range pipelineVariables {
-			item.PipelineEnv[pipelineVariable.Key] = pipelineVariable.Value
-
-			switch pipelineVariable.Key {
-			case envNameTest:
-				item.Test = pipelineVariable.Value
-			case envNameOwner:
-				item.PipelineOwner = pipelineVariable.Value
-			case envNameRelease:
-				item.PipelineRelease = pipelineVariable.Value
-			case envNameNamespace:
-				item.TestNamespace = pipelineVariable.Value
-			}
-		}
+		maps.Copy(item.PipelineEnv, pipelineVariables)
+
+		item.Test = pipelineVariables[envNameTest]
+		item.PipelineOwner = pipelineVariables[envNameOwner]
+		item.PipelineRelease = pipelineVariables[envNameRelease]
+		item.TestNamespace = pipelineVariables[envNameNamespace]
 
 		// ignore pipelines with another namespace
 		if autotestConfig.FilterByNamespace && item.TestNamespace != environment.Namespace {
@@ -213,6 +227,10 @@ func GetAutotestDetails(ctx context.Context, environment *api.Environment, size
 		result.Pipelines = append(result.Pipelines, item)
 	}
 
+	if len(autotestConfig.JUnitArtifacts) > 0 {
+		addTestResults(ctx, strconv.Itoa(autotestConfig.ProjectID), autotestConfig.JUnitArtifacts, result.Pipelines)
+	}
+
 	result.LastPipelines = make([]*Pipeline, 0)
 
 	// search last pipelines for action types
@@ -234,6 +252,45 @@ func GetAutotestDetails(ctx context.Context, environment *api.Environment, size
 	return &result, nil
 }
 
+// add test results to finished pipelines, pipelines are sorted from newest to oldest.
+func addTestResults(ctx context.Context, projectID string, artifacts []string, pipelines []*Pipeline) {
+	ctx, span := telemetry.Start(ctx, "autotests.addTestResults")
+	defer span.End()
+
+	for _, pipeline := range pipelines {
+		if !scm.PipelineStatus(pipeline.Status).IsFinished() {
+			continue
+		}
+
+		testResults, err := getPipelineTestResults(ctx, projectID, pipeline.PipelineID, artifacts)
+		if err != nil {
+			log.WithError(err).Warnf("can not get test results of pipeline %s", pipeline.PipelineID)
+
+			continue
+		}
+
+		if testResults.Total > 0 {
+			pipeline.TestResults = testResults
+		}
+	}
+
+	for i, pipeline := range pipelines {
+		if pipeline.TestResults == nil {
+			continue
+		}
+
+		for _, previous := range pipelines[i+1:] {
+			if previous.TestResults == nil || previous.Test != pipeline.Test || previous.TestNamespace != pipeline.TestNamespace {
+				continue
+			}
+
+			pipeline.TestResultsComparison = CompareTestResults(previous.PipelineID, pipeline.TestResults, previous.TestResults)
+
+			break
+		}
+	}
+}
+
 type StartAutotestInput struct {
 	environment *api.Environment
 	Ref         string
@@ -274,53 +331,88 @@ func (s *StartAutotestInput) SetEnvironment(environment *api.Environment) {
 	s.environment = environment
 }
 
-func StartAutotest(ctx context.Context, input *StartAutotestInput) error {
+type StartAutotestResult struct {
+	// pipeline was created
+	Started bool
+	// pipeline of same test is running, test was added to queue
+	QueueItem *QueueItem
+}
+
+func StartAutotest(ctx context.Context, input *StartAutotestInput) (*StartAutotestResult, error) {
 	ctx, span := telemetry.Start(ctx, "api.StartAutotest")
 	defer span.End()
 
 	if err := input.Validate(ctx); err != nil {
-		return errors.Wrap(err, "error validating input")
+		return nil, errors.Wrap(err, "error validating input")
 	}
 
 	autotestConfig := config.Get().GetAutotestByID(input.environment.ID)
 
 	if autotestConfig == nil {
-		return errNotFound
+		return nil, errNotFound
 	}
 
 	action := autotestConfig.GetActionByTest(input.Test)
 
 	if action == nil {
-		return errNotFound
+		return nil, errNotFound
 	}
 
 	if len(input.Ref) == 0 {
 		input.Ref = action.Ref
 	}
 
-	// check for pending pipelines
+	// check for pending pipelines, test is queued if it already running or waiting in queue
 	if !input.Force {
-		details, err := GetAutotestDetails(ctx, input.environment, defaultGetAutotestDetailsSize)
+		runningTests, err := getRunningTests(ctx, input.environment)
 		if err != nil {
-			return errors.Wrap(err, "error getting environment details")
+			return nil, err
 		}
 
-		for _, pipeline := range details.Pipelines {
-			if pipeline.Test == input.Test &&
-				(pipeline.Status == pipelineStatusRunning || pipeline.Status == pipelineStatusPending) {
-				return errPipelineIsRunning
+		if runningTests[input.Test] || isTestQueued(input.environment, input.Test) {
+			queueItem, err := enqueueAutotest(ctx, input)
+			if err != nil {
+				return nil, errors.Wrap(err, "error adding autotest to queue")
 			}
+
+			return &StartAutotestResult{QueueItem: queueItem}, nil
 		}
 	}
 
+	if err := triggerAutotest(ctx, input.environment, autotestConfig, action, input.Ref, input.ExtraEnv); err != nil {
+		return nil, err
+	}
+
+	return &StartAutotestResult{Started: true}, nil
+}
+
+// create autotest pipeline, owner of pipeline is user from context.
+func triggerAutotest(
+	ctx context.Context,
+	environment *api.Environment,
+	autotestConfig *config.Autotest,
+	action *config.AutotestAction,
+	ref string,
+	extraEnv map[string]string,
+) error {
+	ctx, span := telemetry.Start(ctx, "autotests.triggerAutotest")
+	defer span.End()
+
+	owner := ""
+
+	if security, ok := ctx.Value(types.ContextSecurityKey).(types.ContextSecurity); ok {
+		owner = security.Owner
+	}
+
 	pipelineEnv := map[string]string{
-		envNameTest:      input.Test,
-		envNameOwner:     input.GetUser(ctx),
-		envNameNamespace: input.environment.Namespace,
+		envNameTest:      action.Test,
+		envNameOwner:     owner,
+		envNameNamespace: environment.Namespace,
+		envNameCluster:   environment.Cluster,
 	}
 
 	if len(action.Release) > 0 {
-		releaseURL, err := utils.GetTemplatedResult(ctx, action.Release, input.environment)
+		releaseURL, err := utils.GetTemplatedResult(ctx, action.Release, environment)
 		if err != nil {
 			return errors.Wrap(err, "error getting release url")
 		}
@@ -334,21 +426,23 @@ func StartAutotest(ctx context.Context, input *StartAutotestInput) error {
 	}
 
 	// add extra env
-	maps.Copy(pipelineEnv, input.ExtraEnv)
+	maps.Copy(pipelineEnv, extraEnv)
 
-	gitlabClient := client.GetGitlabClient()
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return errNoSCMProvider
+	}
 
-	variables := make([]*gitlab.PipelineVariableOptions, 0)
+	variables := make([]*scm.Variable, 0)
 
 	for key, value := range pipelineEnv {
 		if len(value) == 0 {
 			return errors.Errorf("env %s is empty", key)
 		}
 
-		variable := &gitlab.PipelineVariableOptions{
-			Key:          gitlab.Ptr(key),
-			Value:        gitlab.Ptr(value),
-			VariableType: gitlab.Ptr(gitlab.EnvVariableType),
+		variable := &scm.Variable{
+			Key:   key,
+			Value: value,
 		}
 
 		// for scenarios with @FILE in the end of key
@@ -358,25 +452,31 @@ func StartAutotest(ctx context.Context, input *StartAutotestInput) error {
 		// will be converted to TEST
 		// and variable type will be set to file
 		if before, ok := strings.CutSuffix(key, pipelineEnvSuffixFile); ok {
-			variable.Key = gitlab.Ptr(before)
-			variable.VariableType = gitlab.Ptr(gitlab.FileVariableType)
+			variable.Key = before
+			variable.File = true
 		}
 
 		variables = append(variables, variable)
 	}
 
-	_, _, err := gitlabClient.Pipelines.CreatePipeline(
-		autotestConfig.ProjectID,
-		&gitlab.CreatePipelineOptions{
-			Ref:       &input.Ref,
-			Variables: &variables,
-		},
-		gitlab.WithContext(ctx),
-	)
+	pipeline, err := scmProvider.TriggerPipeline(ctx, &scm.TriggerPipelineInput{
+		ProjectID: strconv.Itoa(autotestConfig.ProjectID),
+		Ref:       ref,
+		Variables: variables,
+	})
 	if err != nil {
 		return errors.Wrap(err, "can not create pipeline")
 	}
 
+	eventMessage := environment.NewWebhookMessage(types.EventAutotestStarted)
+	eventMessage.Reason = "Autotest started ..."
+	eventMessage.Properties["slackEmoji"] = ":test_tube:"
+	eventMessage.Properties["test"] = action.Test
+	eventMessage.Properties["ref"] = ref
+	eventMessage.Properties["pipeline"] = pipeline.WebURL
+
+	environment.SendWebhookEvent(ctx, eventMessage)
+
 	return nil
 }
 
@@ -448,49 +548,113 @@ func StopAutotest(ctx context.Context, input *StopAutotestInput) error {
 		return errNotFound
 	}
 
-	gitlabClient := client.GetGitlabClient()
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return errNoSCMProvider
+	}
 
-	pipelineID, err := strconv.ParseInt(input.PipelineID, 10, 64)
-	if err != nil {
-		return errors.Wrap(err, "error converting pipeline id to int")
+	projectID := strconv.Itoa(autotestConfig.ProjectID)
+
+	if err := scmProvider.CancelPipeline(ctx, projectID, input.PipelineID); err != nil {
+		return errors.Wrap(err, "error cancelling pipeline")
 	}
 
-	_, _, err = gitlabClient.Pipelines.CancelPipelineBuild(
-		autotestConfig.ProjectID,
-		pipelineID,
-		gitlab.WithContext(ctx),
-	)
+	pipeline, err := scmProvider.GetPipeline(ctx, projectID, input.PipelineID)
 	if err != nil {
-		return errors.Wrap(err, "error cancelling pipeline")
+		return errors.Wrap(err, "error getting pipeline")
 	}
 
-	jobs, _, err := gitlabClient.Jobs.ListPipelineJobs(
-		autotestConfig.ProjectID,
-		pipelineID,
-		&gitlab.ListJobsOptions{
-			ListOptions: gitlab.ListOptions{
-				PerPage: gitlabListPerPage,
-			},
-		},
-		gitlab.WithContext(ctx),
-	)
+	jobs, err := scmProvider.ListJobs(ctx, projectID, input.PipelineID)
 	if err != nil {
 		return errors.Wrap(err, "error getting pipeline jobs")
 	}
 
 	for _, job := range jobs {
 		if job.Name == config.GetEnvDefault("AUTOTEST_POST_STOP_JOB_NAME", "scaledown_hard") {
-			_, _, err = gitlabClient.Jobs.PlayJob(
-				autotestConfig.ProjectID,
-				job.ID,
-				&gitlab.PlayJobOptions{},
-				gitlab.WithContext(ctx),
-			)
-			if err != nil {
+			if err := scmProvider.PlayJob(ctx, projectID, job.ID); err != nil {
 				return errors.Wrap(err, "error playing pipeline job")
 			}
 		}
 	}
 
+	eventMessage := input.environment.NewWebhookMessage(types.EventAutotestStopped)
+	eventMessage.Reason = "Autotest stopped ..."
+	eventMessage.Properties["slackEmoji"] = ":octagonal_sign:"
+	eventMessage.Properties["ref"] = pipeline.Ref
+	eventMessage.Properties["pipeline"] = pipeline.WebURL
+
+	input.environment.SendWebhookEvent(ctx, eventMessage)
+
+	return nil
+}
+
+// send event when autotest pipeline is finished.
+func ProcessGitlabPipelineEvent(ctx context.Context, event *gitlab.PipelineEvent) error {
+	ctx, span := telemetry.Start(ctx, "autotests.ProcessGitlabPipelineEvent")
+	defer span.End()
+
+	variables := api.GetGitlabPipelineEventVariables(event)
+
+	namespace := variables[envNameNamespace]
+	cluster := variables[envNameCluster]
+
+	// pipeline was not created by kubernetes-manager
+	if len(namespace) == 0 || len(cluster) == 0 {
+		return nil
+	}
+
+	if !api.IsGitlabPipelineFinished(event.ObjectAttributes.Status) {
+		return nil
+	}
+
+	environment, err := api.GetEnvironmentByID(ctx, fmt.Sprintf("%s:%s", cluster, namespace))
+	if err != nil {
+		log.WithError(err).Warnf("environment %s:%s not found", cluster, namespace)
+
+		return nil
+	}
+
+	autotestConfig := config.Get().GetAutotestByID(environment.ID)
+	if autotestConfig == nil || int64(autotestConfig.ProjectID) != event.Project.ID {
+		return nil
+	}
+
+	eventMessage := environment.NewWebhookMessage(types.EventAutotestFinished)
+	eventMessage.Reason = fmt.Sprintf("Autotest %s ...", event.ObjectAttributes.Status)
+	eventMessage.Properties["slackEmoji"] = ":test_tube:"
+	eventMessage.Properties["user"] = variables[envNameOwner]
+	eventMessage.Properties["test"] = variables[envNameTest]
+	eventMessage.Properties["ref"] = event.ObjectAttributes.Ref
+	eventMessage.Properties["status"] = event.ObjectAttributes.Status
+	eventMessage.Properties["pipeline"] = event.ObjectAttributes.URL
+
+	if trigger := variables[envNameTrigger]; len(trigger) > 0 {
+		eventMessage.Properties["trigger"] = trigger
+	}
+
+	if len(autotestConfig.JUnitArtifacts) > 0 {
+		projectID := strconv.Itoa(autotestConfig.ProjectID)
+		pipelineID := strconv.FormatInt(event.ObjectAttributes.ID, 10)
+
+		testResults, err := getPipelineTestResults(ctx, projectID, pipelineID, autotestConfig.JUnitArtifacts)
+		if err != nil {
+			log.WithError(err).Warnf("can not get test results of pipeline %s", pipelineID)
+		} else if testResults.Total > 0 {
+			eventMessage.Properties["tests"] = fmt.Sprintf("%d total, %d failed, %d flaky, %d skipped",
+				testResults.Total,
+				testResults.Failed,
+				testResults.Flaky,
+				testResults.Skipped,
+			)
+		}
+	}
+
+	environment.SendWebhookEvent(ctx, eventMessage)
+
+	// start next queued tests
+	if err := ProcessQueue(ctx, environment); err != nil {
+		return errors.Wrap(err, "error processing autotest queue")
+	}
+
 	return nil
 }
@@ -0,0 +1,361 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package autotests
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/cache"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/metrics"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	slowestTestsCount   = 10
+	maxTestMessageBytes = 1024
+)
+
+type TestCaseStatus string
+
+const (
+	TestCasePassed  TestCaseStatus = "passed"
+	TestCaseFailed  TestCaseStatus = "failed"
+	TestCaseSkipped TestCaseStatus = "skipped"
+	// test passed after retries.
+	TestCaseFlaky TestCaseStatus = "flaky"
+)
+
+type TestCase struct {
+	Suite    string
+	Name     string
+	Status   TestCaseStatus
+	Duration time.Duration
+	Message  string
+}
+
+// unique name of test in results.
+func (t *TestCase) ID() string {
+	return t.Suite + "/" + t.Name
+}
+
+type TestResults struct {
+	Total   int
+	Passed  int
+	Failed  int
+	Skipped int
+	// flaky tests are counted in passed tests
+	Flaky    int
+	Duration time.Duration
+	// human readable duration
+	DurationHuman string
+	Failures      []*TestCase
+	FlakyTests    []*TestCase
+	SlowestTests  []*TestCase
+}
+
+type TestResultsComparison struct {
+	PreviousPipelineID string
+	// tests that failed in this run and passed in previous run
+	NewFailures []string
+	// tests that passed in this run and failed in previous run
+	FixedTests    []string
+	TotalDelta    int
+	FailedDelta   int
+	DurationDelta time.Duration
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string          `xml:"name,attr"`
+	ClassName string          `xml:"classname,attr"`
+	Time      string          `xml:"time,attr"`
+	Failures  []*junitFailure `xml:"failure"`
+	Errors    []*junitFailure `xml:"error"`
+	Skipped   *junitFailure   `xml:"skipped"`
+	// failed attempts of test that passed after rerun (surefire format)
+	FlakyFailures []*junitFailure `xml:"flakyFailure"`
+	FlakyErrors   []*junitFailure `xml:"flakyError"`
+}
+
+type junitTestSuite struct {
+	Name       string            `xml:"name,attr"`
+	TestCases  []*junitTestCase  `xml:"testcase"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+}
+
+// parse JUnit XML report, root element can be testsuites or testsuite.
+func ParseJUnit(data []byte) ([]*TestCase, error) {
+	root := junitTestSuite{}
+
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		return nil, errors.Wrap(err, "can not parse junit report")
+	}
+
+	return root.testCases(root.Name), nil
+}
+
+func (s *junitTestSuite) testCases(suite string) []*TestCase {
+	result := make([]*TestCase, 0, len(s.TestCases))
+
+	for _, testCase := range s.TestCases {
+		item := TestCase{
+			Suite:    suite,
+			Name:     testCase.Name,
+			Status:   TestCasePassed,
+			Duration: parseJUnitTime(testCase.Time),
+		}
+
+		if len(testCase.ClassName) > 0 {
+			item.Suite = testCase.ClassName
+		}
+
+		switch {
+		case len(testCase.Failures) > 0 || len(testCase.Errors) > 0:
+			item.Status = TestCaseFailed
+			item.Message = junitMessage(append(testCase.Failures, testCase.Errors...))
+		case testCase.Skipped != nil:
+			item.Status = TestCaseSkipped
+			item.Message = junitMessage([]*junitFailure{testCase.Skipped})
+		case len(testCase.FlakyFailures) > 0 || len(testCase.FlakyErrors) > 0:
+			item.Status = TestCaseFlaky
+			item.Message = junitMessage(append(testCase.FlakyFailures, testCase.FlakyErrors...))
+		}
+
+		result = append(result, &item)
+	}
+
+	for _, testSuite := range s.TestSuites {
+		result = append(result, testSuite.testCases(testSuite.Name)...)
+	}
+
+	return result
+}
+
+func junitMessage(failures []*junitFailure) string {
+	for _, failure := range failures {
+		message := strings.TrimSpace(failure.Message)
+		if len(message) == 0 {
+			message = strings.TrimSpace(failure.Text)
+		}
+
+		if len(message) > maxTestMessageBytes {
+			message = message[:maxTestMessageBytes]
+		}
+
+		if len(message) > 0 {
+			return message
+		}
+	}
+
+	return ""
+}
+
+// time is in seconds, some reporters use thousands separator.
+func parseJUnitTime(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// summary of test cases, test that has several results (reruns) with failed
+// and passed result is flaky.
+func NewTestResults(testCases []*TestCase) *TestResults {
+	tests := make(map[string]*TestCase)
+	order := make([]string, 0)
+
+	for _, testCase := range testCases {
+		id := testCase.ID()
+
+		previous, ok := tests[id]
+		if !ok {
+			tests[id] = testCase
+			order = append(order, id)
+
+			continue
+		}
+
+		merged := *previous
+		merged.Duration += testCase.Duration
+
+		if previous.Status != testCase.Status &&
+			(previous.Status == TestCaseFailed || testCase.Status == TestCaseFailed) &&
+			(previous.Status != TestCaseSkipped && testCase.Status != TestCaseSkipped) {
+			merged.Status = TestCaseFlaky
+
+			if len(merged.Message) == 0 {
+				merged.Message = testCase.Message
+			}
+		}
+
+		tests[id] = &merged
+	}
+
+	result := TestResults{
+		Failures:     make([]*TestCase, 0),
+		FlakyTests:   make([]*TestCase, 0),
+		SlowestTests: make([]*TestCase, 0),
+	}
+
+	for _, id := range order {
+		testCase := tests[id]
+
+		result.Total++
+		result.Duration += testCase.Duration
+
+		switch testCase.Status {
+		case TestCasePassed:
+			result.Passed++
+		case TestCaseFailed:
+			result.Failed++
+			result.Failures = append(result.Failures, testCase)
+		case TestCaseSkipped:
+			result.Skipped++
+		case TestCaseFlaky:
+			result.Passed++
+			result.Flaky++
+			result.FlakyTests = append(result.FlakyTests, testCase)
+		}
+
+		if testCase.Status != TestCaseSkipped {
+			result.SlowestTests = append(result.SlowestTests, testCase)
+		}
+	}
+
+	slices.SortStableFunc(result.SlowestTests, func(a, b *TestCase) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+
+	if len(result.SlowestTests) > slowestTestsCount {
+		result.SlowestTests = result.SlowestTests[:slowestTestsCount]
+	}
+
+	result.DurationHuman = result.Duration.Round(time.Second).String()
+
+	return &result
+}
+
+// compare results with results of previous run.
+func CompareTestResults(previousPipelineID string, current, previous *TestResults) *TestResultsComparison {
+	result := TestResultsComparison{
+		PreviousPipelineID: previousPipelineID,
+		NewFailures:        make([]string, 0),
+		FixedTests:         make([]string, 0),
+		TotalDelta:         current.Total - previous.Total,
+		FailedDelta:        current.Failed - previous.Failed,
+		DurationDelta:      current.Duration - previous.Duration,
+	}
+
+	previousFailures := make(map[string]bool)
+
+	for _, testCase := range previous.Failures {
+		previousFailures[testCase.ID()] = true
+	}
+
+	currentFailures := make(map[string]bool)
+
+	for _, testCase := range current.Failures {
+		currentFailures[testCase.ID()] = true
+
+		if !previousFailures[testCase.ID()] {
+			result.NewFailures = append(result.NewFailures, testCase.ID())
+		}
+	}
+
+	for _, testCase := range previous.Failures {
+		if !currentFailures[testCase.ID()] {
+			result.FixedTests = append(result.FixedTests, testCase.ID())
+		}
+	}
+
+	return &result
+}
+
+// returns results of JUnit reports in artifacts of finished pipeline jobs,
+// results are cached because artifacts of finished pipeline are not changed.
+func getPipelineTestResults(ctx context.Context, projectID, pipelineID string, artifacts []string) (*TestResults, error) {
+	ctx, span := telemetry.Start(ctx, "autotests.getPipelineTestResults")
+	defer span.End()
+
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return nil, errNoSCMProvider
+	}
+
+	cacheKey := fmt.Sprintf("autotests::project::%s::pipeline::%s::results", projectID, pipelineID)
+
+	var cacheValue TestResults
+
+	if err := cache.Client().Get(ctx, cacheKey, &cacheValue); err == nil {
+		metrics.CacheHits.WithLabelValues("getPipelineTestResults").Inc()
+
+		return &cacheValue, nil
+	}
+
+	jobs, err := scmProvider.ListJobs(ctx, projectID, pipelineID)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting pipeline jobs")
+	}
+
+	testCases := make([]*TestCase, 0)
+
+	for _, job := range jobs {
+		if job.Finished == nil {
+			continue
+		}
+
+		for _, artifact := range artifacts {
+			report, err := scmProvider.GetJobArtifact(ctx, projectID, job.ID, artifact)
+			if errors.Is(err, scm.ErrNotFound) {
+				continue
+			}
+
+			if err != nil {
+				return nil, errors.Wrap(err, "error getting job artifact")
+			}
+
+			reportTestCases, err := ParseJUnit(report)
+			if err != nil {
+				log.WithError(err).Warnf("can not parse %s in job %s", artifact, job.ID)
+
+				continue
+			}
+
+			testCases = append(testCases, reportTestCases...)
+		}
+	}
+
+	results := NewTestResults(testCases)
+
+	_ = cache.Client().Set(ctx, cacheKey, results, cache.HighTTL)
+
+	return results, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package autotests_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/modules/autotests"
+)
+
+const testReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="api">
+    <testcase name="login" classname="api.Auth" time="1.5"/>
+    <testcase name="logout" classname="api.Auth" time="0.5">
+      <failure message="expected 200">stack</failure>
+    </testcase>
+    <testcase name="profile" classname="api.User" time="1,000.25">
+      <flakyFailure message="timeout"/>
+    </testcase>
+    <testcase name="search" classname="api.User">
+      <skipped/>
+    </testcase>
+  </testsuite>
+  <testsuite name="ui">
+    <testcase name="cart" time="2"><error>connection refused</error></testcase>
+    <testcase name="cart" time="3"/>
+  </testsuite>
+</testsuites>`
+
+func TestParseJUnit(t *testing.T) {
+	t.Parallel()
+
+	testCases, err := autotests.ParseJUnit([]byte(testReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := autotests.NewTestResults(testCases)
+
+	if results.Total != 5 || results.Passed != 3 || results.Failed != 1 || results.Skipped != 1 || results.Flaky != 2 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	if want := 1007250 * time.Millisecond; results.Duration != want {
+		t.Fatalf("want=%s,got=%s", want, results.Duration)
+	}
+
+	if results.Failures[0].ID() != "api.Auth/logout" || results.Failures[0].Message != "expected 200" {
+		t.Fatalf("unexpected failure %+v", results.Failures[0])
+	}
+
+	// rerun of failed test
+	if results.FlakyTests[1].ID() != "ui/cart" || results.FlakyTests[1].Message != "connection refused" {
+		t.Fatalf("unexpected flaky test %+v", results.FlakyTests[1])
+	}
+
+	if results.SlowestTests[0].ID() != "api.User/profile" {
+		t.Fatalf("unexpected slowest test %+v", results.SlowestTests[0])
+	}
+
+	// report with testsuite root
+	testCases, err = autotests.ParseJUnit([]byte(`<testsuite name="single"><testcase name="test"/></testsuite>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testCases) != 1 || testCases[0].ID() != "single/test" {
+		t.Fatalf("unexpected test cases %+v", testCases)
+	}
+
+	if _, err := autotests.ParseJUnit([]byte("not xml")); err == nil {
+		t.Fatal("invalid report must return error")
+	}
+}
+
+func TestCompareTestResults(t *testing.T) {
+	t.Parallel()
+
+	previous := autotests.NewTestResults([]*autotests.TestCase{
+		{Suite: "s", Name: "a", Status: autotests.TestCaseFailed, Duration: time.Second},
+		{Suite: "s", Name: "b", Status: autotests.TestCasePassed, Duration: time.Second},
+	})
+
+	current := autotests.NewTestResults([]*autotests.TestCase{
+		{Suite: "s", Name: "a", Status: autotests.TestCasePassed, Duration: time.Second},
+		{Suite: "s", Name: "b", Status: autotests.TestCaseFailed, Duration: 2 * time.Second},
+		{Suite: "s", Name: "c", Status: autotests.TestCaseFailed, Duration: time.Second},
+	})
+
+	comparison := autotests.CompareTestResults("1", current, previous)
+
+	if !slices.Equal(comparison.NewFailures, []string{"s/b", "s/c"}) {
+		t.Fatalf("unexpected new failures %v", comparison.NewFailures)
+	}
+
+	if !slices.Equal(comparison.FixedTests, []string{"s/a"}) {
+		t.Fatalf("unexpected fixed tests %v", comparison.FixedTests)
+	}
+
+	if comparison.TotalDelta != 1 || comparison.FailedDelta != 1 || comparison.DurationDelta != 2*time.Second {
+		t.Fatalf("unexpected comparison %+v", comparison)
+	}
+}
@@ -0,0 +1,414 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package autotests
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/cache"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/metrics"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultOverviewDays  = 14
+	maxOverviewDays      = 90
+	topFailingTestsCount = 10
+	trendDateFormat      = "2006-01-02"
+)
+
+type OverviewInput struct {
+	// period of overview in days
+	Days int
+}
+
+func (o *OverviewInput) Validate() error {
+	if o.Days == 0 {
+		o.Days = defaultOverviewDays
+	}
+
+	if o.Days < 0 || o.Days > maxOverviewDays {
+		return errors.Errorf("days must be between 1 and %d", maxOverviewDays)
+	}
+
+	return nil
+}
+
+type Overview struct {
+	// start of period that is covered by loaded pipelines
+	Since string
+	// only last pipelines are loaded, Since is later than requested period
+	Truncated bool
+	Tests     []*TestOverview
+}
+
+// statistics of finished pipelines of one test type in all environments.
+type TestOverview struct {
+	ProjectID    int
+	Test         string
+	Environments int
+	Pipelines    int
+	Passed       int
+	Failed       int
+	// percent of passed pipelines
+	PassRate          float64
+	MeanDuration      time.Duration
+	MeanDurationHuman string
+	// tests from JUnit reports that failed most frequently
+	TopFailingTests []*FailingTest
+	// statistics per day, ordered by date
+	Trend []*TrendPoint
+}
+
+type FailingTest struct {
+	ID       string
+	Failures int
+	// runs where test passed after rerun
+	Flaky       int
+	LastFailed  string
+	LastMessage string
+}
+
+type TrendPoint struct {
+	Date      string
+	Pipelines int
+	Passed    int
+	Failed    int
+	PassRate  float64
+}
+
+// finished autotest pipeline with parsed variables.
+type overviewPipeline struct {
+	ProjectID   int
+	Test        string
+	Environment string
+	Status      PipelineStatus
+	Created     time.Time
+	Duration    time.Duration
+	TestResults *TestResults
+}
+
+// aggregate statistics of recent autotest pipelines in all environments.
+func GetAutotestsOverview(ctx context.Context, input *OverviewInput) (*Overview, error) {
+	ctx, span := telemetry.Start(ctx, "autotests.GetAutotestsOverview")
+	defer span.End()
+
+	if err := input.Validate(); err != nil {
+		return nil, errors.Wrap(err, "error validating input")
+	}
+
+	requestedSince := time.Now().AddDate(0, 0, -input.Days)
+	since := requestedSince
+
+	// projects can be used in several autotests configs
+	projects := make(map[int]*config.Autotest)
+	projectTests := make(map[int]map[string]bool)
+
+	for _, autotest := range config.Get().Autotests {
+		if _, ok := projects[autotest.ProjectID]; !ok {
+			projects[autotest.ProjectID] = autotest
+			projectTests[autotest.ProjectID] = make(map[string]bool)
+		}
+
+		for _, action := range autotest.Actions {
+			projectTests[autotest.ProjectID][action.Test] = true
+		}
+	}
+
+	pipelines := make([]*overviewPipeline, 0)
+
+	for projectID, autotest := range projects {
+		projectPipelines, covered, err := getOverviewPipelines(ctx, autotest, projectTests[projectID], requestedSince)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting pipelines of project %d", projectID)
+		}
+
+		// all projects must have statistics for same period
+		if covered.After(since) {
+			since = covered
+		}
+
+		pipelines = append(pipelines, projectPipelines...)
+	}
+
+	pipelines = slices.DeleteFunc(pipelines, func(pipeline *overviewPipeline) bool {
+		return pipeline.Created.Before(since)
+	})
+
+	return &Overview{
+		Since:     utils.TimeToString(since),
+		Truncated: since.After(requestedSince),
+		Tests:     newTestsOverview(pipelines),
+	}, nil
+}
+
+// returns pipelines after since and start of period that is covered by pipelines,
+// only last pipelines of project are loaded, older pipelines can be in period.
+func getOverviewPipelines(ctx context.Context, autotest *config.Autotest, tests map[string]bool, since time.Time) ([]*overviewPipeline, time.Time, error) {
+	ctx, span := telemetry.Start(ctx, "autotests.getOverviewPipelines")
+	defer span.End()
+
+	projectID := strconv.Itoa(autotest.ProjectID)
+
+	pipelines, err := getCachedPipelines(ctx, projectID)
+	if err != nil {
+		return nil, since, err
+	}
+
+	covered := getCoveredSince(pipelines, since)
+
+	result := make([]*overviewPipeline, 0)
+
+	for _, pipeline := range pipelines {
+		if pipeline.Created == nil || pipeline.Created.Before(since) {
+			continue
+		}
+
+		status := PipelineStatus(pipeline.Status)
+
+		if status != pipelineStatusSuccess && status != pipelineStatusFailed {
+			continue
+		}
+
+		variables, err := api.GetCachedPipelineVariables(ctx, projectID, pipeline.ID)
+		if err != nil {
+			return nil, since, errors.Wrap(err, "error getting pipeline variables")
+		}
+
+		// ignore custom runs and pipelines that was not created by kubernetes-manager
+		if variables["CUSTOM_ACTION"] == config.TrueValue || !tests[variables[envNameTest]] {
+			continue
+		}
+
+		item := overviewPipeline{
+			ProjectID:   autotest.ProjectID,
+			Test:        variables[envNameTest],
+			Environment: fmt.Sprintf("%s:%s", variables[envNameCluster], variables[envNameNamespace]),
+			Status:      status,
+			Created:     *pipeline.Created,
+		}
+
+		if pipeline.Updated != nil {
+			item.Duration = pipeline.Updated.Sub(*pipeline.Created)
+		}
+
+		if len(autotest.JUnitArtifacts) > 0 {
+			item.TestResults, err = getPipelineTestResults(ctx, projectID, pipeline.ID, autotest.JUnitArtifacts)
+			if err != nil {
+				log.WithError(err).Warnf("can not get test results of pipeline %s", pipeline.ID)
+			}
+		}
+
+		result = append(result, &item)
+	}
+
+	return result, covered, nil
+}
+
+// if list of pipelines is full, pipelines before oldest pipeline was not loaded.
+func getCoveredSince(pipelines []*scm.Pipeline, since time.Time) time.Time {
+	if len(pipelines) < pipelinesListLimit {
+		return since
+	}
+
+	var oldest *time.Time
+
+	for _, pipeline := range pipelines {
+		if pipeline.Created != nil && (oldest == nil || pipeline.Created.Before(*oldest)) {
+			oldest = pipeline.Created
+		}
+	}
+
+	if oldest == nil || oldest.Before(since) {
+		return since
+	}
+
+	return *oldest
+}
+
+// last pipelines of project, pipelines are cached to reduce requests to source control.
+func getCachedPipelines(ctx context.Context, projectID string) ([]*scm.Pipeline, error) {
+	ctx, span := telemetry.Start(ctx, "autotests.getCachedPipelines")
+	defer span.End()
+
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return nil, errNoSCMProvider
+	}
+
+	cacheKey := fmt.Sprintf("autotests::project::%s::pipelines", projectID)
+	cacheValue := make([]*scm.Pipeline, 0)
+
+	if err := cache.Client().Get(ctx, cacheKey, &cacheValue); err == nil {
+		metrics.CacheHits.WithLabelValues("getCachedPipelines").Inc()
+
+		return cacheValue, nil
+	}
+
+	pipelines, err := scmProvider.ListPipelines(ctx, &scm.ListPipelinesInput{
+		ProjectID: projectID,
+		Limit:     pipelinesListLimit,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting pipelines")
+	}
+
+	_ = cache.Client().Set(ctx, cacheKey, pipelines, cache.MiddleTTL)
+
+	return pipelines, nil
+}
+
+func newTestsOverview(pipelines []*overviewPipeline) []*TestOverview {
+	type testKey struct {
+		projectID int
+		test      string
+	}
+
+	groups := make(map[testKey][]*overviewPipeline)
+
+	for _, pipeline := range pipelines {
+		key := testKey{projectID: pipeline.ProjectID, test: pipeline.Test}
+		groups[key] = append(groups[key], pipeline)
+	}
+
+	result := make([]*TestOverview, 0, len(groups))
+
+	for key, group := range groups {
+		result = append(result, newTestOverview(key.projectID, key.test, group))
+	}
+
+	slices.SortFunc(result, func(a, b *TestOverview) int {
+		return cmp.Or(cmp.Compare(a.ProjectID, b.ProjectID), cmp.Compare(a.Test, b.Test))
+	})
+
+	return result
+}
+
+func newTestOverview(projectID int, test string, pipelines []*overviewPipeline) *TestOverview {
+	slices.SortFunc(pipelines, func(a, b *overviewPipeline) int {
+		return a.Created.Compare(b.Created)
+	})
+
+	result := TestOverview{
+		ProjectID:       projectID,
+		Test:            test,
+		TopFailingTests: make([]*FailingTest, 0),
+		Trend:           make([]*TrendPoint, 0),
+	}
+
+	environments := make(map[string]bool)
+	failingTests := make(map[string]*FailingTest)
+
+	var totalDuration time.Duration
+
+	for _, pipeline := range pipelines {
+		environments[pipeline.Environment] = true
+		totalDuration += pipeline.Duration
+
+		date := pipeline.Created.Format(trendDateFormat)
+
+		if len(result.Trend) == 0 || result.Trend[len(result.Trend)-1].Date != date {
+			result.Trend = append(result.Trend, &TrendPoint{Date: date})
+		}
+
+		trend := result.Trend[len(result.Trend)-1]
+		trend.Pipelines++
+		result.Pipelines++
+
+		if pipeline.Status == pipelineStatusSuccess {
+			trend.Passed++
+			result.Passed++
+		} else {
+			trend.Failed++
+			result.Failed++
+		}
+
+		if pipeline.TestResults == nil {
+			continue
+		}
+
+		for _, testCase := range pipeline.TestResults.Failures {
+			failingTest := getFailingTest(failingTests, testCase.ID())
+			failingTest.Failures++
+			failingTest.LastFailed = utils.TimeToString(pipeline.Created)
+			failingTest.LastMessage = testCase.Message
+		}
+
+		for _, testCase := range pipeline.TestResults.FlakyTests {
+			getFailingTest(failingTests, testCase.ID()).Flaky++
+		}
+	}
+
+	for _, trend := range result.Trend {
+		trend.PassRate = passRate(trend.Passed, trend.Pipelines)
+	}
+
+	result.Environments = len(environments)
+	result.PassRate = passRate(result.Passed, result.Pipelines)
+
+	if result.Pipelines > 0 {
+		result.MeanDuration = (totalDuration / time.Duration(result.Pipelines)).Round(time.Second)
+		result.MeanDurationHuman = result.MeanDuration.String()
+	}
+
+	for _, failingTest := range failingTests {
+		result.TopFailingTests = append(result.TopFailingTests, failingTest)
+	}
+
+	slices.SortFunc(result.TopFailingTests, func(a, b *FailingTest) int {
+		return cmp.Or(
+			cmp.Compare(b.Failures, a.Failures),
+			cmp.Compare(b.Flaky, a.Flaky),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	if len(result.TopFailingTests) > topFailingTestsCount {
+		result.TopFailingTests = result.TopFailingTests[:topFailingTestsCount]
+	}
+
+	return &result
+}
+
+func getFailingTest(failingTests map[string]*FailingTest, id string) *FailingTest {
+	failingTest, ok := failingTests[id]
+	if !ok {
+		failingTest = &FailingTest{ID: id}
+		failingTests[id] = failingTest
+	}
+
+	return failingTest
+}
+
+// percent rounded to 2 decimal places.
+func passRate(passed, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	const percent = 10000
+
+	return float64(passed*percent/total) / 100
+}
@@ -0,0 +1,110 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package autotests_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/modules/autotests"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+)
+
+func TestNewTestsOverview(t *testing.T) {
+	t.Parallel()
+
+	day1 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	failed := &autotests.TestResults{
+		Failures: []*autotests.TestCase{{Suite: "api", Name: "login", Message: "expected 200"}},
+	}
+	flaky := &autotests.TestResults{
+		FlakyTests: []*autotests.TestCase{{Suite: "api", Name: "logout"}},
+	}
+
+	pipelines := []*autotests.OverviewPipeline{
+		{ProjectID: 1, Test: "smoke", Environment: "dev:a", Status: "success", Created: day2, Duration: 3 * time.Minute, TestResults: flaky},
+		{ProjectID: 1, Test: "smoke", Environment: "dev:a", Status: "failed", Created: day1, Duration: time.Minute, TestResults: failed},
+		{ProjectID: 1, Test: "smoke", Environment: "dev:b", Status: "failed", Created: day2, Duration: 2 * time.Minute, TestResults: failed},
+		{ProjectID: 1, Test: "regression", Environment: "dev:a", Status: "success", Created: day1},
+	}
+
+	result := autotests.NewTestsOverview(pipelines)
+
+	if len(result) != 2 {
+		t.Fatalf("want 2 tests, got %d", len(result))
+	}
+
+	if result[0].Test != "regression" || result[0].PassRate != 100 {
+		t.Fatalf("unexpected regression overview %+v", result[0])
+	}
+
+	smoke := result[1]
+
+	if smoke.Pipelines != 3 || smoke.Passed != 1 || smoke.Failed != 2 || smoke.Environments != 2 {
+		t.Fatalf("unexpected smoke overview %+v", smoke)
+	}
+
+	if smoke.PassRate != 33.33 {
+		t.Fatalf("want pass rate 33.33, got %v", smoke.PassRate)
+	}
+
+	if smoke.MeanDuration != 2*time.Minute {
+		t.Fatalf("want mean duration 2m, got %s", smoke.MeanDuration)
+	}
+
+	if len(smoke.Trend) != 2 || smoke.Trend[0].Date != "2026-01-01" || smoke.Trend[1].Pipelines != 2 || smoke.Trend[1].PassRate != 50 {
+		t.Fatalf("unexpected trend %+v %+v", smoke.Trend[0], smoke.Trend[1])
+	}
+
+	if len(smoke.TopFailingTests) != 2 {
+		t.Fatalf("want 2 failing tests, got %d", len(smoke.TopFailingTests))
+	}
+
+	if top := smoke.TopFailingTests[0]; top.ID != "api/login" || top.Failures != 2 || top.LastMessage != "expected 200" {
+		t.Fatalf("unexpected top failing test %+v", top)
+	}
+
+	if flakyTest := smoke.TopFailingTests[1]; flakyTest.ID != "api/logout" || flakyTest.Flaky != 1 {
+		t.Fatalf("unexpected flaky test %+v", flakyTest)
+	}
+}
+
+func TestGetCoveredSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+	since := now.AddDate(0, 0, -14)
+
+	pipelines := make([]*scm.Pipeline, 0)
+
+	// one pipeline every hour
+	for i := range 100 {
+		created := now.Add(-time.Duration(i) * time.Hour)
+
+		pipelines = append(pipelines, &scm.Pipeline{Created: &created})
+	}
+
+	if covered := autotests.GetCoveredSince(pipelines, since); !covered.Equal(now.Add(-99 * time.Hour)) {
+		t.Fatalf("list is full, period must start from oldest pipeline, got %s", covered)
+	}
+
+	if covered := autotests.GetCoveredSince(pipelines[:50], since); !covered.Equal(since) {
+		t.Fatalf("all pipelines are loaded, got %s", covered)
+	}
+
+	if covered := autotests.GetCoveredSince(pipelines, now.Add(-10*time.Hour)); !covered.Equal(now.Add(-10 * time.Hour)) {
+		t.Fatalf("oldest pipeline is before period, got %s", covered)
+	}
+}
@@ -0,0 +1,416 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package autotests
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	queueItemIDLength = 8
+	// queued test is removed from queue if it can not be started after this number of attempts
+	maxQueueAttempts = 3
+)
+
+var errQueueItemNotFound = errors.New("queued autotest not found")
+
+type QueuePriority int
+
+const (
+	// scheduled and post-deploy runs.
+	QueuePriorityAutomatic QueuePriority = 0
+	// runs of users are started before automatic runs, because user is waiting for results.
+	QueuePriorityManual QueuePriority = 1
+)
+
+// autotest that will be started when running pipeline of same test is finished.
+type QueueItem struct {
+	ID       string
+	Test     string
+	Ref      string
+	Owner    string
+	Priority QueuePriority
+	ExtraEnv map[string]string
+	Created  string
+	// failed attempts to start test
+	Attempts int `json:",omitempty"`
+	// position in queue, starts from 1
+	Position int
+}
+
+// returns queue of environment sorted by priority and creation time.
+func getQueue(environment *api.Environment) []*QueueItem {
+	return parseQueue(environment.NamespaceAnnotations[config.LabelAutotestQueue])
+}
+
+func parseQueue(value string) []*QueueItem {
+	result := make([]*QueueItem, 0)
+
+	if len(value) == 0 {
+		return result
+	}
+
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		log.WithError(err).Warn("error parsing autotest queue")
+	}
+
+	sortQueue(result)
+
+	return result
+}
+
+func sortQueue(queue []*QueueItem) {
+	slices.SortStableFunc(queue, func(a, b *QueueItem) int {
+		if a.Priority != b.Priority {
+			return cmp.Compare(b.Priority, a.Priority)
+		}
+
+		return cmp.Compare(a.Created, b.Created)
+	})
+
+	for i, item := range queue {
+		item.Position = i + 1
+	}
+}
+
+// queue is saved in namespace annotation and shared between replicas,
+// update is repeated with current queue if queue was changed by another replica.
+func updateQueue(ctx context.Context, environment *api.Environment, update func(queue []*QueueItem) ([]*QueueItem, error)) error {
+	return environment.UpdateNamespaceAnnotation(ctx, config.LabelAutotestQueue, func(value string) (string, error) {
+		queue, err := update(parseQueue(value))
+		if err != nil {
+			return "", err
+		}
+
+		queueJSON, err := json.Marshal(queue)
+		if err != nil {
+			return "", errors.Wrap(err, "error marshaling queue")
+		}
+
+		return string(queueJSON), nil
+	})
+}
+
+// add test to queue, same test with same parameters is added only once.
+func enqueueAutotest(ctx context.Context, input *StartAutotestInput) (*QueueItem, error) {
+	ctx, span := telemetry.Start(ctx, "autotests.enqueueAutotest")
+	defer span.End()
+
+	owner := input.GetUser(ctx)
+
+	var result *QueueItem
+
+	err := updateQueue(ctx, input.environment, func(queue []*QueueItem) ([]*QueueItem, error) {
+		for _, item := range queue {
+			if item.Test == input.Test && item.Ref == input.Ref && maps.Equal(item.ExtraEnv, input.ExtraEnv) {
+				result = item
+
+				return queue, nil
+			}
+		}
+
+		item := QueueItem{
+			ID:       utils.RandomString(queueItemIDLength),
+			Test:     input.Test,
+			Ref:      input.Ref,
+			Owner:    owner,
+			Priority: QueuePriorityManual,
+			ExtraEnv: input.ExtraEnv,
+			Created:  utils.TimeToString(time.Now()),
+		}
+
+		if owner == SystemOwner {
+			item.Priority = QueuePriorityAutomatic
+		}
+
+		result = &item
+
+		queue = append(queue, &item)
+		sortQueue(queue)
+
+		return queue, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error saving queue")
+	}
+
+	return result, nil
+}
+
+func isTestQueued(environment *api.Environment, test string) bool {
+	return slices.ContainsFunc(getQueue(environment), func(item *QueueItem) bool {
+		return item.Test == test
+	})
+}
+
+type CancelQueuedAutotestInput struct {
+	environment *api.Environment
+	ID          string
+}
+
+func (c *CancelQueuedAutotestInput) SetEnvironment(environment *api.Environment) {
+	c.environment = environment
+}
+
+// remove test from queue, users can cancel own and automatic runs.
+func CancelQueuedAutotest(ctx context.Context, input *CancelQueuedAutotestInput) error {
+	ctx, span := telemetry.Start(ctx, "autotests.CancelQueuedAutotest")
+	defer span.End()
+
+	if input.environment == nil {
+		return errors.New("environment is empty")
+	}
+
+	user := input.environment.GetUser(ctx)
+
+	return updateQueue(ctx, input.environment, func(queue []*QueueItem) ([]*QueueItem, error) {
+		index := slices.IndexFunc(queue, func(item *QueueItem) bool {
+			return item.ID == input.ID
+		})
+
+		if index < 0 {
+			return nil, errQueueItemNotFound
+		}
+
+		if owner := queue[index].Owner; owner != user && owner != SystemOwner {
+			return nil, errors.Errorf("autotest was queued by %s", owner)
+		}
+
+		return slices.Delete(queue, index, index+1), nil
+	})
+}
+
+// returns tests that can be started now, one test of every type that has no running pipeline.
+func getStartableQueueItems(queue []*QueueItem, runningTests map[string]bool) ([]*QueueItem, []*QueueItem) {
+	running := maps.Clone(runningTests)
+	startable := make([]*QueueItem, 0)
+	remaining := make([]*QueueItem, 0, len(queue))
+
+	for _, item := range queue {
+		if running[item.Test] {
+			remaining = append(remaining, item)
+
+			continue
+		}
+
+		running[item.Test] = true
+
+		startable = append(startable, item)
+	}
+
+	return startable, remaining
+}
+
+// start queued tests that have no running pipelines, one pipeline of every test is started.
+// tests are removed from queue before start, so test is started only by one replica,
+// tests that failed to start are returned to queue.
+func ProcessQueue(ctx context.Context, environment *api.Environment) error {
+	ctx, span := telemetry.Start(ctx, "autotests.ProcessQueue")
+	defer span.End()
+
+	if err := environment.ReloadFromNamespace(ctx); err != nil {
+		return errors.Wrap(err, "error reload environment")
+	}
+
+	queue := getQueue(environment)
+	if len(queue) == 0 {
+		return nil
+	}
+
+	autotestConfig := config.Get().GetAutotestByID(environment.ID)
+	if autotestConfig == nil {
+		return updateQueue(ctx, environment, func(_ []*QueueItem) ([]*QueueItem, error) {
+			return []*QueueItem{}, nil
+		})
+	}
+
+	runningTests, err := getRunningTests(ctx, environment)
+	if err != nil {
+		return errors.Wrap(err, "error getting running tests")
+	}
+
+	if startable, _ := getStartableQueueItems(queue, runningTests); len(startable) == 0 {
+		return nil
+	}
+
+	var startable []*QueueItem
+
+	err = updateQueue(ctx, environment, func(queue []*QueueItem) ([]*QueueItem, error) {
+		var remaining []*QueueItem
+
+		startable, remaining = getStartableQueueItems(queue, runningTests)
+
+		return remaining, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "error saving queue")
+	}
+
+	failed := make([]*QueueItem, 0)
+
+	for _, item := range startable {
+		log := log.WithFields(log.Fields{
+			"environment": environment.ID,
+			"test":        item.Test,
+			"owner":       item.Owner,
+		})
+
+		if err := startQueuedAutotest(ctx, environment, autotestConfig, item); err != nil {
+			item.Attempts++
+
+			if item.Attempts >= maxQueueAttempts {
+				log.WithError(err).Errorf("queued autotest removed after %d attempts", item.Attempts)
+
+				sendQueuedAutotestFailed(ctx, environment, item, err)
+
+				continue
+			}
+
+			log.WithError(err).Warn("error starting queued autotest, it will be retried")
+
+			failed = append(failed, item)
+
+			continue
+		}
+
+		log.Info("queued autotest started")
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	// failed tests keep priority and creation time
+	return updateQueue(ctx, environment, func(queue []*QueueItem) ([]*QueueItem, error) {
+		queue = append(queue, failed...)
+		sortQueue(queue)
+
+		return queue, nil
+	})
+}
+
+func startQueuedAutotest(ctx context.Context, environment *api.Environment, autotestConfig *config.Autotest, item *QueueItem) error {
+	action := autotestConfig.GetActionByTest(item.Test)
+	if action == nil {
+		return errors.Errorf("test %s is not configured", item.Test)
+	}
+
+	ctx = context.WithValue(ctx, types.ContextSecurityKey, types.ContextSecurity{
+		Owner: item.Owner,
+	})
+
+	return triggerAutotest(ctx, environment, autotestConfig, action, item.Ref, item.ExtraEnv)
+}
+
+// notify owner that queued test was removed from queue.
+func sendQueuedAutotestFailed(ctx context.Context, environment *api.Environment, item *QueueItem, err error) {
+	eventMessage := environment.NewWebhookMessage(types.EventAutotestFinished)
+	eventMessage.Reason = "Queued autotest was not started ..."
+	eventMessage.Properties["slackEmoji"] = ":test_tube:"
+	eventMessage.Properties["user"] = item.Owner
+	eventMessage.Properties["test"] = item.Test
+	eventMessage.Properties["ref"] = item.Ref
+	eventMessage.Properties["status"] = string(pipelineStatusFailed)
+	eventMessage.Properties["error"] = err.Error()
+
+	environment.SendWebhookEvent(ctx, eventMessage)
+}
+
+// start queued tests in all environments.
+func processQueues(ctx context.Context) error {
+	environments, err := api.GetEnvironments(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "error listing environments")
+	}
+
+	for _, environment := range environments {
+		if len(getQueue(environment)) == 0 {
+			continue
+		}
+
+		if err := ProcessQueue(ctx, environment); err != nil {
+			log.WithError(err).WithField("environment", environment.ID).Error("error processing autotest queue")
+		}
+	}
+
+	return nil
+}
+
+// returns tests that have running or pending pipelines,
+// only variables of running pipelines are requested, finished pipelines are ignored.
+func getRunningTests(ctx context.Context, environment *api.Environment) (map[string]bool, error) {
+	ctx, span := telemetry.Start(ctx, "autotests.getRunningTests")
+	defer span.End()
+
+	autotestConfig := config.Get().GetAutotestByID(environment.ID)
+	if autotestConfig == nil {
+		return nil, errNotFound
+	}
+
+	scmProvider := client.GetSCMProvider()
+	if scmProvider == nil {
+		return nil, errNoSCMProvider
+	}
+
+	projectID := strconv.Itoa(autotestConfig.ProjectID)
+
+	pipelines, err := scmProvider.ListPipelines(ctx, &scm.ListPipelinesInput{
+		ProjectID: projectID,
+		Limit:     pipelinesListLimit,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting pipelines")
+	}
+
+	result := make(map[string]bool)
+
+	for _, pipeline := range pipelines {
+		status := PipelineStatus(pipeline.Status)
+
+		if status != pipelineStatusRunning && status != pipelineStatusPending {
+			continue
+		}
+
+		variables, err := api.GetCachedPipelineVariables(ctx, projectID, pipeline.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "error getting pipeline variables")
+		}
+
+		// ignore pipelines with another namespace
+		if autotestConfig.FilterByNamespace && variables[envNameNamespace] != environment.Namespace {
+			continue
+		}
+
+		if autotestConfig.GetActionByTest(variables[envNameTest]) != nil {
+			result[variables[envNameTest]] = true
+		}
+	}
+
+	return result, nil
+}
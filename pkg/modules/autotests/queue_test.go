@@ -0,0 +1,101 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package autotests_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/modules/autotests"
+)
+
+func TestGetQueue(t *testing.T) {
+	t.Parallel()
+
+	queue := []*autotests.QueueItem{
+		{ID: "1", Test: "regression", Owner: autotests.SystemOwner, Priority: autotests.QueuePriorityAutomatic, Created: "2026-01-01T10:00:00Z"},
+		{ID: "2", Test: "smoke", Owner: "user2", Priority: autotests.QueuePriorityManual, Created: "2026-01-01T10:02:00Z"},
+		{ID: "3", Test: "smoke", Owner: "user1", Priority: autotests.QueuePriorityManual, Created: "2026-01-01T10:01:00Z"},
+	}
+
+	queueJSON, err := json.Marshal(queue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	environment := api.Environment{
+		NamespaceAnnotations: map[string]string{
+			config.LabelAutotestQueue: string(queueJSON),
+		},
+	}
+
+	result := autotests.GetQueue(&environment)
+
+	want := []string{"3", "2", "1"}
+
+	if len(result) != len(want) {
+		t.Fatalf("want %d items, got %d", len(want), len(result))
+	}
+
+	for i, item := range result {
+		if item.ID != want[i] {
+			t.Fatalf("position %d: want %s, got %s", i+1, want[i], item.ID)
+		}
+
+		if item.Position != i+1 {
+			t.Fatalf("item %s: want position %d, got %d", item.ID, i+1, item.Position)
+		}
+	}
+}
+
+func TestGetQueueEmpty(t *testing.T) {
+	t.Parallel()
+
+	environment := api.Environment{
+		NamespaceAnnotations: map[string]string{},
+	}
+
+	if result := autotests.GetQueue(&environment); len(result) != 0 {
+		t.Fatalf("want empty queue, got %d items", len(result))
+	}
+}
+
+func TestGetStartableQueueItems(t *testing.T) {
+	t.Parallel()
+
+	queue := []*autotests.QueueItem{
+		{ID: "1", Test: "smoke"},
+		{ID: "2", Test: "smoke"},
+		{ID: "3", Test: "regression"},
+		{ID: "4", Test: "e2e"},
+	}
+
+	runningTests := map[string]bool{"regression": true}
+
+	startable, remaining := autotests.GetStartableQueueItems(queue, runningTests)
+
+	if len(startable) != 2 || startable[0].ID != "1" || startable[1].ID != "4" {
+		t.Fatalf("unexpected startable items %+v", startable)
+	}
+
+	if len(remaining) != 2 || remaining[0].ID != "2" || remaining[1].ID != "3" {
+		t.Fatalf("unexpected remaining items %+v", remaining)
+	}
+
+	// running tests must not be changed, function is called again when queue is updated by another replica
+	if len(runningTests) != 1 {
+		t.Fatalf("running tests was changed %+v", runningTests)
+	}
+}
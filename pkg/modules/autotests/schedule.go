@@ -0,0 +1,215 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package autotests
+
+import (
+	"context"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// owner of automatic autotest runs.
+	SystemOwner = "kubernetes-manager"
+
+	TriggerSchedule = "schedule"
+	TriggerDeploy   = "deploy"
+
+	envNameTrigger string = "TRIGGER"
+
+	maxScheduleDuration = 5 * time.Minute
+)
+
+// start scheduled autotests at the beginning of every minute, must run only in one replica,
+// queued autotests are also checked in case pipeline event was lost.
+func Schedule(ctx context.Context) {
+	for ctx.Err() == nil {
+		next := time.Now().Truncate(time.Minute).Add(time.Minute)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, maxScheduleDuration)
+			defer cancel()
+
+			ctx, span := telemetry.Start(ctx, "autotests.scheduleAutotests", trace.WithNewRoot())
+			defer span.End()
+
+			if err := startScheduledAutotests(ctx, next); err != nil {
+				log.WithError(err).Error()
+			}
+
+			if err := processQueues(ctx); err != nil {
+				log.WithError(err).Error()
+			}
+		}()
+	}
+}
+
+func startScheduledAutotests(ctx context.Context, now time.Time) error {
+	scheduled := make(map[*config.Autotest][]*config.AutotestSchedule)
+
+	for _, autotest := range config.Get().Autotests {
+		for _, schedule := range autotest.Schedules {
+			if schedule.IsScheduled(now) {
+				scheduled[autotest] = append(scheduled[autotest], schedule)
+			}
+		}
+	}
+
+	if len(scheduled) == 0 {
+		return nil
+	}
+
+	environments, err := api.GetEnvironments(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "error listing environments")
+	}
+
+	for _, environment := range environments {
+		if environment.IsSystemNamespace() {
+			continue
+		}
+
+		schedules := scheduled[config.Get().GetAutotestByID(environment.ID)]
+
+		for _, schedule := range schedules {
+			if !schedule.MatchEnvironment(environment.ID) {
+				continue
+			}
+
+			if err := startAutomaticAutotest(ctx, environment, schedule.Test, TriggerSchedule); err != nil {
+				log.WithError(err).WithField("environment", environment.ID).Error("error starting scheduled autotest")
+			}
+		}
+	}
+
+	return nil
+}
+
+// start autotests after successful deploy pipeline in environment.
+func ProcessGitlabDeployPipelineEvent(ctx context.Context, event *gitlab.PipelineEvent) error {
+	ctx, span := telemetry.Start(ctx, "autotests.ProcessGitlabDeployPipelineEvent")
+	defer span.End()
+
+	variables := api.GetGitlabPipelineEventVariables(event)
+
+	if variables[string(api.GitlabPipelineOperationDeploy)] != config.TrueValue || event.ObjectAttributes.Status != "success" {
+		return nil
+	}
+
+	environmentID := api.GetGitlabPipelineEnvironmentID(variables)
+	if len(environmentID) == 0 {
+		return nil
+	}
+
+	autotestConfig := config.Get().GetAutotestByID(environmentID)
+	if autotestConfig == nil {
+		return nil
+	}
+
+	environment, err := api.GetEnvironmentByID(ctx, environmentID)
+	if err != nil {
+		log.WithError(err).Warnf("environment %s not found", environmentID)
+
+		return nil
+	}
+
+	for _, schedule := range autotestConfig.Schedules {
+		if !schedule.AfterDeploy || !schedule.MatchEnvironment(environmentID) {
+			continue
+		}
+
+		err := startAutomaticAutotest(ctx, environment, schedule.Test, TriggerDeploy)
+		if err != nil {
+			return errors.Wrap(err, "error starting autotest after deploy")
+		}
+	}
+
+	return nil
+}
+
+// start autotest with system owner, environments that are scaled down are skipped.
+func startAutomaticAutotest(ctx context.Context, environment *api.Environment, test, trigger string) error {
+	ctx, span := telemetry.Start(ctx, "autotests.startAutomaticAutotest")
+	defer span.End()
+
+	log := log.WithFields(log.Fields{
+		"environment": environment.ID,
+		"test":        test,
+		"trigger":     trigger,
+	})
+
+	// get latest annotations
+	if err := environment.ReloadFromNamespace(ctx); err != nil {
+		return errors.Wrap(err, "error reload environment")
+	}
+
+	if !environment.IsWorkingHours(time.Now()) {
+		log.Info("environment is scaled down, autotest skipped")
+
+		return nil
+	}
+
+	// environment can be scaled down before scale down delay
+	podsInfo, err := environment.GetPodsInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting pods info")
+	}
+
+	if podsInfo.PodsTotal == 0 {
+		log.Info("environment has no pods, autotest skipped")
+
+		return nil
+	}
+
+	ctx = context.WithValue(ctx, types.ContextSecurityKey, types.ContextSecurity{
+		Owner: SystemOwner,
+	})
+
+	input := StartAutotestInput{
+		Test: test,
+		ExtraEnv: map[string]string{
+			envNameTrigger: trigger,
+		},
+	}
+
+	input.SetEnvironment(environment)
+
+	result, err := StartAutotest(ctx, &input)
+	if err != nil {
+		return err
+	}
+
+	if result.QueueItem != nil {
+		log.Infof("autotest is already running, queued at position %d", result.QueueItem.Position)
+
+		return nil
+	}
+
+	log.Info("autotest started")
+
+	return nil
+}
@@ -0,0 +1,216 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package portforward
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const closeTimeout = 5 * time.Second
+
+// websocket connection as stream of bytes, data is sent in binary messages.
+type Conn struct {
+	ws     *websocket.Conn
+	reader io.Reader
+}
+
+func NewConn(ws *websocket.Conn) *Conn {
+	return &Conn{ws: ws}
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			messageType, reader, err := c.ws.NextReader()
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return 0, io.EOF
+			}
+
+			if err != nil {
+				return 0, err //nolint:wrapcheck
+			}
+
+			if messageType != websocket.BinaryMessage {
+				continue
+			}
+
+			c.reader = reader
+		}
+
+		n, err := c.reader.Read(p)
+		if errors.Is(err, io.EOF) {
+			c.reader = nil
+
+			if n == 0 {
+				continue
+			}
+
+			return n, nil
+		}
+
+		return n, err //nolint:wrapcheck
+	}
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	if err := c.ws.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return len(p), nil
+}
+
+func (c *Conn) Close() error {
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+
+	_ = c.ws.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(closeTimeout))
+
+	return c.ws.Close() //nolint:wrapcheck
+}
+
+// copy data in both directions until one side is closed.
+func Pipe(a, b io.ReadWriter) {
+	done := make(chan struct{}, 2) //nolint:mnd
+
+	go func() {
+		_, _ = io.Copy(a, b)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(b, a)
+		done <- struct{}{}
+	}()
+
+	<-done
+}
+
+// opens local listener and forwards connections to service or pod in environment.
+type Client struct {
+	// kubernetes-manager URL
+	Server        string
+	EnvironmentID string
+	Service       string
+	Pod           string
+	Port          int
+	// authentication headers
+	Headers http.Header
+}
+
+func (c *Client) URL() (string, error) {
+	serverURL, err := url.Parse(c.Server)
+	if err != nil {
+		return "", errors.Wrap(err, "can not parse server url")
+	}
+
+	switch serverURL.Scheme {
+	case "https":
+		serverURL.Scheme = "wss"
+	case "http":
+		serverURL.Scheme = "ws"
+	default:
+		return "", errors.Errorf("unknown server scheme %s", serverURL.Scheme)
+	}
+
+	serverURL.Path = strings.TrimSuffix(serverURL.Path, "/") + "/api/" + c.EnvironmentID + "/port-forward"
+
+	query := url.Values{}
+	query.Set("port", strconv.Itoa(c.Port))
+
+	if len(c.Service) > 0 {
+		query.Set("service", c.Service)
+	}
+
+	if len(c.Pod) > 0 {
+		query.Set("pod", c.Pod)
+	}
+
+	serverURL.RawQuery = query.Encode()
+
+	return serverURL.String(), nil
+}
+
+func (c *Client) ListenAndServe(ctx context.Context, address string) error {
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", address)
+	if err != nil {
+		return errors.Wrap(err, "can not listen")
+	}
+
+	return c.Serve(ctx, listener)
+}
+
+// forward connections of listener, listener is closed when context is done.
+func (c *Client) Serve(ctx context.Context, listener net.Listener) error {
+	serverURL, err := c.URL()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		_ = listener.Close()
+	}()
+
+	log.Infof("forwarding %s to %s", listener.Addr().String(), serverURL)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return errors.Wrap(err, "can not accept connection")
+		}
+
+		go c.forward(ctx, serverURL, conn)
+	}
+}
+
+func (c *Client) forward(ctx context.Context, serverURL string, conn net.Conn) {
+	defer conn.Close()
+
+	ws, resp, err := websocket.DefaultDialer.DialContext(ctx, serverURL, c.Headers)
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if err != nil {
+		if resp != nil {
+			message, _ := io.ReadAll(resp.Body)
+
+			log.WithError(err).Errorf("can not connect to server, status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+		} else {
+			log.WithError(err).Error("can not connect to server")
+		}
+
+		return
+	}
+
+	wsConn := NewConn(ws)
+	defer wsConn.Close()
+
+	Pipe(conn, wsConn)
+}
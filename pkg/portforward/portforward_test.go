@@ -0,0 +1,113 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package portforward_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/portforward"
+)
+
+func TestURL(t *testing.T) {
+	t.Parallel()
+
+	client := portforward.Client{
+		Server:        "https://manager/prefix/",
+		EnvironmentID: "cluster:namespace",
+		Service:       "mysql",
+		Port:          3306,
+	}
+
+	url, err := client.URL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "wss://manager/prefix/api/cluster:namespace/port-forward?port=3306&service=mysql"; url != want {
+		t.Fatalf("want=%s,got=%s", want, url)
+	}
+
+	client.Server = "ftp://manager"
+
+	if _, err := client.URL(); err == nil {
+		t.Fatal("unknown scheme must be error")
+	}
+}
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	upgrader := websocket.Upgrader{}
+
+	// echo server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		conn := portforward.NewConn(ws)
+		defer conn.Close()
+
+		_, _ = io.Copy(conn, conn)
+	}))
+	defer ts.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := portforward.Client{
+		Server:        ts.URL,
+		EnvironmentID: "cluster:namespace",
+		Pod:           "pod",
+		Port:          3306,
+		Headers:       http.Header{"Authorization": []string{"Bearer test"}},
+	}
+
+	go func() {
+		_ = client.Serve(t.Context(), listener)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4)
+
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(buf) != "ping" {
+		t.Fatalf("want=ping,got=%s", buf)
+	}
+}
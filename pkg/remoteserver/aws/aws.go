@@ -0,0 +1,215 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package aws
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+)
+
+const tagName = "Name"
+
+type ProviderConfig struct {
+	AccessKeyID     string
+	AccessSecretKey string
+	Region          string
+	// only instances with this tags are remote servers, all instances if empty
+	Tags map[string]string
+}
+
+type Provider struct {
+	config ProviderConfig
+	client *ec2.EC2
+}
+
+func NewProvider(config ProviderConfig, httpClient *http.Client) (*Provider, error) {
+	if len(config.Region) == 0 {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+
+	awsConfig := &aws.Config{
+		Region:     aws.String(config.Region),
+		HTTPClient: httpClient,
+	}
+
+	if len(config.AccessKeyID) > 0 && len(config.AccessSecretKey) > 0 {
+		awsConfig.Credentials = credentials.NewStaticCredentials(
+			config.AccessKeyID,
+			config.AccessSecretKey,
+			"",
+		)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while creating session")
+	}
+
+	return &Provider{
+		config: config,
+		client: ec2.New(sess),
+	}, nil
+}
+
+func (p *Provider) ListServers(ctx context.Context) ([]*remoteserver.Server, error) {
+	ctx, span := telemetry.Start(ctx, "remoteserver.aws.ListServers")
+	defer span.End()
+
+	filters := []*ec2.Filter{{
+		Name:   aws.String("instance-state-name"),
+		Values: aws.StringSlice([]string{"pending", "running", "stopping", "stopped"}),
+	}}
+
+	for key, value := range p.config.Tags {
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String("tag:" + key),
+			Values: aws.StringSlice([]string{value}),
+		})
+	}
+
+	result := make([]*remoteserver.Server, 0)
+
+	err := p.client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{Filters: filters},
+		func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					result = append(result, newServer(instance))
+				}
+			}
+
+			return true
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "error while getting instances")
+	}
+
+	return result, nil
+}
+
+func newServer(instance *ec2.Instance) *remoteserver.Server {
+	server := remoteserver.Server{
+		ID:         aws.StringValue(instance.InstanceId),
+		Name:       aws.StringValue(instance.InstanceId),
+		Status:     remoteserver.ServerStatusStopped,
+		IPv4:       aws.StringValue(instance.PublicIpAddress),
+		ServerType: aws.StringValue(instance.InstanceType),
+		Labels:     make(map[string]string),
+	}
+
+	if instance.State != nil && aws.StringValue(instance.State.Name) == ec2.InstanceStateNameRunning {
+		server.Status = remoteserver.ServerStatusRunning
+	}
+
+	for _, tag := range instance.Tags {
+		server.Labels[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	if name := server.Labels[tagName]; len(name) > 0 {
+		server.Name = name
+	}
+
+	// launch time is changed on every start, attach time of first volume is closer to creation time
+	server.Created = aws.TimeValue(instance.LaunchTime)
+
+	for _, device := range instance.BlockDeviceMappings {
+		if device.Ebs == nil {
+			continue
+		}
+
+		if attachTime := aws.TimeValue(device.Ebs.AttachTime); !attachTime.IsZero() && attachTime.Before(server.Created) {
+			server.Created = attachTime
+		}
+	}
+
+	if server.Created.IsZero() {
+		server.Created = time.Now()
+	}
+
+	return &server
+}
+
+func (p *Provider) PowerOn(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.aws.PowerOn")
+	defer span.End()
+
+	_, err := p.client.StartInstancesWithContext(ctx, &ec2.StartInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return errors.Wrap(err, "can power on server")
+	}
+
+	return nil
+}
+
+func (p *Provider) PowerOff(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.aws.PowerOff")
+	defer span.End()
+
+	_, err := p.client.StopInstancesWithContext(ctx, &ec2.StopInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return errors.Wrap(err, "can power off server")
+	}
+
+	return nil
+}
+
+func (p *Provider) Reboot(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.aws.Reboot")
+	defer span.End()
+
+	_, err := p.client.RebootInstancesWithContext(ctx, &ec2.RebootInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return errors.Wrap(err, "can reboot server")
+	}
+
+	return nil
+}
+
+func (p *Provider) SetLabels(ctx context.Context, id string, labels map[string]string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.aws.SetLabels")
+	defer span.End()
+
+	tags := make([]*ec2.Tag, 0, len(labels))
+
+	for key, value := range labels {
+		tags = append(tags, &ec2.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(value),
+		})
+	}
+
+	_, err := p.client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: aws.StringSlice([]string{id}),
+		Tags:      tags,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error updating server tags")
+	}
+
+	return nil
+}
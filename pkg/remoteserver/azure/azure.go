@@ -0,0 +1,269 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package azure
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+)
+
+const powerStateRunning = "PowerState/running"
+
+type ProviderConfig struct {
+	SubscriptionID string
+	ClientID       string
+	ClientSecret   string
+	TenantID       string
+	// list virtual machines only in resource group, all resource groups if empty
+	ResourceGroup string
+	// only virtual machines with this tags are remote servers, all virtual machines if empty
+	Tags map[string]string
+}
+
+type Provider struct {
+	config                ProviderConfig
+	virtualMachinesClient *armcompute.VirtualMachinesClient
+}
+
+func NewProvider(config ProviderConfig, httpClient *http.Client) (*Provider, error) {
+	clientOptions := policy.ClientOptions{
+		Transport: httpClient,
+	}
+
+	cred, err := azidentity.NewClientSecretCredential(
+		config.TenantID,
+		config.ClientID,
+		config.ClientSecret,
+		&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid auth")
+	}
+
+	virtualMachinesClient, err := armcompute.NewVirtualMachinesClient(
+		config.SubscriptionID,
+		cred,
+		&arm.ClientOptions{ClientOptions: clientOptions},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not create virtual machines client")
+	}
+
+	return &Provider{
+		config:                config,
+		virtualMachinesClient: virtualMachinesClient,
+	}, nil
+}
+
+func (p *Provider) ListServers(ctx context.Context) ([]*remoteserver.Server, error) {
+	ctx, span := telemetry.Start(ctx, "remoteserver.azure.ListServers")
+	defer span.End()
+
+	// statusOnly returns instance view of virtual machines, power state is read without request per virtual machine,
+	// list of resource group does not support it, virtual machines are filtered by resource group of ID
+	statusOnly := "true"
+
+	pager := p.virtualMachinesClient.NewListAllPager(&armcompute.VirtualMachinesClientListAllOptions{
+		StatusOnly: &statusOnly,
+	})
+
+	virtualMachines := make([]*armcompute.VirtualMachine, 0)
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "can not get virtual machines")
+		}
+
+		virtualMachines = append(virtualMachines, page.Value...)
+	}
+
+	result := make([]*remoteserver.Server, 0)
+
+	for _, virtualMachine := range virtualMachines {
+		if virtualMachine.ID == nil || !p.matchTags(virtualMachine.Tags) {
+			continue
+		}
+
+		resource, err := parseID(*virtualMachine.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.matchResourceGroup(resource) {
+			continue
+		}
+
+		result = append(result, newServer(resource, virtualMachine))
+	}
+
+	return result, nil
+}
+
+func (p *Provider) matchResourceGroup(resource *arm.ResourceID) bool {
+	if len(p.config.ResourceGroup) == 0 {
+		return true
+	}
+
+	return strings.EqualFold(resource.ResourceGroupName, p.config.ResourceGroup)
+}
+
+func (p *Provider) matchTags(tags map[string]*string) bool {
+	for key, value := range p.config.Tags {
+		if tags[key] == nil || *tags[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// public IP address of virtual machine is not returned, it requires network API.
+func newServer(resource *arm.ResourceID, virtualMachine *armcompute.VirtualMachine) *remoteserver.Server {
+	server := remoteserver.Server{
+		ID:     *virtualMachine.ID,
+		Name:   resource.Name,
+		Status: remoteserver.ServerStatusStopped,
+		Labels: make(map[string]string),
+	}
+
+	for key, value := range virtualMachine.Tags {
+		if value != nil {
+			server.Labels[key] = *value
+		}
+	}
+
+	if properties := virtualMachine.Properties; properties != nil {
+		if properties.TimeCreated != nil {
+			server.Created = *properties.TimeCreated
+		}
+
+		if properties.HardwareProfile != nil && properties.HardwareProfile.VMSize != nil {
+			server.ServerType = string(*properties.HardwareProfile.VMSize)
+		}
+
+		if properties.InstanceView != nil {
+			for _, status := range properties.InstanceView.Statuses {
+				if status.Code != nil && *status.Code == powerStateRunning {
+					server.Status = remoteserver.ServerStatusRunning
+				}
+			}
+		}
+	}
+
+	return &server
+}
+
+func parseID(id string) (*arm.ResourceID, error) {
+	resource, err := arm.ParseResourceID(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not parse resource id %s", id)
+	}
+
+	return resource, nil
+}
+
+func (p *Provider) PowerOn(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.azure.PowerOn")
+	defer span.End()
+
+	resource, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.virtualMachinesClient.BeginStart(ctx, resource.ResourceGroupName, resource.Name, nil); err != nil {
+		return errors.Wrap(err, "can power on server")
+	}
+
+	return nil
+}
+
+// virtual machine is deallocated, stopped virtual machine is still billed.
+func (p *Provider) PowerOff(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.azure.PowerOff")
+	defer span.End()
+
+	resource, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.virtualMachinesClient.BeginDeallocate(ctx, resource.ResourceGroupName, resource.Name, nil); err != nil {
+		return errors.Wrap(err, "can power off server")
+	}
+
+	return nil
+}
+
+func (p *Provider) Reboot(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.azure.Reboot")
+	defer span.End()
+
+	resource, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.virtualMachinesClient.BeginRestart(ctx, resource.ResourceGroupName, resource.Name, nil); err != nil {
+		return errors.Wrap(err, "can reboot server")
+	}
+
+	return nil
+}
+
+func (p *Provider) SetLabels(ctx context.Context, id string, labels map[string]string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.azure.SetLabels")
+	defer span.End()
+
+	resource, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	virtualMachine, err := p.virtualMachinesClient.Get(ctx, resource.ResourceGroupName, resource.Name, nil)
+	if err != nil {
+		return errors.Wrap(err, "can not get server")
+	}
+
+	tags := make(map[string]*string)
+
+	maps.Copy(tags, virtualMachine.Tags)
+
+	for key, value := range labels {
+		tags[key] = &value
+	}
+
+	poller, err := p.virtualMachinesClient.BeginUpdate(ctx, resource.ResourceGroupName, resource.Name, armcompute.VirtualMachineUpdate{
+		Tags: tags,
+	}, nil)
+	if err != nil {
+		return errors.Wrap(err, "error updating server tags")
+	}
+
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return errors.Wrap(err, "error updating server tags")
+	}
+
+	return nil
+}
@@ -0,0 +1,296 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package hcloud
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+)
+
+const metricsStepSeconds = 60
+
+type ProviderConfig struct {
+	Token string
+}
+
+type Provider struct {
+	Client *hcloud.Client
+}
+
+func NewProvider(config ProviderConfig, httpClient *http.Client) *Provider {
+	return &Provider{
+		Client: hcloud.NewClient(
+			hcloud.WithToken(config.Token),
+			hcloud.WithHTTPClient(httpClient),
+		),
+	}
+}
+
+func (p *Provider) ListServers(ctx context.Context) ([]*remoteserver.Server, error) {
+	ctx, span := telemetry.Start(ctx, "remoteserver.hcloud.ListServers")
+	defer span.End()
+
+	servers, err := p.Client.Server.All(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get servers")
+	}
+
+	result := make([]*remoteserver.Server, 0, len(servers))
+
+	for _, server := range servers {
+		result = append(result, newServer(server))
+	}
+
+	return result, nil
+}
+
+func newServer(server *hcloud.Server) *remoteserver.Server {
+	status := remoteserver.ServerStatusStopped
+
+	if server.Status == hcloud.ServerStatusRunning {
+		status = remoteserver.ServerStatusRunning
+	}
+
+	result := remoteserver.Server{
+		ID:      strconv.Itoa(server.ID),
+		Name:    server.Name,
+		Status:  status,
+		IPv4:    server.PublicNet.IPv4.IP.String(),
+		Created: server.Created,
+		Labels:  server.Labels,
+	}
+
+	if server.ServerType != nil {
+		result.ServerType = server.ServerType.Name
+	}
+
+	return &result
+}
+
+func (p *Provider) GetServer(ctx context.Context, id string) (*hcloud.Server, error) {
+	serverID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, errors.New("can not parse id")
+	}
+
+	server, _, err := p.Client.Server.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get server")
+	}
+
+	if server == nil {
+		return nil, errors.Wrap(remoteserver.ErrNotFound, id)
+	}
+
+	return server, nil
+}
+
+func (p *Provider) PowerOn(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.hcloud.PowerOn")
+	defer span.End()
+
+	server, err := p.GetServer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := p.Client.Server.Poweron(ctx, server); err != nil {
+		return errors.Wrap(err, "can power on server")
+	}
+
+	return nil
+}
+
+func (p *Provider) PowerOff(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.hcloud.PowerOff")
+	defer span.End()
+
+	server, err := p.GetServer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := p.Client.Server.Poweroff(ctx, server); err != nil {
+		return errors.Wrap(err, "can power off server")
+	}
+
+	return nil
+}
+
+func (p *Provider) Reboot(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.hcloud.Reboot")
+	defer span.End()
+
+	server, err := p.GetServer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := p.Client.Server.Reboot(ctx, server); err != nil {
+		return errors.Wrap(err, "can reboot server")
+	}
+
+	return nil
+}
+
+func (p *Provider) SetLabels(ctx context.Context, id string, labels map[string]string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.hcloud.SetLabels")
+	defer span.End()
+
+	server, err := p.GetServer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	newLabels := make(map[string]string)
+
+	maps.Copy(newLabels, server.Labels)
+	maps.Copy(newLabels, labels)
+
+	opts := hcloud.ServerUpdateOpts{
+		Labels: newLabels,
+	}
+
+	if _, _, err := p.Client.Server.Update(ctx, server, opts); err != nil {
+		return errors.Wrap(err, "error updating server")
+	}
+
+	return nil
+}
+
+func (p *Provider) CreateServer(ctx context.Context, input *remoteserver.CreateServerInput) (*remoteserver.Server, error) {
+	ctx, span := telemetry.Start(ctx, "remoteserver.hcloud.CreateServer")
+	defer span.End()
+
+	opts := hcloud.ServerCreateOpts{
+		Name:             input.Name,
+		ServerType:       &hcloud.ServerType{Name: input.ServerType},
+		Image:            &hcloud.Image{Name: input.Image},
+		UserData:         input.UserData,
+		Labels:           input.Labels,
+		StartAfterCreate: hcloud.Ptr(true),
+	}
+
+	if len(input.Location) > 0 {
+		opts.Location = &hcloud.Location{Name: input.Location}
+	}
+
+	// keys are sent by ID
+	for _, name := range input.SSHKeys {
+		sshKey, _, err := p.Client.SSHKey.Get(ctx, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can not get ssh key %s", name)
+		}
+
+		if sshKey == nil {
+			return nil, errors.Errorf("ssh key %s not found", name)
+		}
+
+		opts.SSHKeys = append(opts.SSHKeys, sshKey)
+	}
+
+	result, _, err := p.Client.Server.Create(ctx, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not create server")
+	}
+
+	return newServer(result.Server), nil
+}
+
+func (p *Provider) CreateSnapshot(ctx context.Context, id, description string, labels map[string]string) (string, error) {
+	ctx, span := telemetry.Start(ctx, "remoteserver.hcloud.CreateSnapshot")
+	defer span.End()
+
+	server, err := p.GetServer(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	result, _, err := p.Client.Server.CreateImage(ctx, server, &hcloud.ServerCreateImageOpts{
+		Type:        hcloud.ImageTypeSnapshot,
+		Description: hcloud.Ptr(description),
+		Labels:      labels,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "can not create snapshot")
+	}
+
+	// server is locked while snapshot is creating
+	if err := p.Client.Action.WaitFor(ctx, result.Action); err != nil {
+		return "", errors.Wrap(err, "error waiting snapshot")
+	}
+
+	return strconv.Itoa(result.Image.ID), nil
+}
+
+func (p *Provider) DeleteServer(ctx context.Context, id string) error {
+	ctx, span := telemetry.Start(ctx, "remoteserver.hcloud.DeleteServer")
+	defer span.End()
+
+	server, err := p.GetServer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := p.Client.Server.DeleteWithResult(ctx, server); err != nil {
+		return errors.Wrap(err, "can not delete server")
+	}
+
+	return nil
+}
+
+func (p *Provider) GetCPUUsage(ctx context.Context, id string, since time.Time) (float64, error) {
+	ctx, span := telemetry.Start(ctx, "remoteserver.hcloud.GetCPUUsage")
+	defer span.End()
+
+	server, err := p.GetServer(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	metrics, _, err := p.Client.Server.GetMetrics(ctx, server, hcloud.ServerGetMetricsOpts{
+		Types: []hcloud.ServerMetricType{hcloud.ServerMetricCPU},
+		Start: since,
+		End:   time.Now(),
+		Step:  metricsStepSeconds,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "can not get metrics")
+	}
+
+	values := metrics.TimeSeries[string(hcloud.ServerMetricCPU)]
+	if len(values) == 0 {
+		return 0, errors.New("no cpu metrics")
+	}
+
+	total := 0.0
+
+	for _, value := range values {
+		cpu, err := strconv.ParseFloat(value.Value, 64)
+		if err != nil {
+			return 0, errors.Wrap(err, "can not parse cpu metric")
+		}
+
+		total += cpu
+	}
+
+	return total / float64(len(values)), nil
+}
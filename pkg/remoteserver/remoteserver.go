@@ -0,0 +1,128 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package remoteserver
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// returned (wrapped) when server does not exist.
+var ErrNotFound = errors.New("server not found")
+
+type ProviderType string
+
+const (
+	ProviderHcloud ProviderType = "hcloud"
+	ProviderAWS    ProviderType = "aws"
+	ProviderAzure  ProviderType = "azure"
+)
+
+// stopped servers in Hetzner Cloud are billed, stopped EC2 instances and deallocated
+// Azure virtual machines are billed only for disks.
+func (t ProviderType) IsBilledWhenStopped() bool {
+	return t != ProviderAWS && t != ProviderAzure
+}
+
+type ServerStatus string
+
+const (
+	ServerStatusRunning ServerStatus = "Running"
+	ServerStatusStopped ServerStatus = "Stoped"
+)
+
+type Server struct {
+	ID     string
+	Name   string
+	Status ServerStatus
+	IPv4   string
+	// type of server in provider, for example cx22 or t3.medium
+	ServerType string
+	Created    time.Time
+	Labels     map[string]string
+}
+
+// cloud provider of remote servers.
+type Provider interface {
+	ListServers(ctx context.Context) ([]*Server, error)
+	PowerOn(ctx context.Context, id string) error
+	PowerOff(ctx context.Context, id string) error
+	Reboot(ctx context.Context, id string) error
+	// add labels to server, existing labels with other keys are not changed
+	SetLabels(ctx context.Context, id string, labels map[string]string) error
+}
+
+type CreateServerInput struct {
+	Name       string
+	Image      string
+	ServerType string
+	Location   string
+	// names of SSH keys in provider
+	SSHKeys []string
+	// cloud-init user data
+	UserData string
+	Labels   map[string]string
+}
+
+// provider that can create, snapshot and delete servers.
+type LifecycleProvider interface {
+	Provider
+	CreateServer(ctx context.Context, input *CreateServerInput) (*Server, error)
+	// create snapshot of server disk and wait until snapshot is created, returns snapshot ID
+	CreateSnapshot(ctx context.Context, id, description string, labels map[string]string) (string, error)
+	DeleteServer(ctx context.Context, id string) error
+}
+
+// provider that can return metrics of servers.
+type MetricsProvider interface {
+	Provider
+	// average CPU usage of server in percent since time
+	GetCPUUsage(ctx context.Context, id string, since time.Time) (float64, error)
+}
+
+// label value that is valid in all providers, invalid characters are replaced with "_".
+func LabelValue(value string) string {
+	const maxLabelValueLength = 63
+
+	result := []rune(value)
+
+	for i, r := range result {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.' || r > unicode.MaxASCII {
+			result[i] = '_'
+		}
+	}
+
+	if len(result) > maxLabelValueLength {
+		result = result[:maxLabelValueLength]
+	}
+
+	return string(result)
+}
+
+// convert provider config from config file to provider specific struct.
+func DecodeConfig(config any, result any) error {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "invalid provider config")
+	}
+
+	if err := json.Unmarshal(configBytes, result); err != nil {
+		return errors.Wrap(err, "invalid provider config")
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package remoteserver_test
+
+import (
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/remoteserver"
+)
+
+func TestLabelValue(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"user":                 "user",
+		"user.name@domain.com": "user.name_domain.com",
+		"имя":                  "___",
+		"a b/c":                "a_b_c",
+	}
+
+	for value, want := range tests {
+		if got := remoteserver.LabelValue(value); got != want {
+			t.Errorf("LabelValue(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestIsBilledWhenStopped(t *testing.T) {
+	t.Parallel()
+
+	tests := map[remoteserver.ProviderType]bool{
+		remoteserver.ProviderHcloud: true,
+		remoteserver.ProviderAWS:    false,
+		remoteserver.ProviderAzure:  false,
+	}
+
+	for providerType, want := range tests {
+		if got := providerType.IsBilledWhenStopped(); got != want {
+			t.Errorf("%s IsBilledWhenStopped() = %t, want %t", providerType, got, want)
+		}
+	}
+}
@@ -0,0 +1,781 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	listPerPage      = 100
+	runSearchPerPage = 20
+	runNameIDLength  = 8
+	apiVersion       = "2022-11-28"
+	// workflow input that contains all pipeline variables in JSON
+	VariablesInput = "variables"
+	// workflow input with unique run name, workflow must use it in run-name
+	RunNameInput = "run_name"
+)
+
+var errRunNotFound = errors.New("workflow run not found")
+
+func NewProvider(endpoint string) *Provider {
+	return &Provider{
+		Endpoint:        strings.TrimRight(endpoint, "/"),
+		HTTPClient:      http.DefaultClient,
+		RunWaitAttempts: 5,           //nolint:mnd
+		RunWaitInterval: time.Second, //nolint:mnd
+	}
+}
+
+type Provider struct {
+	Endpoint string
+	Token    string
+	// organization or user that owns repositories
+	Owner string
+	// workflow file name or ID that is used to create pipelines
+	Workflow   string
+	HTTPClient *http.Client
+	// workflow dispatch does not return run, provider waits for run to appear
+	RunWaitAttempts int
+	RunWaitInterval time.Duration
+	// variables that are added to run name and can be read with GetPipelineVariables
+	RunNameVariables []string
+}
+
+type repository struct {
+	ID            int64    `json:"id"`
+	FullName      string   `json:"full_name"`
+	Description   string   `json:"description"`
+	DefaultBranch string   `json:"default_branch"`
+	HTMLURL       string   `json:"html_url"`
+	Topics        []string `json:"topics"`
+}
+
+type ref struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+type commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Committer struct {
+			Date *time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+type workflowRun struct {
+	ID           int64      `json:"id"`
+	DisplayTitle string     `json:"display_title"`
+	HeadBranch   string     `json:"head_branch"`
+	HeadSHA      string     `json:"head_sha"`
+	Status       string     `json:"status"`
+	Conclusion   string     `json:"conclusion"`
+	HTMLURL      string     `json:"html_url"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+type workflowRuns struct {
+	WorkflowRuns []*workflowRun `json:"workflow_runs"`
+}
+
+type artifact struct {
+	Name               string `json:"name"`
+	Expired            bool   `json:"expired"`
+	ArchiveDownloadURL string `json:"archive_download_url"`
+}
+
+type job struct {
+	ID          int64      `json:"id"`
+	RunID       int64      `json:"run_id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	HTMLURL     string     `json:"html_url"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+func convertStatus(status, conclusion string) scm.PipelineStatus {
+	switch status {
+	case "in_progress":
+		return scm.PipelineStatusRunning
+	case "waiting", "action_required":
+		return scm.PipelineStatusManual
+	case "completed":
+		switch conclusion {
+		case "success":
+			return scm.PipelineStatusSuccess
+		case "cancelled":
+			return scm.PipelineStatusCanceled
+		case "skipped", "neutral":
+			return scm.PipelineStatusSkipped
+		case "action_required":
+			return scm.PipelineStatusManual
+		default:
+			return scm.PipelineStatusFailed
+		}
+	default:
+		return scm.PipelineStatusPending
+	}
+}
+
+func (r *workflowRun) toPipeline() *scm.Pipeline {
+	return &scm.Pipeline{
+		ID:      strconv.FormatInt(r.ID, 10),
+		Ref:     r.HeadBranch,
+		SHA:     r.HeadSHA,
+		Status:  convertStatus(r.Status, r.Conclusion),
+		WebURL:  r.HTMLURL,
+		Created: r.CreatedAt,
+		Updated: r.UpdatedAt,
+	}
+}
+
+// returns API path of repository, project ID can be repository ID, full name or name of repository in owner.
+func (p *Provider) repoPath(projectID string) string {
+	if _, err := strconv.ParseInt(projectID, 10, 64); err == nil {
+		return "/repositories/" + projectID
+	}
+
+	if strings.Contains(projectID, "/") {
+		return "/repos/" + projectID
+	}
+
+	return "/repos/" + p.Owner + "/" + projectID
+}
+
+func (p *Provider) do(ctx context.Context, method, path string, query url.Values, body, result any) error {
+	reqURL := p.Endpoint + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	respBody, err := p.send(ctx, method, reqURL, body)
+	if err != nil {
+		return errors.Wrapf(err, "%s %s", method, path)
+	}
+
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return errors.Wrap(err, "error decoding response")
+	}
+
+	return nil
+}
+
+// returns body of response, scm.ErrNotFound is returned if resource does not exist.
+func (p *Provider) send(ctx context.Context, method, reqURL string, body any) ([]byte, error) {
+	var reqBody io.Reader
+
+	if body != nil {
+		bodyJSON, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "error encoding request")
+		}
+
+		reqBody = bytes.NewReader(bodyJSON)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating request")
+	}
+
+	req.Header.Add("Accept", "application/vnd.github+json")
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-GitHub-Api-Version", apiVersion)
+
+	if len(p.Token) > 0 {
+		req.Header.Add("Authorization", "Bearer "+p.Token)
+	}
+
+	log.Debug("request: " + req.URL.String())
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error sending request")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading response")
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, scm.ErrNotFound
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, errors.Errorf("error response code: %s, %s", resp.Status, string(respBody))
+	}
+
+	return respBody, nil
+}
+
+func (r *repository) toProject() *scm.Project {
+	return &scm.Project{
+		ID:            r.ID,
+		Name:          r.FullName,
+		Path:          r.FullName,
+		Description:   r.Description,
+		DefaultBranch: r.DefaultBranch,
+		WebURL:        r.HTMLURL,
+		Topics:        r.Topics,
+	}
+}
+
+func (p *Provider) GetProject(ctx context.Context, projectID string) (*scm.Project, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.GetProject")
+	defer span.End()
+
+	repo := repository{}
+
+	if err := p.do(ctx, http.MethodGet, p.repoPath(projectID), nil, nil, &repo); err != nil {
+		return nil, errors.Wrap(err, "can not get repository")
+	}
+
+	return repo.toProject(), nil
+}
+
+func (p *Provider) ListProjectsByTopic(ctx context.Context, topic string) ([]*scm.Project, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.ListProjectsByTopic")
+	defer span.End()
+
+	searchQuery := "topic:" + topic
+	if len(p.Owner) > 0 {
+		searchQuery += " user:" + p.Owner
+	}
+
+	result := make([]*scm.Project, 0)
+
+	for page := 1; ctx.Err() == nil; page++ {
+		search := struct {
+			TotalCount int           `json:"total_count"`
+			Items      []*repository `json:"items"`
+		}{}
+
+		query := url.Values{
+			"q":        []string{searchQuery},
+			"per_page": []string{strconv.Itoa(listPerPage)},
+			"page":     []string{strconv.Itoa(page)},
+		}
+
+		if err := p.do(ctx, http.MethodGet, "/search/repositories", query, nil, &search); err != nil {
+			return nil, errors.Wrap(err, "can not search repositories")
+		}
+
+		for _, repo := range search.Items {
+			result = append(result, repo.toProject())
+		}
+
+		if len(search.Items) == 0 || len(result) >= search.TotalCount {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+func (p *Provider) listRefs(ctx context.Context, path string, limit int) ([]*scm.Ref, error) {
+	result := make([]*scm.Ref, 0)
+
+	for page := 1; ctx.Err() == nil; page++ {
+		refs := make([]*ref, 0)
+
+		query := url.Values{
+			"per_page": []string{strconv.Itoa(listPerPage)},
+			"page":     []string{strconv.Itoa(page)},
+		}
+
+		if err := p.do(ctx, http.MethodGet, path, query, nil, &refs); err != nil {
+			return nil, err
+		}
+
+		for _, item := range refs {
+			// GitHub does not return commit date in list of refs
+			result = append(result, &scm.Ref{
+				Name:      item.Name,
+				CommitSHA: item.Commit.SHA,
+			})
+
+			if limit > 0 && len(result) >= limit {
+				return result, nil
+			}
+		}
+
+		if len(refs) < listPerPage {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// returns commit of ref (branch, tag or SHA).
+func (p *Provider) getCommit(ctx context.Context, projectID, name, ref string) (*scm.Ref, error) {
+	result := commit{}
+
+	if err := p.do(ctx, http.MethodGet, p.repoPath(projectID)+"/commits/"+ref, nil, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &scm.Ref{
+		Name:      name,
+		CommitSHA: result.SHA,
+		Updated:   result.Commit.Committer.Date,
+	}, nil
+}
+
+func (p *Provider) GetBranch(ctx context.Context, projectID, branch string) (*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.GetBranch")
+	defer span.End()
+
+	result, err := p.getCommit(ctx, projectID, branch, "refs/heads/"+url.PathEscape(branch))
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get branch "+branch)
+	}
+
+	return result, nil
+}
+
+func (p *Provider) CreateBranch(ctx context.Context, projectID, branch, sha string) (*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.CreateBranch")
+	defer span.End()
+
+	body := map[string]string{
+		"ref": "refs/heads/" + branch,
+		"sha": sha,
+	}
+
+	if err := p.do(ctx, http.MethodPost, p.repoPath(projectID)+"/git/refs", nil, body, nil); err != nil {
+		return nil, errors.Wrap(err, "can not create branch "+branch)
+	}
+
+	return &scm.Ref{
+		Name:      branch,
+		CommitSHA: sha,
+	}, nil
+}
+
+func (p *Provider) DeleteBranch(ctx context.Context, projectID, branch string) error {
+	ctx, span := telemetry.Start(ctx, "scm.github.DeleteBranch")
+	defer span.End()
+
+	path := p.repoPath(projectID) + "/git/refs/heads/" + url.PathEscape(branch)
+
+	if err := p.do(ctx, http.MethodDelete, path, nil, nil, nil); err != nil {
+		return errors.Wrap(err, "can not delete branch "+branch)
+	}
+
+	return nil
+}
+
+func (p *Provider) GetTag(ctx context.Context, projectID, tag string) (*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.GetTag")
+	defer span.End()
+
+	result, err := p.getCommit(ctx, projectID, tag, "refs/tags/"+url.PathEscape(tag))
+	if err != nil {
+		return nil, errors.Wrap(err, "can not get tag "+tag)
+	}
+
+	return result, nil
+}
+
+func (p *Provider) ListBranches(ctx context.Context, projectID string) ([]*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.ListBranches")
+	defer span.End()
+
+	result, err := p.listRefs(ctx, p.repoPath(projectID)+"/branches", 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list branches")
+	}
+
+	return result, nil
+}
+
+func (p *Provider) ListTags(ctx context.Context, projectID string, limit int64) ([]*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.ListTags")
+	defer span.End()
+
+	result, err := p.listRefs(ctx, p.repoPath(projectID)+"/tags", int(limit))
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list tags")
+	}
+
+	return result, nil
+}
+
+func (p *Provider) Compare(ctx context.Context, projectID, from, to string) (*scm.CompareResult, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.Compare")
+	defer span.End()
+
+	compare := struct {
+		AheadBy int    `json:"ahead_by"`
+		HTMLURL string `json:"html_url"`
+	}{}
+
+	path := fmt.Sprintf("%s/compare/%s...%s", p.repoPath(projectID), url.PathEscape(from), url.PathEscape(to))
+
+	if err := p.do(ctx, http.MethodGet, path, nil, nil, &compare); err != nil {
+		return nil, errors.Wrap(err, "can not compare refs")
+	}
+
+	return &scm.CompareResult{
+		Commits: compare.AheadBy,
+		WebURL:  compare.HTMLURL,
+	}, nil
+}
+
+// dispatch workflow with all variables in one JSON input and unique run name,
+// workflow must declare these inputs and use run name input in run-name.
+func (p *Provider) TriggerPipeline(ctx context.Context, input *scm.TriggerPipelineInput) (*scm.Pipeline, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.TriggerPipeline")
+	defer span.End()
+
+	variables := make(map[string]string, len(input.Variables))
+
+	for _, variable := range input.Variables {
+		variables[variable.Key] = variable.Value
+	}
+
+	variablesJSON, err := json.Marshal(variables)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding variables")
+	}
+
+	runName, err := p.newRunName(variables)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating run name")
+	}
+
+	body := map[string]any{
+		"ref": input.Ref,
+		"inputs": map[string]string{
+			VariablesInput: string(variablesJSON),
+			RunNameInput:   runName,
+		},
+	}
+
+	path := p.repoPath(input.ProjectID) + "/actions/workflows/" + url.PathEscape(p.Workflow) + "/dispatches"
+
+	if err := p.do(ctx, http.MethodPost, path, nil, body, nil); err != nil {
+		return nil, errors.Wrap(err, "can not dispatch workflow")
+	}
+
+	for attempt := range p.RunWaitAttempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(p.RunWaitInterval):
+			}
+		}
+
+		runs, err := p.listWorkflowRuns(ctx, input.ProjectID, url.Values{
+			"branch":   []string{input.Ref},
+			"event":    []string{"workflow_dispatch"},
+			"per_page": []string{strconv.Itoa(runSearchPerPage)},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "can not list workflow runs")
+		}
+
+		// parallel dispatches on same branch are distinguished by unique run name
+		for _, run := range runs {
+			if run.DisplayTitle == runName {
+				return run.toPipeline(), nil
+			}
+		}
+	}
+
+	return nil, errors.Wrapf(errRunNotFound, "workflow %s dispatched on %s with run name %s", p.Workflow, input.Ref, runName)
+}
+
+// returns unique run name with variables that must be available in GetPipelineVariables.
+func (p *Provider) newRunName(variables map[string]string) (string, error) {
+	id := make([]byte, runNameIDLength)
+
+	if _, err := rand.Read(id); err != nil {
+		return "", errors.Wrap(err, "error generating id")
+	}
+
+	runVariables := url.Values{}
+
+	for _, key := range p.RunNameVariables {
+		if value, ok := variables[key]; ok {
+			runVariables.Set(key, value)
+		}
+	}
+
+	runName := hex.EncodeToString(id)
+
+	if len(runVariables) > 0 {
+		runName += " " + runVariables.Encode()
+	}
+
+	return runName, nil
+}
+
+func (p *Provider) listWorkflowRuns(ctx context.Context, projectID string, query url.Values) ([]*workflowRun, error) {
+	runs := workflowRuns{}
+
+	path := p.repoPath(projectID) + "/actions/workflows/" + url.PathEscape(p.Workflow) + "/runs"
+
+	if err := p.do(ctx, http.MethodGet, path, query, nil, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs.WorkflowRuns, nil
+}
+
+func (p *Provider) ListPipelines(ctx context.Context, input *scm.ListPipelinesInput) ([]*scm.Pipeline, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.ListPipelines")
+	defer span.End()
+
+	query := url.Values{}
+
+	if input.Limit > 0 {
+		query.Set("per_page", strconv.FormatInt(input.Limit, 10))
+	}
+
+	if len(input.Ref) > 0 {
+		query.Set("branch", input.Ref)
+	}
+
+	if input.Triggered {
+		query.Set("event", "workflow_dispatch")
+	}
+
+	runs, err := p.listWorkflowRuns(ctx, input.ProjectID, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list workflow runs")
+	}
+
+	result := make([]*scm.Pipeline, 0, len(runs))
+
+	for _, run := range runs {
+		result = append(result, run.toPipeline())
+	}
+
+	return result, nil
+}
+
+func (p *Provider) GetPipeline(ctx context.Context, projectID, pipelineID string) (*scm.Pipeline, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.GetPipeline")
+	defer span.End()
+
+	run := workflowRun{}
+
+	path := p.repoPath(projectID) + "/actions/runs/" + url.PathEscape(pipelineID)
+
+	if err := p.do(ctx, http.MethodGet, path, nil, nil, &run); err != nil {
+		return nil, errors.Wrap(err, "can not get workflow run")
+	}
+
+	return run.toPipeline(), nil
+}
+
+// variables are parsed from run name, only variables from RunNameVariables are returned.
+func (p *Provider) GetPipelineVariables(ctx context.Context, projectID, pipelineID string) (map[string]string, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.GetPipelineVariables")
+	defer span.End()
+
+	run := workflowRun{}
+
+	path := p.repoPath(projectID) + "/actions/runs/" + url.PathEscape(pipelineID)
+
+	if err := p.do(ctx, http.MethodGet, path, nil, nil, &run); err != nil {
+		return nil, errors.Wrap(err, "can not get workflow run")
+	}
+
+	result := make(map[string]string)
+
+	_, runVariables, ok := strings.Cut(run.DisplayTitle, " ")
+	if !ok {
+		return result, nil
+	}
+
+	values, err := url.ParseQuery(runVariables)
+	if err != nil {
+		// run was not created by TriggerPipeline
+		return result, nil //nolint:nilerr
+	}
+
+	for key := range values {
+		result[key] = values.Get(key)
+	}
+
+	return result, nil
+}
+
+func (p *Provider) CancelPipeline(ctx context.Context, projectID, pipelineID string) error {
+	ctx, span := telemetry.Start(ctx, "scm.github.CancelPipeline")
+	defer span.End()
+
+	path := p.repoPath(projectID) + "/actions/runs/" + url.PathEscape(pipelineID) + "/cancel"
+
+	if err := p.do(ctx, http.MethodPost, path, nil, nil, nil); err != nil {
+		return errors.Wrap(err, "can not cancel workflow run")
+	}
+
+	return nil
+}
+
+func (p *Provider) ListJobs(ctx context.Context, projectID, pipelineID string) ([]*scm.Job, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.ListJobs")
+	defer span.End()
+
+	jobs := struct {
+		Jobs []*job `json:"jobs"`
+	}{}
+
+	path := p.repoPath(projectID) + "/actions/runs/" + url.PathEscape(pipelineID) + "/jobs"
+	query := url.Values{"per_page": []string{strconv.Itoa(listPerPage)}}
+
+	if err := p.do(ctx, http.MethodGet, path, query, nil, &jobs); err != nil {
+		return nil, errors.Wrap(err, "can not list jobs")
+	}
+
+	result := make([]*scm.Job, 0, len(jobs.Jobs))
+
+	for _, item := range jobs.Jobs {
+		result = append(result, &scm.Job{
+			ID:       strconv.FormatInt(item.ID, 10),
+			Name:     item.Name,
+			Status:   convertStatus(item.Status, item.Conclusion),
+			WebURL:   item.HTMLURL,
+			Started:  item.StartedAt,
+			Finished: item.CompletedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// GitHub Actions does not have manual jobs, job will be re-run.
+func (p *Provider) PlayJob(ctx context.Context, projectID, jobID string) error {
+	ctx, span := telemetry.Start(ctx, "scm.github.PlayJob")
+	defer span.End()
+
+	path := p.repoPath(projectID) + "/actions/jobs/" + url.PathEscape(jobID) + "/rerun"
+
+	if err := p.do(ctx, http.MethodPost, path, nil, nil, nil); err != nil {
+		return errors.Wrap(err, "can not re-run job")
+	}
+
+	return nil
+}
+
+// GitHub Actions stores artifacts per workflow run, file is searched in all artifacts of job run.
+func (p *Provider) GetJobArtifact(ctx context.Context, projectID, jobID, path string) ([]byte, error) {
+	ctx, span := telemetry.Start(ctx, "scm.github.GetJobArtifact")
+	defer span.End()
+
+	runJob := job{}
+
+	if err := p.do(ctx, http.MethodGet, p.repoPath(projectID)+"/actions/jobs/"+url.PathEscape(jobID), nil, nil, &runJob); err != nil {
+		return nil, errors.Wrap(err, "can not get job")
+	}
+
+	artifacts := struct {
+		Artifacts []*artifact `json:"artifacts"`
+	}{}
+
+	artifactsPath := p.repoPath(projectID) + "/actions/runs/" + strconv.FormatInt(runJob.RunID, 10) + "/artifacts"
+
+	if err := p.do(ctx, http.MethodGet, artifactsPath, nil, nil, &artifacts); err != nil {
+		return nil, errors.Wrap(err, "can not list artifacts")
+	}
+
+	for _, item := range artifacts.Artifacts {
+		if item.Expired {
+			continue
+		}
+
+		archive, err := p.send(ctx, http.MethodGet, item.ArchiveDownloadURL, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "can not download artifact "+item.Name)
+		}
+
+		file, err := readZipFile(archive, path)
+		if errors.Is(err, scm.ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "can not read artifact "+item.Name)
+		}
+
+		return file, nil
+	}
+
+	return nil, errors.Wrap(scm.ErrNotFound, path)
+}
+
+// artifact archive contains files relative to uploaded directory, file is matched by path or file name.
+func readZipFile(archive []byte, path string) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, errors.Wrap(err, "can not open archive")
+	}
+
+	for _, file := range reader.File {
+		if file.Name != path && file.Name != filepath.Base(path) {
+			continue
+		}
+
+		content, err := file.Open()
+		if err != nil {
+			return nil, errors.Wrap(err, "can not open file")
+		}
+		defer content.Close()
+
+		result, err := io.ReadAll(content)
+		if err != nil {
+			return nil, errors.Wrap(err, "can not read file")
+		}
+
+		return result, nil
+	}
+
+	return nil, scm.ErrNotFound
+}
@@ -0,0 +1,351 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github_test
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm/github"
+)
+
+const testToken = "test-token"
+
+func newTestProvider(t *testing.T) *github.Provider {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	runName := atomic.Value{}
+	runName.Store("")
+
+	mux.HandleFunc("GET /search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != "topic:test-topic user:test-owner" {
+			t.Errorf("unexpected query %s", q)
+		}
+
+		_, _ = w.Write([]byte(`{"total_count":1,"items":[{"id":1,"full_name":"test-owner/repo","default_branch":"main","topics":["test-topic"]}]}`))
+	})
+
+	mux.HandleFunc("GET /repositories/1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":1,"full_name":"test-owner/repo","default_branch":"main","html_url":"http://repo"}`))
+	})
+
+	mux.HandleFunc("GET /repositories/1/commits/refs/heads/main", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"sha":"sha1","commit":{"committer":{"date":"2024-01-01T00:00:00Z"}}}`))
+	})
+
+	mux.HandleFunc("GET /repositories/1/commits/refs/tags/v1.0.0", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"sha":"sha2","commit":{"committer":{"date":"2024-01-01T00:00:00Z"}}}`))
+	})
+
+	mux.HandleFunc("POST /repositories/1/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+
+		if body["ref"] != "refs/heads/fork" || body["sha"] != "sha2" {
+			t.Errorf("unexpected ref %+v", body)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	mux.HandleFunc("DELETE /repositories/1/git/refs/heads/fork", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /repositories/1/branches", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"name":"main","commit":{"sha":"sha1"}}]`))
+	})
+
+	mux.HandleFunc("GET /repos/test-owner/repo/tags", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"name":"v1.0.1","commit":{"sha":"sha3"}},{"name":"v1.0.0","commit":{"sha":"sha2"}}]`))
+	})
+
+	mux.HandleFunc("GET /repositories/1/compare/{basehead}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("basehead") != "sha1...sha2" {
+			t.Errorf("unexpected compare %s", r.PathValue("basehead"))
+		}
+
+		_, _ = w.Write([]byte(`{"ahead_by":3,"html_url":"http://compare"}`))
+	})
+
+	mux.HandleFunc("POST /repositories/1/actions/workflows/deploy.yml/dispatches", func(w http.ResponseWriter, r *http.Request) {
+		body := struct {
+			Ref    string            `json:"ref"`
+			Inputs map[string]string `json:"inputs"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+
+		variables := make(map[string]string)
+
+		if err := json.Unmarshal([]byte(body.Inputs[github.VariablesInput]), &variables); err != nil {
+			t.Error(err)
+		}
+
+		if body.Ref != "main" || variables["KEY"] != "value" {
+			t.Errorf("unexpected dispatch %+v", body)
+		}
+
+		if !strings.HasSuffix(body.Inputs[github.RunNameInput], " NAMESPACE=test") {
+			t.Errorf("unexpected run name %s", body.Inputs[github.RunNameInput])
+		}
+
+		runName.Store(body.Inputs[github.RunNameInput])
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /repositories/1/actions/workflows/deploy.yml/runs", func(w http.ResponseWriter, _ *http.Request) {
+		// run of parallel dispatch on same branch must be ignored
+		_, _ = fmt.Fprintf(w, `{"workflow_runs":[
+			{"id":11,"display_title":"other","head_branch":"main","status":"queued"},
+			{"id":10,"display_title":%q,"head_branch":"main","status":"queued","html_url":"http://run/10"},
+			{"id":9,"head_branch":"main","status":"completed","conclusion":"failure"}
+		]}`, runName.Load())
+	})
+
+	mux.HandleFunc("GET /repositories/1/actions/runs/10", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"id":10,"display_title":%q,"head_branch":"main","status":"in_progress","html_url":"http://run/10"}`, runName.Load())
+	})
+
+	mux.HandleFunc("POST /repositories/1/actions/runs/10/cancel", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	mux.HandleFunc("GET /repositories/1/actions/runs/10/jobs", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"jobs":[{"id":20,"name":"test","status":"completed","conclusion":"cancelled"}]}`))
+	})
+
+	mux.HandleFunc("POST /repositories/1/actions/jobs/20/rerun", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	var ts *httptest.Server
+
+	mux.HandleFunc("GET /repositories/1/actions/jobs/20", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":20,"run_id":10}`))
+	})
+
+	mux.HandleFunc("GET /repositories/1/actions/runs/10/artifacts", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"artifacts":[
+			{"name":"old","expired":true,"archive_download_url":"%[1]s/unknown"},
+			{"name":"report","archive_download_url":"%[1]s/artifacts/1/zip"}
+		]}`, ts.URL)
+	})
+
+	mux.HandleFunc("GET /artifacts/1/zip", func(w http.ResponseWriter, _ *http.Request) {
+		archive := zip.NewWriter(w)
+
+		file, err := archive.Create("junit.xml")
+		if err != nil {
+			t.Error(err)
+		}
+
+		_, _ = file.Write([]byte(`<testsuite/>`))
+		_ = archive.Close()
+	})
+
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	provider := github.NewProvider(ts.URL + "/")
+	provider.Token = testToken
+	provider.Owner = "test-owner"
+	provider.Workflow = "deploy.yml"
+	provider.RunWaitInterval = 0
+	provider.RunNameVariables = []string{"NAMESPACE"}
+
+	return provider
+}
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	provider := newTestProvider(t)
+
+	projects, err := provider.ListProjectsByTopic(ctx, "test-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(projects) != 1 || projects[0].ID != 1 || projects[0].Name != "test-owner/repo" {
+		t.Fatalf("unexpected projects %+v", projects)
+	}
+
+	project, err := provider.GetProject(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if project.Path != "test-owner/repo" || project.WebURL != "http://repo" {
+		t.Fatalf("unexpected project %+v", project)
+	}
+
+	branch, err := provider.GetBranch(ctx, "1", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if branch.CommitSHA != "sha1" || branch.Updated == nil {
+		t.Fatalf("unexpected branch %+v", branch)
+	}
+
+	if _, err := provider.GetBranch(ctx, "1", "unknown"); !errors.Is(err, scm.ErrNotFound) {
+		t.Fatalf("must be not found error, got %v", err)
+	}
+
+	tag, err := provider.GetTag(ctx, "1", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.CreateBranch(ctx, "1", "fork", tag.CommitSHA); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.DeleteBranch(ctx, "1", "fork"); err != nil {
+		t.Fatal(err)
+	}
+
+	branches, err := provider.ListBranches(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(branches) != 1 || branches[0].CommitSHA != "sha1" {
+		t.Fatalf("unexpected branches %+v", branches)
+	}
+
+	tags, err := provider.ListTags(ctx, "repo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 1 || tags[0].Name != "v1.0.1" {
+		t.Fatalf("unexpected tags %+v", tags)
+	}
+
+	compare, err := provider.Compare(ctx, "1", "sha1", "sha2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compare.Commits != 3 {
+		t.Fatalf("unexpected compare %+v", compare)
+	}
+
+	pipeline, err := provider.TriggerPipeline(ctx, &scm.TriggerPipelineInput{
+		ProjectID: "1",
+		Ref:       "main",
+		Variables: []*scm.Variable{{Key: "KEY", Value: "value"}, {Key: "NAMESPACE", Value: "test"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pipeline.ID != "10" || pipeline.Status != scm.PipelineStatusPending || pipeline.WebURL != "http://run/10" {
+		t.Fatalf("unexpected pipeline %+v", pipeline)
+	}
+
+	variables, err := provider.GetPipelineVariables(ctx, "1", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if variables["NAMESPACE"] != "test" {
+		t.Fatalf("unexpected variables %+v", variables)
+	}
+
+	pipelines, err := provider.ListPipelines(ctx, &scm.ListPipelinesInput{ProjectID: "1", Ref: "main", Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pipelines) != 3 || pipelines[2].Status != scm.PipelineStatusFailed || !pipelines[2].Status.IsFinished() {
+		t.Fatalf("unexpected pipelines %+v", pipelines)
+	}
+
+	pipeline, err = provider.GetPipeline(ctx, "1", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pipeline.Ref != "main" || pipeline.Status != scm.PipelineStatusRunning {
+		t.Fatalf("unexpected pipeline %+v", pipeline)
+	}
+
+	if err := provider.CancelPipeline(ctx, "1", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := provider.ListJobs(ctx, "1", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(jobs) != 1 || jobs[0].ID != "20" || jobs[0].Status != scm.PipelineStatusCanceled {
+		t.Fatalf("unexpected jobs %+v", jobs)
+	}
+
+	if err := provider.PlayJob(ctx, "1", "20"); err != nil {
+		t.Fatal(err)
+	}
+
+	artifact, err := provider.GetJobArtifact(ctx, "1", "20", "report/junit.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(artifact) != "<testsuite/>" {
+		t.Fatalf("unexpected artifact %s", artifact)
+	}
+
+	if _, err := provider.GetJobArtifact(ctx, "1", "20", "unknown.xml"); !errors.Is(err, scm.ErrNotFound) {
+		t.Fatalf("must be not found error, got %v", err)
+	}
+}
+
+func TestProviderErrors(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider(t)
+	provider.Token = "wrong-token"
+
+	_, err := provider.ListBranches(t.Context(), "1")
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("must be unauthorized error, got %v", err)
+	}
+}
@@ -0,0 +1,520 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gitlab
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const listPerPage = 100
+
+var errNoClient = errors.New("no gitlab client")
+
+type Provider struct {
+	client *gitlab.Client
+	// user of token, used to filter triggered pipelines
+	TriggerUser string
+}
+
+func NewProvider(client *gitlab.Client) (*Provider, error) {
+	if client == nil {
+		return nil, errNoClient
+	}
+
+	return &Provider{client: client}, nil
+}
+
+func convertStatus(status string) scm.PipelineStatus {
+	switch status {
+	case "running":
+		return scm.PipelineStatusRunning
+	case "success":
+		return scm.PipelineStatusSuccess
+	case "failed":
+		return scm.PipelineStatusFailed
+	case "canceled", "canceling":
+		return scm.PipelineStatusCanceled
+	case "skipped":
+		return scm.PipelineStatusSkipped
+	case "manual":
+		return scm.PipelineStatusManual
+	default:
+		return scm.PipelineStatusPending
+	}
+}
+
+// wrap error with scm.ErrNotFound if resource does not exist.
+func wrapError(resp *gitlab.Response, err error, message string) error {
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return errors.Wrap(scm.ErrNotFound, message)
+	}
+
+	return errors.Wrap(err, message)
+}
+
+func convertProject(project *gitlab.Project) *scm.Project {
+	return &scm.Project{
+		ID:            project.ID,
+		Name:          project.NameWithNamespace,
+		Path:          project.PathWithNamespace,
+		Description:   project.Description,
+		DefaultBranch: project.DefaultBranch,
+		WebURL:        project.WebURL,
+		Topics:        project.Topics,
+	}
+}
+
+func convertCommit(name string, commit *gitlab.Commit) *scm.Ref {
+	ref := scm.Ref{Name: name}
+
+	if commit != nil {
+		ref.CommitSHA = commit.ID
+		ref.Updated = commit.CommittedDate
+	}
+
+	return &ref
+}
+
+func convertPipeline(pipeline *gitlab.Pipeline) *scm.Pipeline {
+	return &scm.Pipeline{
+		ID:      strconv.FormatInt(pipeline.ID, 10),
+		Ref:     pipeline.Ref,
+		SHA:     pipeline.SHA,
+		Status:  convertStatus(pipeline.Status),
+		WebURL:  pipeline.WebURL,
+		Created: pipeline.CreatedAt,
+		Updated: pipeline.UpdatedAt,
+	}
+}
+
+func parseID(id string) (int64, error) {
+	result, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid id %s", id)
+	}
+
+	return result, nil
+}
+
+func (p *Provider) GetProject(ctx context.Context, projectID string) (*scm.Project, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.GetProject")
+	defer span.End()
+
+	project, resp, err := p.client.Projects.GetProject(projectID, &gitlab.GetProjectOptions{}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, wrapError(resp, err, "can not get project")
+	}
+
+	return convertProject(project), nil
+}
+
+func (p *Provider) ListProjectsByTopic(ctx context.Context, topic string) ([]*scm.Project, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.ListProjectsByTopic")
+	defer span.End()
+
+	result := make([]*scm.Project, 0)
+	pageNumber := int64(1)
+
+	for ctx.Err() == nil {
+		page, resp, err := p.client.Projects.ListProjects(
+			&gitlab.ListProjectsOptions{
+				Topic: gitlab.Ptr(topic),
+				ListOptions: gitlab.ListOptions{
+					Page: pageNumber,
+				},
+			},
+			gitlab.WithContext(ctx),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "can not list projects")
+		}
+
+		for _, project := range page {
+			result = append(result, convertProject(project))
+		}
+
+		if resp.CurrentPage >= resp.TotalPages {
+			break
+		}
+
+		pageNumber++
+	}
+
+	return result, nil
+}
+
+func (p *Provider) GetBranch(ctx context.Context, projectID, branch string) (*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.GetBranch")
+	defer span.End()
+
+	result, resp, err := p.client.Branches.GetBranch(projectID, branch, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, wrapError(resp, err, "can not get branch "+branch)
+	}
+
+	return convertCommit(result.Name, result.Commit), nil
+}
+
+func (p *Provider) CreateBranch(ctx context.Context, projectID, branch, sha string) (*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.CreateBranch")
+	defer span.End()
+
+	result, _, err := p.client.Branches.CreateBranch(
+		projectID,
+		&gitlab.CreateBranchOptions{
+			Branch: gitlab.Ptr(branch),
+			Ref:    gitlab.Ptr(sha),
+		},
+		gitlab.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not create branch "+branch)
+	}
+
+	return convertCommit(result.Name, result.Commit), nil
+}
+
+func (p *Provider) DeleteBranch(ctx context.Context, projectID, branch string) error {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.DeleteBranch")
+	defer span.End()
+
+	resp, err := p.client.Branches.DeleteBranch(projectID, branch, gitlab.WithContext(ctx))
+	if err != nil {
+		return wrapError(resp, err, "can not delete branch "+branch)
+	}
+
+	return nil
+}
+
+func (p *Provider) GetTag(ctx context.Context, projectID, tag string) (*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.GetTag")
+	defer span.End()
+
+	result, resp, err := p.client.Tags.GetTag(projectID, tag, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, wrapError(resp, err, "can not get tag "+tag)
+	}
+
+	return convertCommit(result.Name, result.Commit), nil
+}
+
+func (p *Provider) ListBranches(ctx context.Context, projectID string) ([]*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.ListBranches")
+	defer span.End()
+
+	result := make([]*scm.Ref, 0)
+	pageNumber := int64(0)
+
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		pageNumber++
+
+		branches, _, err := p.client.Branches.ListBranches(
+			projectID,
+			&gitlab.ListBranchesOptions{
+				ListOptions: gitlab.ListOptions{
+					Page:    pageNumber,
+					PerPage: listPerPage,
+				},
+			},
+			gitlab.WithContext(ctx),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "can not list branches")
+		}
+
+		if len(branches) == 0 {
+			break
+		}
+
+		for _, branch := range branches {
+			result = append(result, convertCommit(branch.Name, branch.Commit))
+		}
+	}
+
+	return result, nil
+}
+
+func (p *Provider) ListTags(ctx context.Context, projectID string, limit int64) ([]*scm.Ref, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.ListTags")
+	defer span.End()
+
+	tags, _, err := p.client.Tags.ListTags(
+		projectID,
+		&gitlab.ListTagsOptions{
+			ListOptions: gitlab.ListOptions{
+				PerPage: limit,
+			},
+			OrderBy: gitlab.Ptr("updated"),
+		},
+		gitlab.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list tags")
+	}
+
+	result := make([]*scm.Ref, 0, len(tags))
+
+	for _, tag := range tags {
+		result = append(result, convertCommit(tag.Name, tag.Commit))
+	}
+
+	return result, nil
+}
+
+func (p *Provider) Compare(ctx context.Context, projectID, from, to string) (*scm.CompareResult, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.Compare")
+	defer span.End()
+
+	compare, _, err := p.client.Repositories.Compare(
+		projectID,
+		&gitlab.CompareOptions{
+			From: &from,
+			To:   &to,
+		},
+		gitlab.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not compare refs")
+	}
+
+	return &scm.CompareResult{
+		Commits: len(compare.Commits),
+		WebURL:  compare.WebURL,
+	}, nil
+}
+
+func (p *Provider) TriggerPipeline(ctx context.Context, input *scm.TriggerPipelineInput) (*scm.Pipeline, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.TriggerPipeline")
+	defer span.End()
+
+	variables := make([]*gitlab.PipelineVariableOptions, 0, len(input.Variables))
+
+	for _, variable := range input.Variables {
+		variableType := gitlab.EnvVariableType
+		if variable.File {
+			variableType = gitlab.FileVariableType
+		}
+
+		variables = append(variables, &gitlab.PipelineVariableOptions{
+			Key:          gitlab.Ptr(variable.Key),
+			Value:        gitlab.Ptr(variable.Value),
+			VariableType: gitlab.Ptr(variableType),
+		})
+	}
+
+	pipeline, _, err := p.client.Pipelines.CreatePipeline(
+		input.ProjectID,
+		&gitlab.CreatePipelineOptions{
+			Ref:       &input.Ref,
+			Variables: &variables,
+		},
+		gitlab.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not create pipeline")
+	}
+
+	return convertPipeline(pipeline), nil
+}
+
+func (p *Provider) ListPipelines(ctx context.Context, input *scm.ListPipelinesInput) ([]*scm.Pipeline, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.ListPipelines")
+	defer span.End()
+
+	opts := gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: input.Limit,
+		},
+		OrderBy: gitlab.Ptr("id"),
+		Sort:    gitlab.Ptr("desc"),
+	}
+
+	if len(input.Ref) > 0 {
+		opts.Ref = gitlab.Ptr(input.Ref)
+	}
+
+	if input.Triggered {
+		opts.Source = gitlab.Ptr("api")
+
+		if len(p.TriggerUser) > 0 {
+			opts.Username = gitlab.Ptr(p.TriggerUser)
+		}
+	}
+
+	pipelines, _, err := p.client.Pipelines.ListProjectPipelines(input.ProjectID, &opts, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list pipelines")
+	}
+
+	result := make([]*scm.Pipeline, 0, len(pipelines))
+
+	for _, pipeline := range pipelines {
+		result = append(result, &scm.Pipeline{
+			ID:      strconv.FormatInt(pipeline.ID, 10),
+			Ref:     pipeline.Ref,
+			SHA:     pipeline.SHA,
+			Status:  convertStatus(pipeline.Status),
+			WebURL:  pipeline.WebURL,
+			Created: pipeline.CreatedAt,
+			Updated: pipeline.UpdatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (p *Provider) GetPipeline(ctx context.Context, projectID, pipelineID string) (*scm.Pipeline, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.GetPipeline")
+	defer span.End()
+
+	id, err := parseID(pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, resp, err := p.client.Pipelines.GetPipeline(projectID, id, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, wrapError(resp, err, "can not get pipeline")
+	}
+
+	return convertPipeline(pipeline), nil
+}
+
+func (p *Provider) GetPipelineVariables(ctx context.Context, projectID, pipelineID string) (map[string]string, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.GetPipelineVariables")
+	defer span.End()
+
+	id, err := parseID(pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, resp, err := p.client.Pipelines.GetPipelineVariables(projectID, id, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, wrapError(resp, err, "can not get pipeline variables")
+	}
+
+	result := make(map[string]string, len(variables))
+
+	for _, variable := range variables {
+		result[variable.Key] = variable.Value
+	}
+
+	return result, nil
+}
+
+func (p *Provider) CancelPipeline(ctx context.Context, projectID, pipelineID string) error {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.CancelPipeline")
+	defer span.End()
+
+	id, err := parseID(pipelineID)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := p.client.Pipelines.CancelPipelineBuild(projectID, id, gitlab.WithContext(ctx)); err != nil {
+		return errors.Wrap(err, "can not cancel pipeline")
+	}
+
+	return nil
+}
+
+func (p *Provider) ListJobs(ctx context.Context, projectID, pipelineID string) ([]*scm.Job, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.ListJobs")
+	defer span.End()
+
+	id, err := parseID(pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, _, err := p.client.Jobs.ListPipelineJobs(
+		projectID,
+		id,
+		&gitlab.ListJobsOptions{
+			ListOptions: gitlab.ListOptions{
+				PerPage: listPerPage,
+			},
+		},
+		gitlab.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not list jobs")
+	}
+
+	result := make([]*scm.Job, 0, len(jobs))
+
+	for _, job := range jobs {
+		result = append(result, &scm.Job{
+			ID:       strconv.FormatInt(job.ID, 10),
+			Name:     job.Name,
+			Stage:    job.Stage,
+			Status:   convertStatus(job.Status),
+			WebURL:   job.WebURL,
+			Started:  job.StartedAt,
+			Finished: job.FinishedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (p *Provider) PlayJob(ctx context.Context, projectID, jobID string) error {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.PlayJob")
+	defer span.End()
+
+	id, err := parseID(jobID)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := p.client.Jobs.PlayJob(projectID, id, &gitlab.PlayJobOptions{}, gitlab.WithContext(ctx)); err != nil {
+		return errors.Wrap(err, "can not play job")
+	}
+
+	return nil
+}
+
+func (p *Provider) GetJobArtifact(ctx context.Context, projectID, jobID, path string) ([]byte, error) {
+	ctx, span := telemetry.Start(ctx, "scm.gitlab.GetJobArtifact")
+	defer span.End()
+
+	id, err := parseID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact, resp, err := p.client.Jobs.DownloadSingleArtifactsFile(projectID, id, path, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, wrapError(resp, err, "can not download job artifact")
+	}
+
+	result, err := io.ReadAll(artifact)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not read job artifact")
+	}
+
+	return result, nil
+}
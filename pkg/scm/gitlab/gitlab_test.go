@@ -0,0 +1,325 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gitlab_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/scm"
+	scmgitlab "github.com/maksim-paskal/kubernetes-manager/pkg/scm/gitlab"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func newTestProvider(t *testing.T) *scmgitlab.Provider {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v4/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("topic") != "test-topic" {
+			t.Errorf("unexpected topic %s", r.URL.Query().Get("topic"))
+		}
+
+		_, _ = w.Write([]byte(`[{"id":1,"name_with_namespace":"group / project","default_branch":"main","topics":["test-topic"]}]`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":1,"path_with_namespace":"group/project","web_url":"http://project"}`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/repository/branches/main", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"main","commit":{"id":"sha1"}}`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/repository/branches/unknown", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"404 Branch Not Found"}`))
+	})
+
+	mux.HandleFunc("POST /api/v4/projects/1/repository/branches", func(w http.ResponseWriter, r *http.Request) {
+		body := gitlab.CreateBranchOptions{}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+
+		if *body.Branch != "fork" || *body.Ref != "sha2" {
+			t.Errorf("unexpected branch %+v", body)
+		}
+
+		_, _ = w.Write([]byte(`{"name":"fork","commit":{"id":"sha2"}}`))
+	})
+
+	mux.HandleFunc("DELETE /api/v4/projects/1/repository/branches/fork", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/repository/tags/v1.0.0", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"v1.0.0","commit":{"id":"sha2"}}`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/pipelines/10/variables", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"key":"NAMESPACE","value":"test"}]`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/repository/branches", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`[]`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`[{"name":"main","commit":{"id":"sha1","committed_date":"2024-01-01T00:00:00Z"}}]`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/repository/tags", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"name":"v1.0.0","commit":{"id":"sha2"}}]`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/repository/compare", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("from") != "sha1" || r.URL.Query().Get("to") != "sha2" {
+			t.Errorf("unexpected compare %s", r.URL.RawQuery)
+		}
+
+		_, _ = w.Write([]byte(`{"commits":[{"id":"a"},{"id":"b"}],"web_url":"http://compare"}`))
+	})
+
+	mux.HandleFunc("POST /api/v4/projects/1/pipeline", func(w http.ResponseWriter, r *http.Request) {
+		body := gitlab.CreatePipelineOptions{}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+
+		if *body.Ref != "main" || len(*body.Variables) != 2 {
+			t.Errorf("unexpected pipeline %+v", body)
+		}
+
+		if *(*body.Variables)[1].VariableType != gitlab.FileVariableType {
+			t.Error("variable must be file")
+		}
+
+		_, _ = w.Write([]byte(`{"id":10,"ref":"main","status":"created","web_url":"http://pipeline/10"}`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/pipelines", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"id":10,"ref":"main","status":"success"},{"id":9,"ref":"main","status":"canceled"}]`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/pipelines/10", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":10,"ref":"main","status":"running","web_url":"http://pipeline/10"}`))
+	})
+
+	mux.HandleFunc("POST /api/v4/projects/1/pipelines/10/cancel", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":10,"status":"canceled"}`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/pipelines/10/jobs", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"id":20,"name":"test","status":"manual"}]`))
+	})
+
+	mux.HandleFunc("POST /api/v4/projects/1/jobs/20/play", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":20,"status":"pending"}`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/jobs/20/artifacts/report/junit.xml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<testsuite/>`))
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/jobs/20/artifacts/report/unknown.xml", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"404 Not Found"}`))
+	})
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	client, err := gitlab.NewClient("", gitlab.WithBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := scmgitlab.NewProvider(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return provider
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Parallel()
+
+	if _, err := scmgitlab.NewProvider(nil); err == nil {
+		t.Fatal("must be error")
+	}
+}
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	provider := newTestProvider(t)
+
+	projects, err := provider.ListProjectsByTopic(ctx, "test-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(projects) != 1 || projects[0].ID != 1 || projects[0].Name != "group / project" {
+		t.Fatalf("unexpected projects %+v", projects)
+	}
+
+	project, err := provider.GetProject(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if project.Path != "group/project" || project.WebURL != "http://project" {
+		t.Fatalf("unexpected project %+v", project)
+	}
+
+	branch, err := provider.GetBranch(ctx, "1", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if branch.CommitSHA != "sha1" {
+		t.Fatalf("unexpected branch %+v", branch)
+	}
+
+	if _, err := provider.GetBranch(ctx, "1", "unknown"); !errors.Is(err, scm.ErrNotFound) {
+		t.Fatalf("must be not found error, got %v", err)
+	}
+
+	tag, err := provider.GetTag(ctx, "1", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.CreateBranch(ctx, "1", "fork", tag.CommitSHA); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.DeleteBranch(ctx, "1", "fork"); err != nil {
+		t.Fatal(err)
+	}
+
+	branches, err := provider.ListBranches(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(branches) != 1 || branches[0].CommitSHA != "sha1" || branches[0].Updated == nil {
+		t.Fatalf("unexpected branches %+v", branches)
+	}
+
+	tags, err := provider.ListTags(ctx, "1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 1 || tags[0].Name != "v1.0.0" {
+		t.Fatalf("unexpected tags %+v", tags)
+	}
+
+	compare, err := provider.Compare(ctx, "1", "sha1", "sha2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compare.Commits != 2 {
+		t.Fatalf("unexpected compare %+v", compare)
+	}
+
+	pipeline, err := provider.TriggerPipeline(ctx, &scm.TriggerPipelineInput{
+		ProjectID: "1",
+		Ref:       "main",
+		Variables: []*scm.Variable{
+			{Key: "KEY", Value: "value"},
+			{Key: "FILE", Value: "content", File: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pipeline.ID != "10" || pipeline.Status != scm.PipelineStatusPending || pipeline.WebURL != "http://pipeline/10" {
+		t.Fatalf("unexpected pipeline %+v", pipeline)
+	}
+
+	pipelines, err := provider.ListPipelines(ctx, &scm.ListPipelinesInput{ProjectID: "1", Ref: "main", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pipelines) != 2 || pipelines[0].Status != scm.PipelineStatusSuccess || pipelines[1].Status != scm.PipelineStatusCanceled {
+		t.Fatalf("unexpected pipelines %+v", pipelines)
+	}
+
+	variables, err := provider.GetPipelineVariables(ctx, "1", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if variables["NAMESPACE"] != "test" {
+		t.Fatalf("unexpected variables %+v", variables)
+	}
+
+	pipeline, err = provider.GetPipeline(ctx, "1", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pipeline.Ref != "main" || pipeline.Status != scm.PipelineStatusRunning {
+		t.Fatalf("unexpected pipeline %+v", pipeline)
+	}
+
+	if err := provider.CancelPipeline(ctx, "1", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.CancelPipeline(ctx, "1", "not-a-number"); err == nil {
+		t.Fatal("must be error")
+	}
+
+	jobs, err := provider.ListJobs(ctx, "1", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(jobs) != 1 || jobs[0].ID != "20" || jobs[0].Status != scm.PipelineStatusManual {
+		t.Fatalf("unexpected jobs %+v", jobs)
+	}
+
+	if err := provider.PlayJob(ctx, "1", "20"); err != nil {
+		t.Fatal(err)
+	}
+
+	artifact, err := provider.GetJobArtifact(ctx, "1", "20", "report/junit.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(artifact) != "<testsuite/>" {
+		t.Fatalf("unexpected artifact %s", artifact)
+	}
+
+	if _, err := provider.GetJobArtifact(ctx, "1", "20", "report/unknown.xml"); !errors.Is(err, scm.ErrNotFound) {
+		t.Fatalf("must be not found error, got %v", err)
+	}
+}
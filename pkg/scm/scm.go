@@ -0,0 +1,141 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package scm
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// returned (wrapped) when project, branch, tag or pipeline does not exist.
+var ErrNotFound = errors.New("not found")
+
+type ProviderName string
+
+const (
+	ProviderGitlab ProviderName = "gitlab"
+	ProviderGithub ProviderName = "github"
+)
+
+type PipelineStatus string
+
+const (
+	PipelineStatusPending  PipelineStatus = "pending"
+	PipelineStatusRunning  PipelineStatus = "running"
+	PipelineStatusSuccess  PipelineStatus = "success"
+	PipelineStatusFailed   PipelineStatus = "failed"
+	PipelineStatusCanceled PipelineStatus = "canceled"
+	PipelineStatusSkipped  PipelineStatus = "skipped"
+	PipelineStatusManual   PipelineStatus = "manual"
+)
+
+func (s PipelineStatus) IsFinished() bool {
+	switch s { //nolint:exhaustive
+	case PipelineStatusSuccess, PipelineStatusFailed, PipelineStatusCanceled, PipelineStatusSkipped:
+		return true
+	default:
+		return false
+	}
+}
+
+type Project struct {
+	ID   int64
+	Name string
+	// full path of project, for example group/project
+	Path          string
+	Description   string
+	DefaultBranch string
+	WebURL        string
+	Topics        []string
+}
+
+type Ref struct {
+	Name      string
+	CommitSHA string
+	// can be nil if provider does not return commit date
+	Updated *time.Time
+}
+
+type CompareResult struct {
+	// number of commits from one ref to another
+	Commits int
+	WebURL  string
+}
+
+type Variable struct {
+	Key   string
+	Value string
+	// variable will be passed as file, if provider supports it
+	File bool
+}
+
+type TriggerPipelineInput struct {
+	ProjectID string
+	Ref       string
+	Variables []*Variable
+}
+
+type ListPipelinesInput struct {
+	ProjectID string
+	Ref       string
+	Limit     int64
+	// return only pipelines that was created with TriggerPipeline
+	Triggered bool
+}
+
+type Pipeline struct {
+	ID      string
+	Ref     string
+	SHA     string
+	Status  PipelineStatus
+	WebURL  string
+	Created *time.Time
+	Updated *time.Time
+}
+
+type Job struct {
+	ID     string
+	Name   string
+	Stage  string
+	Status PipelineStatus
+	WebURL string
+	// nil if job was not started or finished
+	Started  *time.Time
+	Finished *time.Time
+}
+
+// Provider is source control and CI system,
+// project ID is GitLab project ID or GitHub repository ID or full name.
+type Provider interface {
+	GetProject(ctx context.Context, projectID string) (*Project, error)
+	ListProjectsByTopic(ctx context.Context, topic string) ([]*Project, error)
+	GetBranch(ctx context.Context, projectID, branch string) (*Ref, error)
+	ListBranches(ctx context.Context, projectID string) ([]*Ref, error)
+	// create branch from commit
+	CreateBranch(ctx context.Context, projectID, branch, sha string) (*Ref, error)
+	DeleteBranch(ctx context.Context, projectID, branch string) error
+	GetTag(ctx context.Context, projectID, tag string) (*Ref, error)
+	ListTags(ctx context.Context, projectID string, limit int64) ([]*Ref, error)
+	Compare(ctx context.Context, projectID, from, to string) (*CompareResult, error)
+	TriggerPipeline(ctx context.Context, input *TriggerPipelineInput) (*Pipeline, error)
+	ListPipelines(ctx context.Context, input *ListPipelinesInput) ([]*Pipeline, error)
+	GetPipeline(ctx context.Context, projectID, pipelineID string) (*Pipeline, error)
+	// returns variables of pipeline, provider can return only part of variables
+	GetPipelineVariables(ctx context.Context, projectID, pipelineID string) (map[string]string, error)
+	CancelPipeline(ctx context.Context, projectID, pipelineID string) error
+	ListJobs(ctx context.Context, projectID, pipelineID string) ([]*Job, error)
+	PlayJob(ctx context.Context, projectID, jobID string) error
+	// returns file from job artifacts, scm.ErrNotFound if job has no such file
+	GetJobArtifact(ctx context.Context, projectID, jobID, path string) ([]byte, error)
+}
@@ -0,0 +1,244 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stream
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// events that can be resumed with Last-Event-ID.
+	historySize = 1000
+	// subscription will be closed if client can not read events.
+	subscriptionBufferSize = 100
+	bootIDLength           = 4
+)
+
+type EventType string
+
+const (
+	// namespace was created, deleted or labels, annotations was changed.
+	EventNamespace EventType = "namespace"
+	// pod was created, deleted or pod phase was changed.
+	EventPod EventType = "pod"
+	// environment was scaled.
+	EventScale EventType = "scale"
+	// webhook was processed.
+	EventWebhook EventType = "webhook"
+	// events can not be resumed, client must reload all data.
+	EventReset EventType = "reset"
+)
+
+type Event struct {
+	ID            string    `json:"-"`
+	Type          EventType `json:"-"`
+	EnvironmentID string
+	Created       string
+	Data          any
+	seq           uint64
+}
+
+// events of environment or all environments if environmentID is empty,
+// events of pods are sent only to environment subscriptions.
+func (e *Event) match(environmentID string) bool {
+	if len(environmentID) == 0 {
+		return e.Type != EventPod
+	}
+
+	return e.EnvironmentID == environmentID || e.Type == EventReset
+}
+
+type Subscription struct {
+	environmentID string
+	events        chan *Event
+	closed        bool
+	unsubscribe   sync.Once
+	// pods of environment are watched for this subscription
+	watchPods bool
+}
+
+// channel will be closed if client is too slow, client must reconnect with Last-Event-ID.
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.unsubscribe.Do(func() {
+		defaultHub.unsubscribe(s)
+	})
+}
+
+// events are kept in memory of process, events that are published in process
+// (scale and webhook events) are sent only to subscriptions of same process.
+type hub struct {
+	mutex         sync.Mutex
+	bootID        string
+	seq           uint64
+	history       []*Event
+	subscriptions map[*Subscription]struct{}
+	watches       *watches
+}
+
+var defaultHub = newHub()
+
+func newHub() *hub {
+	bootID := make([]byte, bootIDLength)
+	_, _ = rand.Read(bootID)
+
+	return &hub{
+		bootID:        hex.EncodeToString(bootID),
+		history:       make([]*Event, 0),
+		subscriptions: make(map[*Subscription]struct{}),
+		watches:       newWatches(),
+	}
+}
+
+// send event to all subscriptions.
+func Publish(environmentID string, eventType EventType, data any) *Event {
+	return defaultHub.publish(environmentID, eventType, data)
+}
+
+// subscribe to events, events after lastEventID will be returned to resume stream.
+func Subscribe(environmentID, lastEventID string) (*Subscription, []*Event) {
+	return defaultHub.subscribe(environmentID, lastEventID)
+}
+
+func (h *hub) publish(environmentID string, eventType EventType, data any) *Event {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.seq++
+
+	event := &Event{
+		ID:            h.eventID(h.seq),
+		Type:          eventType,
+		EnvironmentID: environmentID,
+		Created:       utils.TimeToString(time.Now()),
+		Data:          data,
+		seq:           h.seq,
+	}
+
+	h.history = append(h.history, event)
+
+	if len(h.history) > historySize {
+		h.history = h.history[len(h.history)-historySize:]
+	}
+
+	for subscription := range h.subscriptions {
+		if !event.match(subscription.environmentID) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			log.Warnf("stream subscription %s is too slow, closing", subscription.environmentID)
+
+			h.closeSubscription(subscription)
+		}
+	}
+
+	return event
+}
+
+func (h *hub) subscribe(environmentID, lastEventID string) (*Subscription, []*Event) {
+	h.watches.startNamespaces()
+
+	subscription := &Subscription{
+		environmentID: environmentID,
+		events:        make(chan *Event, subscriptionBufferSize),
+	}
+
+	if len(environmentID) > 0 {
+		subscription.watchPods = h.watches.startPods(environmentID)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.subscriptions[subscription] = struct{}{}
+
+	return subscription, h.replay(environmentID, lastEventID)
+}
+
+func (h *hub) unsubscribe(subscription *Subscription) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.closeSubscription(subscription)
+
+	if subscription.watchPods {
+		h.watches.stopPods(subscription.environmentID)
+	}
+}
+
+func (h *hub) closeSubscription(subscription *Subscription) {
+	if subscription.closed {
+		return
+	}
+
+	subscription.closed = true
+
+	delete(h.subscriptions, subscription)
+	close(subscription.events)
+}
+
+func (h *hub) eventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", h.bootID, seq)
+}
+
+// returns events after lastEventID, if events was lost returns reset event.
+func (h *hub) replay(environmentID, lastEventID string) []*Event {
+	if len(lastEventID) == 0 {
+		return nil
+	}
+
+	reset := []*Event{{
+		ID:      h.eventID(h.seq),
+		Type:    EventReset,
+		Created: utils.TimeToString(time.Now()),
+	}}
+
+	bootID, seqText, ok := strings.Cut(lastEventID, "-")
+	if !ok || bootID != h.bootID {
+		return reset
+	}
+
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || seq > h.seq {
+		return reset
+	}
+
+	if len(h.history) > 0 && seq+1 < h.history[0].seq {
+		return reset
+	}
+
+	result := make([]*Event, 0)
+
+	for _, event := range h.history {
+		if event.seq > seq && event.match(environmentID) {
+			result = append(result, event)
+		}
+	}
+
+	return result
+}
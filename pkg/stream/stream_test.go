@@ -0,0 +1,110 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stream_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/stream"
+)
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	first := stream.Publish("test:replay", stream.EventScale, stream.ScaleData{Replicas: 1})
+	stream.Publish("test:other", stream.EventScale, stream.ScaleData{Replicas: 1})
+	pod := stream.Publish("test:replay", stream.EventPod, stream.PodData{Name: "test"})
+	scale := stream.Publish("test:replay", stream.EventScale, stream.ScaleData{Replicas: 0})
+
+	subscription, replay := stream.Subscribe("test:replay", first.ID)
+	defer subscription.Close()
+
+	if len(replay) != 2 {
+		t.Fatalf("must be 2 events, got %d", len(replay))
+	}
+
+	if replay[0].ID != pod.ID || replay[1].ID != scale.ID {
+		t.Fatalf("wrong events %s, %s", replay[0].ID, replay[1].ID)
+	}
+
+	// pod events are not sent to all environments stream
+	all, replay := stream.Subscribe("", first.ID)
+	defer all.Close()
+
+	for _, event := range replay {
+		if event.Type == stream.EventPod {
+			t.Fatal("pod event must be skipped")
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	t.Parallel()
+
+	for _, lastEventID := range []string{"unknown-1", "bad", "00000000-a"} {
+		subscription, replay := stream.Subscribe("test:reset", lastEventID)
+		subscription.Close()
+
+		if len(replay) != 1 || replay[0].Type != stream.EventReset {
+			t.Fatalf("must be reset event for %s", lastEventID)
+		}
+	}
+
+	subscription, replay := stream.Subscribe("test:reset", "")
+	subscription.Close()
+
+	if len(replay) != 0 {
+		t.Fatal("must be no events without Last-Event-ID")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	subscription, _ := stream.Subscribe("test:subscribe", "")
+	defer subscription.Close()
+
+	stream.Publish("test:other", stream.EventScale, stream.ScaleData{})
+	published := stream.Publish("test:subscribe", stream.EventWebhook, stream.WebhookData{Reason: "test"})
+
+	select {
+	case event := <-subscription.Events():
+		if event.ID != published.ID {
+			t.Fatalf("wrong event %s", event.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+
+	subscription.Close()
+
+	if _, ok := <-subscription.Events(); ok {
+		t.Fatal("subscription must be closed")
+	}
+}
+
+func TestFailedPodsWatch(t *testing.T) {
+	t.Parallel()
+
+	// cluster is not configured, watch must be retried by next subscription
+	for range 2 {
+		subscription, _ := stream.Subscribe("unknown-cluster:namespace", "")
+
+		if stream.IsPodsWatched("unknown-cluster:namespace") {
+			t.Fatal("failed watch must not be saved")
+		}
+
+		subscription.Close()
+	}
+}
@@ -0,0 +1,290 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stream
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/client"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+type Action string
+
+const (
+	ActionCreated Action = "created"
+	ActionUpdated Action = "updated"
+	ActionDeleted Action = "deleted"
+)
+
+type NamespaceData struct {
+	Action      Action
+	Phase       string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+type PodData struct {
+	Action        Action
+	Name          string
+	Phase         string
+	PreviousPhase string
+	Ready         bool
+}
+
+type ScaleData struct {
+	Replicas int32
+	Error    string
+}
+
+type WebhookData struct {
+	Event      types.Event
+	Reason     string
+	Properties map[string]string
+	Error      string
+}
+
+type podWatch struct {
+	stop          chan struct{}
+	subscriptions int
+}
+
+// namespaces are watched in all clusters after first subscription,
+// pods are watched only while environment has subscriptions.
+type watches struct {
+	mutex      sync.Mutex
+	namespaces sync.Once
+	pods       map[string]*podWatch
+}
+
+func newWatches() *watches {
+	return &watches{
+		pods: make(map[string]*podWatch),
+	}
+}
+
+func (w *watches) startNamespaces() {
+	w.namespaces.Do(func() {
+		for cluster, clientset := range client.GetAllClientsets() {
+			factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+				informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+					options.LabelSelector = config.FilterLabels
+				}),
+			)
+
+			informer := factory.Core().V1().Namespaces().Informer()
+
+			if _, err := informer.AddEventHandler(namespaceHandler(cluster)); err != nil {
+				log.WithError(err).Errorf("can not watch namespaces in cluster %s", cluster)
+
+				continue
+			}
+
+			// namespaces are watched while process is running
+			factory.Start(make(chan struct{}))
+		}
+	})
+}
+
+// returns false if pods can not be watched, failed watch is not saved and will be retried by next subscription.
+func (w *watches) startPods(environmentID string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if watch, ok := w.pods[environmentID]; ok {
+		watch.subscriptions++
+
+		return true
+	}
+
+	idInfo, err := types.NewIDInfo(environmentID)
+	if err != nil {
+		log.WithError(err).Warnf("can not watch pods of %s", environmentID)
+
+		return false
+	}
+
+	clientset, err := client.GetClientset(idInfo.Cluster)
+	if err != nil {
+		log.WithError(err).Warnf("can not watch pods of %s", environmentID)
+
+		return false
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithNamespace(idInfo.Namespace))
+
+	informer := factory.Core().V1().Pods().Informer()
+
+	if _, err := informer.AddEventHandler(podHandler(environmentID)); err != nil {
+		log.WithError(err).Warnf("can not watch pods of %s", environmentID)
+
+		return false
+	}
+
+	watch := &podWatch{
+		stop:          make(chan struct{}),
+		subscriptions: 1,
+	}
+
+	w.pods[environmentID] = watch
+
+	factory.Start(watch.stop)
+
+	return true
+}
+
+func (w *watches) stopPods(environmentID string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	watch, ok := w.pods[environmentID]
+	if !ok {
+		return
+	}
+
+	if watch.subscriptions--; watch.subscriptions > 0 {
+		return
+	}
+
+	close(watch.stop)
+	delete(w.pods, environmentID)
+}
+
+func newNamespaceData(action Action, namespace *corev1.Namespace) NamespaceData {
+	return NamespaceData{
+		Action:      action,
+		Phase:       string(namespace.Status.Phase),
+		Labels:      namespace.Labels,
+		Annotations: namespace.Annotations,
+	}
+}
+
+func namespaceHandler(cluster string) cache.ResourceEventHandler {
+	environmentID := func(namespace *corev1.Namespace) string {
+		return cluster + ":" + namespace.Name
+	}
+
+	return cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			namespace, ok := obj.(*corev1.Namespace)
+			if !ok || isInInitialList {
+				return
+			}
+
+			Publish(environmentID(namespace), EventNamespace, newNamespaceData(ActionCreated, namespace))
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldNamespace, ok := oldObj.(*corev1.Namespace)
+			if !ok {
+				return
+			}
+
+			namespace, ok := newObj.(*corev1.Namespace)
+			if !ok {
+				return
+			}
+
+			if oldNamespace.Status.Phase == namespace.Status.Phase &&
+				maps.Equal(oldNamespace.Labels, namespace.Labels) &&
+				maps.Equal(oldNamespace.Annotations, namespace.Annotations) {
+				return
+			}
+
+			Publish(environmentID(namespace), EventNamespace, newNamespaceData(ActionUpdated, namespace))
+		},
+		DeleteFunc: func(obj any) {
+			if deleted, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = deleted.Obj
+			}
+
+			namespace, ok := obj.(*corev1.Namespace)
+			if !ok {
+				return
+			}
+
+			Publish(environmentID(namespace), EventNamespace, newNamespaceData(ActionDeleted, namespace))
+		},
+	}
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+func newPodData(action Action, pod *corev1.Pod) PodData {
+	return PodData{
+		Action: action,
+		Name:   pod.Name,
+		Phase:  string(pod.Status.Phase),
+		Ready:  isPodReady(pod),
+	}
+}
+
+func podHandler(environmentID string) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok || isInInitialList {
+				return
+			}
+
+			Publish(environmentID, EventPod, newPodData(ActionCreated, pod))
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldPod, ok := oldObj.(*corev1.Pod)
+			if !ok {
+				return
+			}
+
+			pod, ok := newObj.(*corev1.Pod)
+			if !ok {
+				return
+			}
+
+			// only phase transitions are sent
+			if oldPod.Status.Phase == pod.Status.Phase && isPodReady(oldPod) == isPodReady(pod) {
+				return
+			}
+
+			data := newPodData(ActionUpdated, pod)
+			data.PreviousPhase = string(oldPod.Status.Phase)
+
+			Publish(environmentID, EventPod, data)
+		},
+		DeleteFunc: func(obj any) {
+			if deleted, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = deleted.Obj
+			}
+
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				return
+			}
+
+			Publish(environmentID, EventPod, newPodData(ActionDeleted, pod))
+		},
+	}
+}
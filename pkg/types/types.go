@@ -48,6 +48,32 @@ const (
 	EventPrestop Event = "prestop"
 	// user reschedule scaledown environment.
 	EventScaledownDelayed Event = "scaledown-delayed"
+	// new environment was created.
+	EventCreated Event = "created"
+	// environment was deleted.
+	EventDeleted Event = "deleted"
+	// service was deleted from environment.
+	EventServiceDeleted Event = "service-deleted"
+	// snapshot pipeline was created.
+	EventSnapshotCreated Event = "snapshot-created"
+	// user start autotest.
+	EventAutotestStarted Event = "autotest-started"
+	// user stops autotest.
+	EventAutotestStopped Event = "autotest-stopped"
+	// environment pipeline was finished.
+	EventPipelineFinished Event = "pipeline-finished"
+	// autotest pipeline was finished.
+	EventAutotestFinished Event = "autotest-finished"
+	// all waves of services deploy was finished.
+	EventDeployFinished Event = "deploy-finished"
+	// user interactive terminal session was closed.
+	EventTerminalSession Event = "terminal-session"
+	// remote server was created from template.
+	EventRemoteServerCreated Event = "remote-server-created"
+	// remote server was not used and will be deleted.
+	EventRemoteServerStaled Event = "remote-server-staled"
+	// remote server was deleted.
+	EventRemoteServerDeleted Event = "remote-server-deleted"
 )
 
 type WebhookMessage struct {
@@ -59,6 +85,15 @@ type WebhookMessage struct {
 	Properties map[string]string
 }
 
+// ID of environment in webhooks config, events that are not related to namespace have only cluster.
+func (m WebhookMessage) ID() string {
+	if len(m.Namespace) == 0 {
+		return m.Cluster
+	}
+
+	return m.Cluster + ":" + m.Namespace
+}
+
 type IDInfo struct {
 	Cluster   string
 	Namespace string
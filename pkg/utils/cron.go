@@ -0,0 +1,148 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const cronFields = 5
+
+//nolint:gochecknoglobals
+var cronAliases = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// min and max values of minute, hour, day of month, month and day of week.
+//
+//nolint:gochecknoglobals
+var cronBounds = [cronFields][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// schedule in cron format: minute hour day-of-month month day-of-week,
+// fields support *, lists, ranges and steps.
+type Cron struct {
+	fields [cronFields]map[int]bool
+	// day of month or day of week is not *
+	restrictedDayOfMonth bool
+	restrictedDayOfWeek  bool
+}
+
+func ParseCron(expression string) (*Cron, error) {
+	if alias, ok := cronAliases[expression]; ok {
+		expression = alias
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != cronFields {
+		return nil, errors.Errorf("cron expression must have %d fields, got %q", cronFields, expression)
+	}
+
+	cron := Cron{
+		restrictedDayOfMonth: fields[2] != "*",
+		restrictedDayOfWeek:  fields[4] != "*",
+	}
+
+	for i, field := range fields {
+		values, err := parseCronField(field, cronBounds[i][0], cronBounds[i][1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid field %q", field)
+		}
+
+		cron.fields[i] = values
+	}
+
+	// 7 is sunday
+	if cron.fields[4][7] {
+		cron.fields[4][0] = true
+	}
+
+	return &cron, nil
+}
+
+func parseCronField(field string, minValue, maxValue int) (map[int]bool, error) {
+	result := make(map[int]bool)
+
+	for part := range strings.SplitSeq(field, ",") {
+		valueRange, stepText, hasStep := strings.Cut(part, "/")
+
+		step := 1
+
+		if hasStep {
+			var err error
+
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step <= 0 {
+				return nil, errors.Errorf("invalid step %s", stepText)
+			}
+		}
+
+		from, to := minValue, maxValue
+
+		if valueRange != "*" {
+			fromText, toText, isRange := strings.Cut(valueRange, "-")
+
+			var err error
+
+			from, err = strconv.Atoi(fromText)
+			if err != nil {
+				return nil, errors.Errorf("invalid value %s", fromText)
+			}
+
+			to = from
+
+			if isRange {
+				to, err = strconv.Atoi(toText)
+				if err != nil {
+					return nil, errors.Errorf("invalid value %s", toText)
+				}
+			} else if hasStep {
+				to = maxValue
+			}
+		}
+
+		if from < minValue || to > maxValue || from > to {
+			return nil, errors.Errorf("value %s out of range %d-%d", valueRange, minValue, maxValue)
+		}
+
+		for value := from; value <= to; value += step {
+			result[value] = true
+		}
+	}
+
+	return result, nil
+}
+
+// returns true if schedule matches minute of time,
+// if both day of month and day of week are restricted one of them must match.
+func (c *Cron) Match(t time.Time) bool {
+	if !c.fields[0][t.Minute()] || !c.fields[1][t.Hour()] || !c.fields[3][int(t.Month())] {
+		return false
+	}
+
+	dayOfMonth := c.fields[2][t.Day()]
+	dayOfWeek := c.fields[4][int(t.Weekday())]
+
+	if c.restrictedDayOfMonth && c.restrictedDayOfWeek {
+		return dayOfMonth || dayOfWeek
+	}
+
+	return dayOfMonth && dayOfWeek
+}
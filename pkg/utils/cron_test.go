@@ -0,0 +1,66 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+)
+
+func TestCron(t *testing.T) {
+	t.Parallel()
+
+	// monday
+	monday := time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC)
+
+	type test struct {
+		expression string
+		time       time.Time
+		want       bool
+	}
+
+	tests := []test{
+		{expression: "* * * * *", time: monday, want: true},
+		{expression: "30 2 * * *", time: monday, want: true},
+		{expression: "30 3 * * *", time: monday, want: false},
+		{expression: "*/15 * * * *", time: monday, want: true},
+		{expression: "*/20 * * * *", time: monday, want: false},
+		{expression: "0,30 1-3 * * 1-5", time: monday, want: true},
+		{expression: "30 2 * * 0,6", time: monday, want: false},
+		{expression: "30 2 * * 7", time: monday.AddDate(0, 0, 6), want: true},
+		// day of month or day of week
+		{expression: "30 2 15 * 1", time: monday, want: true},
+		{expression: "30 2 15 * 2", time: monday, want: false},
+		{expression: "@daily", time: monday.Truncate(24 * time.Hour), want: true},
+		{expression: "@daily", time: monday, want: false},
+	}
+
+	for _, test := range tests {
+		cron, err := utils.ParseCron(test.expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := cron.Match(test.time); got != test.want {
+			t.Fatalf("expression=%s,time=%s,want=%t,got=%t", test.expression, test.time, test.want, got)
+		}
+	}
+
+	for _, expression := range []string{"* * * *", "60 * * * *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := utils.ParseCron(expression); err == nil {
+			t.Fatalf("expression %s must be invalid", expression)
+		}
+	}
+}
@@ -15,9 +15,11 @@ package web
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/jira"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/metrics"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/modules/autotests"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
@@ -239,6 +242,70 @@ func apiOperation(ctx context.Context, r *http.Request, operation string) (*Hand
 		}
 
 		result.Result = "Delayed scaleDown on next " + input.Duration
+	case "make-remote-server-schedule":
+		input := api.SetRemoteServerScheduleInput{}
+
+		err = json.Unmarshal(body, &input)
+		if err != nil {
+			return result, err
+		}
+
+		err := api.SetRemoteServerSchedule(ctx, input)
+		if err != nil {
+			return result, err
+		}
+
+		if input.Schedule == nil {
+			result.Result = "schedule removed"
+		} else {
+			result.Result = "server will work " + input.Schedule.String()
+		}
+	case "usage":
+		overview, err := api.GetUsageOverview(ctx)
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = overview
+	case "cost-report":
+		report, err := api.GetCostReport(ctx, &api.CostReportInput{
+			Month: r.Form.Get("month"),
+		})
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = report
+	case "remote-server-templates":
+		result.Result = api.GetRemoteServerTemplates()
+	case "make-remote-server-create":
+		input := api.CreateRemoteServerInput{}
+
+		err = json.Unmarshal(body, &input)
+		if err != nil {
+			return result, err
+		}
+
+		server, err := api.CreateRemoteServer(ctx, input)
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = fmt.Sprintf("server %s created, press Refresh to view changes", server.ServerName)
+	case "make-remote-server-delete":
+		input := api.DeleteRemoteServerInput{}
+
+		err = json.Unmarshal(body, &input)
+		if err != nil {
+			return result, err
+		}
+
+		err = api.DeleteRemoteServer(ctx, input)
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = "deletion started, snapshot of server will be created before delete"
 	case "jira-issue-info":
 		issue := r.Form.Get("issue")
 		if len(issue) == 0 {
@@ -292,6 +359,23 @@ func apiOperation(ctx context.Context, r *http.Request, operation string) (*Hand
 
 		result.Result = wikiResult
 
+	case "autotests-overview":
+		overviewInput := autotests.OverviewInput{}
+
+		if days := r.Form.Get("days"); len(days) > 0 {
+			overviewInput.Days, err = strconv.Atoi(days)
+			if err != nil {
+				return result, errors.Wrap(err, "bad days")
+			}
+		}
+
+		overview, err := autotests.GetAutotestsOverview(ctx, &overviewInput)
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = overview
+
 	case "cache.flush":
 		err := cache.Client().FlushALL(ctx)
 		if err != nil {
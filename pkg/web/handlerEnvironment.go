@@ -14,7 +14,6 @@ package web
 
 import (
 	"context"
-	b64 "encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -158,7 +157,31 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 
 		result.Result = services
 	case "info":
+		// quota can not be read in some clusters, environment info is returned without it
+		quotaUsage, err := environment.GetQuotaUsage(ctx)
+		if err != nil {
+			log.WithError(err).Warnf("can not get quota usage of %s", environment.ID)
+		}
+
+		environment.QuotaUsage = quotaUsage
+
 		result.Result = environment
+	case "usage":
+		usage, err := environment.GetResourceUsage(ctx)
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = usage
+	case "cost":
+		cost, err := environment.GetCost(ctx, &api.CostReportInput{
+			Month: r.Form.Get("month"),
+		})
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = cost
 	case "containers":
 		filter := r.Form.Get("filter")
 		containerInAnnotation := r.Form.Get("annotation")
@@ -182,7 +205,7 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 
 		containerInfo := ContainerInfo{}
 
-		xdebugInfo, err := environment.ExecContainer(ctx, container, "/kubernetes-manager/xdebugInfo")
+		xdebugInfo, err := environment.RunContainerAction(ctx, container, "xdebug-info", nil)
 		if err != nil {
 			return result, err
 		}
@@ -191,7 +214,7 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 			containerInfo.XdebugEnabled = true
 		}
 
-		phpFpmSettings, err := environment.ExecContainer(ctx, container, "/kubernetes-manager/getPhpSettings")
+		phpFpmSettings, err := environment.RunContainerAction(ctx, container, "php-settings", nil)
 		if err != nil {
 			return result, err
 		}
@@ -228,12 +251,19 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 			return result, err
 		}
 
-		err := environment.CreateGitlabPipelinesByServices(ctx, deployServices.Services, deployServices.Operation)
+		_, err := environment.CreateGitlabPipelinesByServices(ctx, deployServices.Services, deployServices.Operation)
 		if err != nil {
 			return result, err
 		}
 
 		result.Result = clickRefreshButton
+	case "deploy-status":
+		deployProgress, err := environment.GetDeployProgress(ctx)
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = deployProgress
 	case "make-save-namespace-name":
 		type SaveNamespaceName struct {
 			Name string
@@ -351,6 +381,34 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 		}
 
 		result.Result = fmt.Sprintf("Namespace %s deleted", environment.Namespace)
+	case "follow-branch":
+		type FollowBranch struct {
+			Enabled bool
+			Results []*api.FollowBranchResult
+		}
+
+		result.Result = FollowBranch{
+			Enabled: environment.IsFollowBranch(),
+			Results: environment.GetFollowBranchResults(),
+		}
+	case "make-follow-branch":
+		type FollowBranch struct {
+			Enabled bool
+		}
+
+		followBranch := FollowBranch{}
+
+		err := json.Unmarshal(body, &followBranch)
+		if err != nil {
+			return result, err
+		}
+
+		err = environment.SetFollowBranch(ctx, followBranch.Enabled)
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = "Follow branch " + strconv.FormatBool(followBranch.Enabled)
 	case "make-scaledown-delay":
 		type ScaledownDelay struct {
 			Delay string
@@ -422,7 +480,7 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 		containerInfoResult.GitOrigin = containerInfo.PodAnnotations[config.LabelGitSyncOrigin]
 		containerInfoResult.GitBranch = containerInfo.PodAnnotations[config.LabelGitSyncBranch]
 
-		gitSyncResult, err := environment.ExecContainer(ctx, container, "/kubernetes-manager/getGitBranch")
+		gitSyncResult, err := environment.RunContainerAction(ctx, container, "git-branch", nil)
 		if err != nil {
 			return result, err
 		}
@@ -431,7 +489,7 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 			return result, errors.New(gitSyncResult.Stderr)
 		}
 
-		getGitPubKey, err := environment.ExecContainer(ctx, container, "/kubernetes-manager/getGitPubKey")
+		getGitPubKey, err := environment.RunContainerAction(ctx, container, "git-public-key", nil)
 		if err != nil {
 			return result, err
 		}
@@ -447,40 +505,46 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 		}
 
 		result.Result = containerInfoResult
-	case "make-git-sync-init":
-		type GitSyncInit struct {
-			Container string
-			GitOrigin string
-			GitBranch string
+	case "container-actions":
+		container := r.Form.Get("container")
+		if len(container) == 0 {
+			return result, errors.Wrap(errBadFormat, noContainerSpecified)
 		}
 
-		gitSyncInit := GitSyncInit{}
-
-		err = json.Unmarshal(body, &gitSyncInit)
+		actions, err := environment.GetContainerActions(ctx, container)
 		if err != nil {
 			return result, err
 		}
 
-		if len(gitSyncInit.Container) == 0 {
-			return result, errors.Wrap(errBadFormat, noContainerSpecified)
+		result.Result = actions
+	case "make-container-action":
+		type ContainerAction struct {
+			Container string
+			Action    string
+			Params    map[string]string
 		}
 
-		if len(gitSyncInit.GitOrigin) == 0 {
-			return result, errors.Wrap(errBadFormat, "no git origin specified")
+		containerAction := ContainerAction{}
+
+		err = json.Unmarshal(body, &containerAction)
+		if err != nil {
+			return result, err
 		}
 
-		if len(gitSyncInit.GitBranch) == 0 {
-			return result, errors.Wrap(errBadFormat, "no git branch specified")
+		if len(containerAction.Container) == 0 {
+			return result, errors.Wrap(errBadFormat, noContainerSpecified)
 		}
 
-		enableGitCommand := fmt.Sprintf("/kubernetes-manager/enableGit %s %s", gitSyncInit.GitOrigin, gitSyncInit.GitBranch)
+		if len(containerAction.Action) == 0 {
+			return result, errors.Wrap(errBadFormat, "no action specified")
+		}
 
-		enableGit, err := environment.ExecContainer(ctx, gitSyncInit.Container, enableGitCommand)
+		actionResult, err := environment.RunContainerAction(ctx, containerAction.Container, containerAction.Action, containerAction.Params)
 		if err != nil {
 			return result, err
 		}
 
-		result.Result = enableGit.Stdout + enableGit.Stderr
+		result.Result = actionResult
 	case "make-delete-container":
 		type DeletePod struct {
 			Container string
@@ -531,81 +595,54 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 
 		result.Result = fmt.Sprintf("Pod %s deleted", deletePod.PodName)
 
-	case "make-git-sync-fetch":
-		type GitSyncFetch struct {
-			Container string
+	case "debug-containers":
+		type DebugContainers struct {
+			Images     []string
+			Containers []*api.DebugContainerStatus
 		}
 
-		gitSyncFetch := GitSyncFetch{}
-
-		err = json.Unmarshal(body, &gitSyncFetch)
-		if err != nil {
-			return result, err
+		debugContainers := DebugContainers{
+			Images:     config.Get().DebugContainer.Images,
+			Containers: make([]*api.DebugContainerStatus, 0),
 		}
 
-		if len(gitSyncFetch.Container) == 0 {
-			return result, errors.Wrap(errBadFormat, noContainerSpecified)
-		}
+		if pod := r.Form.Get("pod"); len(pod) > 0 {
+			containers, err := environment.GetDebugContainers(ctx, pod)
+			if err != nil {
+				return result, err
+			}
 
-		gitFetch, err := environment.ExecContainer(ctx, gitSyncFetch.Container, "/kubernetes-manager/gitFetch")
-		if err != nil {
-			return result, err
+			debugContainers.Containers = containers
 		}
 
-		result.Result = gitFetch.Stdout + " " + gitFetch.Stderr
-	case "make-debug-xdebug-init":
-		type DebugXdebugInit struct {
+		result.Result = debugContainers
+	case "make-debug-container":
+		type DebugContainer struct {
 			Container string
+			Image     string
 		}
 
-		debugXdebugInit := DebugXdebugInit{}
-
-		err = json.Unmarshal(body, &debugXdebugInit)
-		if err != nil {
-			return result, err
-		}
-
-		if len(debugXdebugInit.Container) == 0 {
-			return result, errors.Wrap(errBadFormat, noContainerSpecified)
-		}
-
-		debugXdebug, err := environment.ExecContainer(ctx, debugXdebugInit.Container, "/kubernetes-manager/enableXdebug")
-		if err != nil {
-			return result, err
-		}
-
-		result.Result = debugXdebug.Stdout + " " + debugXdebug.Stderr
-	case "make-debug-save-config":
-		type DebugSaveConfig struct {
-			Container      string
-			PhpFpmSettings string
-		}
+		debugContainer := DebugContainer{}
 
-		debugSaveConfig := DebugSaveConfig{}
-
-		err = json.Unmarshal(body, &debugSaveConfig)
+		err = json.Unmarshal(body, &debugContainer)
 		if err != nil {
 			return result, err
 		}
 
-		if len(debugSaveConfig.Container) == 0 {
+		if len(debugContainer.Container) == 0 {
 			return result, errors.Wrap(errBadFormat, noContainerSpecified)
 		}
 
-		if len(debugSaveConfig.PhpFpmSettings) == 0 {
-			return result, errors.Wrap(errBadFormat, "no config specified")
+		if len(debugContainer.Image) == 0 {
+			return result, errors.Wrap(errBadFormat, "no image specified")
 		}
 
-		base64Config := b64.StdEncoding.EncodeToString([]byte(debugSaveConfig.PhpFpmSettings))
-
-		cmd := "/kubernetes-manager/setPhpSettings " + base64Config
-
-		debugSaveConfigExec, err := environment.ExecContainer(ctx, debugSaveConfig.Container, cmd)
+		status, err := environment.CreateDebugContainer(ctx, debugContainer.Container, debugContainer.Image)
 		if err != nil {
 			return result, err
 		}
 
-		result.Result = debugSaveConfigExec.Stdout + " " + debugSaveConfigExec.Stderr
+		result.Result = status
 	case "make-delete-service":
 		type DeleteService struct {
 			ProjectID string
@@ -627,65 +664,41 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 			return result, errors.Wrap(errBadFormat, "no ref specified")
 		}
 
-		// pipeline will be created with this environment variable:
-		// DELETE=true
-		// NAMESPACE=<environment.Namespace>
-		// CLUSTER=<environment.Cluster>
-		//
-		// pipeline if succeeded, must delete namespace annotation:
-		// kubectl annotate namespace $NAMESPACE kubernetes-manager/project-${CI_PROJECT_ID}-
-
-		_, err := environment.CreateGitlabPipeline(ctx, &api.CreateGitlabPipelineInput{
-			ProjectID: deleteService.ProjectID,
-			Ref:       deleteService.Ref,
-			Operation: api.GitlabPipelineOperationDelete,
-		})
+		_, err := environment.DeleteService(ctx, deleteService.ProjectID, deleteService.Ref)
 		if err != nil {
 			return result, err
 		}
 
 		result.Result = clickRefreshButton
-	case "make-git-sync-clear-cache":
-		type GitSyncClearCache struct {
-			Container string
+	case "make-snapshot":
+		if len(config.Get().Snapshots.ProjectID) == 0 {
+			return result, errors.Wrap(errBadFormat, "no projectID for snapshoting specified")
 		}
 
-		gitSyncClearCache := GitSyncClearCache{}
+		if len(config.Get().Snapshots.Ref) == 0 {
+			return result, errors.Wrap(errBadFormat, "no ref for snapshoting specified")
+		}
 
-		err = json.Unmarshal(body, &gitSyncClearCache)
+		url, err := environment.CreateSnapshot(ctx)
 		if err != nil {
 			return result, err
 		}
 
-		if len(gitSyncClearCache.Container) == 0 {
-			return result, errors.Wrap(errBadFormat, noContainerSpecified)
-		}
+		result.Result = "Pipeline created " + url
+	case "make-namespace-resources":
+		setNamespaceResources := api.SetNamespaceResourcesInput{}
 
-		clearCache, err := environment.ExecContainer(ctx, gitSyncClearCache.Container, "/kubernetes-manager/clearCache")
+		err = json.Unmarshal(body, &setNamespaceResources)
 		if err != nil {
 			return result, err
 		}
 
-		result.Result = clearCache.Stdout + " " + clearCache.Stderr
-	case "make-snapshot":
-		if len(config.Get().Snapshots.ProjectID) == 0 {
-			return result, errors.Wrap(errBadFormat, "no projectID for snapshoting specified")
-		}
-
-		if len(config.Get().Snapshots.Ref) == 0 {
-			return result, errors.Wrap(errBadFormat, "no ref for snapshoting specified")
-		}
-
-		url, err := environment.CreateGitlabPipeline(ctx, &api.CreateGitlabPipelineInput{
-			ProjectID: config.Get().Snapshots.ProjectID,
-			Ref:       config.Get().Snapshots.Ref,
-			Operation: api.GitlabPipelineOperationSnapshot,
-		})
+		err = environment.SetNamespaceResources(ctx, &setNamespaceResources)
 		if err != nil {
 			return result, err
 		}
 
-		result.Result = "Pipeline created " + url
+		result.Result = "Namespace resources applied"
 	case "autotests":
 		size := 10
 
@@ -712,12 +725,32 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 
 		startAutotest.SetEnvironment(environment)
 
-		err = autotests.StartAutotest(ctx, &startAutotest)
+		startResult, err := autotests.StartAutotest(ctx, &startAutotest)
+		if err != nil {
+			return result, err
+		}
+
+		if startResult.QueueItem != nil {
+			result.Result = fmt.Sprintf("Autotest is already running, queued at position %d.", startResult.QueueItem.Position)
+		} else {
+			result.Result = "Autotest started. Click Refresh button to see status."
+		}
+	case "make-cancel-queued-autotest":
+		cancelQueuedAutotest := autotests.CancelQueuedAutotestInput{}
+
+		err = json.Unmarshal(body, &cancelQueuedAutotest)
 		if err != nil {
 			return result, err
 		}
 
-		result.Result = "Autotest started. Click Refresh button to see status."
+		cancelQueuedAutotest.SetEnvironment(environment)
+
+		err = autotests.CancelQueuedAutotest(ctx, &cancelQueuedAutotest)
+		if err != nil {
+			return result, err
+		}
+
+		result.Result = "Autotest removed from queue."
 	case "make-stop-autotest":
 		stopAutotest := autotests.StopAutotestInput{}
 
@@ -748,20 +781,11 @@ func environmentOperation(ctx context.Context, r *http.Request, environmentID st
 
 		result.Result = podContainers
 	case "pod-container-logs":
-		pod := r.Form.Get("pod")
-		container := r.Form.Get("container")
-
-		if len(pod) == 0 || len(container) == 0 {
-			return result, errors.Wrap(errBadFormat, "no pod or container specified")
-		}
-
-		logRequest := &api.GetPodContainerLogRequest{
-			Pod:       pod,
-			Container: container,
+		logRequest, err := newPodContainerLogRequest(r)
+		if err != nil {
+			return result, err
 		}
 
-		logRequest.SetTimestamps(r.Form.Get("timestamps"))
-
 		containerLog, err := environment.GetPodContainerLog(ctx, logRequest)
 		if err != nil {
 			return result, err
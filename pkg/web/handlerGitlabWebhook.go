@@ -0,0 +1,148 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/modules/autotests"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	logrushooksentry "github.com/maksim-paskal/logrus-hook-sentry"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const gitlabWebhookProcessTimeout = 5 * time.Minute
+
+var (
+	errGitlabWebhookDisabled = errors.New("gitlab webhook is disabled")
+	errGitlabWebhookToken    = errors.New("invalid gitlab webhook token")
+)
+
+func handlerGitlabWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, span := telemetry.Start(r.Context(), "handlerGitlabWebhook")
+	defer span.End()
+
+	err := processGitlabWebhook(ctx, r)
+	if err != nil {
+		span.RecordError(err)
+
+		switch {
+		case errors.Is(err, errGitlabWebhookDisabled):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, errGitlabWebhookToken):
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		if _, err := w.Write([]byte(err.Error())); err != nil { //nolint:gosec
+			log.WithError(err).Error()
+		}
+
+		log.
+			WithError(err).
+			WithFields(logrushooksentry.AddRequest(r)).
+			Error()
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func processGitlabWebhook(ctx context.Context, r *http.Request) error {
+	ctx, span := telemetry.Start(ctx, "web.processGitlabWebhook")
+	defer span.End()
+
+	token := config.Get().GitlabWebhook.Token
+	if len(token) == 0 {
+		return errGitlabWebhookDisabled
+	}
+
+	if subtle.ConstantTimeCompare([]byte(gitlab.HookEventToken(r)), []byte(token)) != 1 {
+		return errGitlabWebhookToken
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read request body")
+	}
+	defer r.Body.Close()
+
+	eventType := gitlab.HookEventType(r)
+
+	switch eventType { //nolint:exhaustive
+	case gitlab.EventTypePipeline, gitlab.EventTypePush:
+	default:
+		log.Debugf("gitlab event %s ignored", eventType)
+
+		return nil
+	}
+
+	event, err := gitlab.ParseWebhook(eventType, body)
+	if err != nil {
+		return errors.Wrap(err, "error parsing event")
+	}
+
+	// GitLab waits for response only 10 seconds and retries slow deliveries,
+	// event is processed in background to avoid duplicated pipelines
+	go processGitlabWebhookEvent(context.WithoutCancel(ctx), event)
+
+	return nil
+}
+
+func processGitlabWebhookEvent(ctx context.Context, event any) {
+	ctx, cancel := context.WithTimeout(ctx, gitlabWebhookProcessTimeout)
+	defer cancel()
+
+	ctx, span := telemetry.Start(ctx, "web.processGitlabWebhookEvent")
+	defer span.End()
+
+	var err error
+
+	switch event := event.(type) {
+	case *gitlab.PipelineEvent:
+		if err = api.ProcessGitlabPipelineEvent(ctx, event); err != nil {
+			err = errors.Wrap(err, "error processing pipeline event")
+
+			break
+		}
+
+		if err = autotests.ProcessGitlabPipelineEvent(ctx, event); err != nil {
+			err = errors.Wrap(err, "error processing autotest pipeline event")
+
+			break
+		}
+
+		if err = autotests.ProcessGitlabDeployPipelineEvent(ctx, event); err != nil {
+			err = errors.Wrap(err, "error processing deploy pipeline event")
+		}
+	case *gitlab.PushEvent:
+		if err = api.ProcessGitlabPushEvent(ctx, event); err != nil {
+			err = errors.Wrap(err, "error processing push event")
+		}
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		log.WithError(err).Error()
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func newPodContainerLogRequest(r *http.Request) (*api.GetPodContainerLogRequest, error) {
+	logRequest := &api.GetPodContainerLogRequest{
+		Pod:       r.Form.Get("pod"),
+		Container: r.Form.Get("container"),
+		Selector:  r.Form.Get("selector"),
+	}
+
+	if len(logRequest.Selector) == 0 && (len(logRequest.Pod) == 0 || len(logRequest.Container) == 0) {
+		return nil, errors.Wrap(errBadFormat, "no pod or container specified")
+	}
+
+	if err := logRequest.SetTailLines(r.Form.Get("tailLines")); err != nil {
+		return nil, errors.Wrap(errBadFormat, err.Error())
+	}
+
+	if err := logRequest.SetSinceSeconds(r.Form.Get("sinceSeconds")); err != nil {
+		return nil, errors.Wrap(errBadFormat, err.Error())
+	}
+
+	logRequest.SetTimestamps(r.Form.Get("timestamps"))
+	logRequest.SetPrevious(r.Form.Get("previous"))
+	logRequest.SetFollow(r.Form.Get("follow"))
+
+	return logRequest, nil
+}
+
+// flush every write to client.
+type flushWriter struct {
+	controller *http.ResponseController
+	w          http.ResponseWriter
+	written    bool
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	f.written = true
+
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	return n, f.controller.Flush()
+}
+
+// chunked logs of pod container or pods that match selector, request stops when client disconnects.
+func handlerPodContainerLogsStream(w http.ResponseWriter, r *http.Request) {
+	ctx, span := telemetry.Start(r.Context(), "handlerPodContainerLogsStream")
+	defer span.End()
+
+	vars := mux.Vars(r)
+
+	telemetry.Attributes(span, vars)
+
+	if owner := r.Header[config.HeaderOwner]; len(owner) > 0 {
+		log.Infof("user %s request pod-container-logs-stream", owner[0])
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	logRequest, err := newPodContainerLogRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	environment, err := api.GetEnvironmentByID(ctx, vars["environmentID"])
+	if err != nil {
+		span.RecordError(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	controller := http.NewResponseController(w)
+
+	// logs are not limited by server write timeout
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.WithError(err).Warn("can not reset write deadline")
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	writer := &flushWriter{controller: controller, w: w}
+
+	if err := environment.StreamPodContainerLog(ctx, logRequest, writer); err != nil {
+		span.RecordError(err)
+
+		if !writer.written {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		log.WithError(err).Warn("error streaming logs")
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package web
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/portforward"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// TCP connection to service or pod port over websocket.
+func handlerPortForward(w http.ResponseWriter, r *http.Request) {
+	ctx, span := telemetry.Start(r.Context(), "handlerPortForward")
+	defer span.End()
+
+	vars := mux.Vars(r)
+
+	telemetry.Attributes(span, vars)
+
+	owner, ok := getRequestOwner(w, r)
+	if !ok {
+		return
+	}
+
+	ctx = context.WithValue(ctx, types.ContextSecurityKey, types.ContextSecurity{Owner: owner})
+
+	service := r.URL.Query().Get("service")
+	pod := r.URL.Query().Get("pod")
+
+	port, err := strconv.Atoi(r.URL.Query().Get("port"))
+	if err != nil || (len(service) == 0 && len(pod) == 0) {
+		http.Error(w, "service or pod and port must be specified", http.StatusBadRequest)
+
+		return
+	}
+
+	if !config.Get().IsAuthorized(config.PermissionPortForward, owner, vars["environmentID"]) {
+		http.Error(w, "user has no permission to port-forward", http.StatusForbidden)
+
+		return
+	}
+
+	environment, err := api.GetEnvironmentByID(ctx, vars["environmentID"])
+	if err != nil {
+		span.RecordError(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	target, err := environment.GetPortForwardTarget(ctx, service, pod, port)
+	if err != nil {
+		span.RecordError(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	ws, err := websocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.WithError(err).Warn("can not upgrade port-forward connection")
+
+		return
+	}
+
+	conn := portforward.NewConn(ws)
+	defer conn.Close()
+
+	if err := environment.PortForward(ctx, target, conn); err != nil {
+		log.WithError(err).Warn("port-forward failed")
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/stream"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const streamHeartbeatInterval = 15 * time.Second
+
+// server-sent events of all environments or one environment.
+func handlerStream(w http.ResponseWriter, r *http.Request) {
+	ctx, span := telemetry.Start(r.Context(), "handlerStream")
+	defer span.End()
+
+	vars := mux.Vars(r)
+
+	telemetry.Attributes(span, vars)
+
+	environmentID := vars["environmentID"]
+
+	if owner := r.Header[config.HeaderOwner]; len(owner) > 0 {
+		log.Infof("user %s request stream %s", owner[0], environmentID)
+	}
+
+	if len(environmentID) > 0 {
+		if _, err := api.GetEnvironmentByID(ctx, environmentID); err != nil {
+			span.RecordError(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+	}
+
+	controller := http.NewResponseController(w)
+
+	// stream is not limited by server write timeout
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.WithError(err).Warn("can not reset write deadline")
+	}
+
+	subscription, replay := stream.Subscribe(environmentID, r.Header.Get("Last-Event-ID"))
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range replay {
+		if err := writeStreamEvent(w, event); err != nil {
+			return
+		}
+	}
+
+	if err := controller.Flush(); err != nil {
+		log.WithError(err).Warn("can not flush stream")
+
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-subscription.Events():
+			// subscription was closed, client will reconnect with Last-Event-ID
+			if !ok {
+				return
+			}
+
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w io.Writer, event *stream.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "can not marshal event")
+	}
+
+	if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+		return errors.Wrap(err, "can not write event")
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package web
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	websocketCloseTimeout = 5 * time.Second
+	// max length of close message reason in websocket protocol.
+	websocketMaxCloseReason = 123
+)
+
+// default CheckOrigin allows only same origin requests.
+var websocketUpgrader = websocket.Upgrader{}
+
+type TerminalMessageType string
+
+const (
+	// server sends session ID after connect.
+	TerminalMessageSession TerminalMessageType = "session"
+	// client sends user input.
+	TerminalMessageStdin TerminalMessageType = "stdin"
+	// client sends terminal size.
+	TerminalMessageResize TerminalMessageType = "resize"
+)
+
+// terminal output is sent in binary messages, other messages are JSON.
+type TerminalMessage struct {
+	Type TerminalMessageType
+	Data string
+	Cols uint16
+	Rows uint16
+}
+
+// writes terminal output to websocket.
+type websocketWriter struct {
+	conn *websocket.Conn
+}
+
+func (w *websocketWriter) Write(p []byte) (int, error) {
+	if err := w.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return len(p), nil
+}
+
+func closeWebsocket(conn *websocket.Conn, err error) {
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+
+	if err != nil {
+		reason := err.Error()
+		if len(reason) > websocketMaxCloseReason {
+			reason = reason[:websocketMaxCloseReason]
+		}
+
+		closeMessage = websocket.FormatCloseMessage(websocket.CloseInternalServerErr, reason)
+	}
+
+	_ = conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(websocketCloseTimeout))
+}
+
+// returns owner of request, http error is written if request has no owner.
+func getRequestOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	owner := r.Header.Get(config.HeaderOwner)
+	if len(owner) == 0 {
+		http.Error(w, errMustHaveOwner.Error(), http.StatusUnauthorized)
+
+		return "", false
+	}
+
+	return owner, true
+}
+
+// interactive shell in container over websocket.
+func handlerTerminal(w http.ResponseWriter, r *http.Request) {
+	ctx, span := telemetry.Start(r.Context(), "handlerTerminal")
+	defer span.End()
+
+	vars := mux.Vars(r)
+
+	telemetry.Attributes(span, vars)
+
+	owner, ok := getRequestOwner(w, r)
+	if !ok {
+		return
+	}
+
+	ctx = context.WithValue(ctx, types.ContextSecurityKey, types.ContextSecurity{Owner: owner})
+
+	container := r.URL.Query().Get("container")
+	if len(container) == 0 {
+		http.Error(w, noContainerSpecified, http.StatusBadRequest)
+
+		return
+	}
+
+	if !config.Get().IsAuthorized(config.PermissionTerminal, owner, vars["environmentID"]) {
+		http.Error(w, "user has no permission to terminal", http.StatusForbidden)
+
+		return
+	}
+
+	environment, err := api.GetEnvironmentByID(ctx, vars["environmentID"])
+	if err != nil {
+		span.RecordError(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	conn, err := websocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.WithError(err).Warn("can not upgrade terminal connection")
+
+		return
+	}
+	defer conn.Close()
+
+	sessionID := api.NewTerminalSessionID()
+
+	if err := conn.WriteJSON(TerminalMessage{Type: TerminalMessageSession, Data: sessionID}); err != nil {
+		log.WithError(err).Warn("can not send terminal session")
+
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stdin, stdinWriter := io.Pipe()
+	defer stdin.Close()
+
+	resize := make(chan api.TerminalSize)
+
+	go func() {
+		// session is closed when client disconnects
+		defer cancel()
+
+		for {
+			message := TerminalMessage{}
+
+			if err := conn.ReadJSON(&message); err != nil {
+				_ = stdinWriter.CloseWithError(err)
+
+				return
+			}
+
+			switch message.Type {
+			case TerminalMessageStdin:
+				if _, err := stdinWriter.Write([]byte(message.Data)); err != nil {
+					return
+				}
+			case TerminalMessageResize:
+				select {
+				case resize <- api.TerminalSize{Cols: message.Cols, Rows: message.Rows}:
+				case <-ctx.Done():
+					return
+				}
+			default:
+				log.Warnf("unknown terminal message %s", message.Type)
+			}
+		}
+	}()
+
+	err = environment.ExecTerminal(ctx, &api.ExecTerminalInput{
+		SessionID: sessionID,
+		Container: container,
+		Stdin:     stdin,
+		Stdout:    &websocketWriter{conn: conn},
+		Resize:    resize,
+	})
+	if err != nil {
+		log.WithError(err).Warn("terminal session failed")
+	}
+
+	closeWebsocket(conn, err)
+}
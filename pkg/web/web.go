@@ -18,7 +18,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"path"
 	"runtime"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -33,7 +36,7 @@ import (
 const (
 	recoveryBufferSize   = 2048
 	serverReadTimeout    = 5 * time.Second
-	serverRequestTimeout = 60 * time.Second
+	serverRequestTimeout = config.RequestTimeoutSeconds * time.Second
 	serverWriteTimeout   = 70 * time.Second
 )
 
@@ -91,7 +94,13 @@ func GetHandler() *mux.Router {
 	mux.HandleFunc("/api/ready", handlerReady)
 	mux.HandleFunc("/api/healthz", handlerHealthz)
 	mux.HandleFunc("/oauth2/userinfo", handlerUser)
+	mux.HandleFunc("/api/gitlab/webhook", handlerGitlabWebhook).Methods(http.MethodPost)
+	mux.HandleFunc("/api/stream", handlerStream).Methods(http.MethodGet)
 	mux.HandleFunc("/api/{operation}", handlerAPI)
+	mux.HandleFunc("/api/{environmentID}/stream", handlerStream).Methods(http.MethodGet)
+	mux.HandleFunc("/api/{environmentID}/pod-container-logs-stream", handlerPodContainerLogsStream).Methods(http.MethodGet)
+	mux.HandleFunc("/api/{environmentID}/terminal", handlerTerminal).Methods(http.MethodGet)
+	mux.HandleFunc("/api/{environmentID}/port-forward", handlerPortForward).Methods(http.MethodGet)
 	mux.HandleFunc("/api/{environmentID}/{operation}", handlerEnvironment)
 
 	// pprof
@@ -114,6 +123,14 @@ func GetHandler() *mux.Router {
 	return mux
 }
 
+// operations that are served without request timeout.
+var longLivedOperations = []string{"stream", "pod-container-logs-stream", "terminal", "port-forward"}
+
+// http.TimeoutHandler does not support http.Flusher, long-lived requests must be served without it.
+func isLongLivedRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/") && slices.Contains(longLivedOperations, path.Base(r.URL.Path))
+}
+
 var parentContext context.Context
 
 func StartServer(ctx context.Context) {
@@ -127,10 +144,19 @@ func StartServer(ctx context.Context) {
 	timeoutMessage := fmt.Sprintf("Server timeout after %s", serverRequestTimeout)
 
 	traceHandler := otelhttp.NewHandler(GetHandler(), "/")
+	timeoutHandler := http.TimeoutHandler(traceHandler, serverRequestTimeout, timeoutMessage)
 
 	server := &http.Server{
-		Addr:         *config.Get().WebListen,
-		Handler:      http.TimeoutHandler(traceHandler, serverRequestTimeout, timeoutMessage),
+		Addr: *config.Get().WebListen,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isLongLivedRequest(r) {
+				traceHandler.ServeHTTP(w, r)
+
+				return
+			}
+
+			timeoutHandler.ServeHTTP(w, r)
+		}),
 		ReadTimeout:  serverReadTimeout,
 		WriteTimeout: serverWriteTimeout,
 	}
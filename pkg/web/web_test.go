@@ -13,15 +13,19 @@ limitations under the License.
 package web_test
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/api"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/stream"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/web"
 )
 
@@ -86,3 +90,126 @@ func TestReplacer(t *testing.T) {
 		}
 	}
 }
+
+func TestGitlabWebhook(t *testing.T) { //nolint:paralleltest
+	config.Get().GitlabWebhook.Token = "test-token"
+	defer func() { config.Get().GitlabWebhook.Token = "" }()
+
+	type test struct {
+		token      string
+		event      string
+		body       string
+		statusCode int
+	}
+
+	tests := []test{
+		{token: "bad-token", event: "Pipeline Hook", body: "{}", statusCode: http.StatusUnauthorized},
+		{token: "test-token", event: "Issue Hook", body: "{}", statusCode: http.StatusOK},
+		{token: "test-token", event: "Pipeline Hook", body: "not-json", statusCode: http.StatusInternalServerError},
+		// pipeline was not created by kubernetes-manager
+		{token: "test-token", event: "Pipeline Hook", body: `{"object_kind":"pipeline","object_attributes":{"id":1,"status":"success"}}`, statusCode: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL+"/api/gitlab/webhook", strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("X-Gitlab-Token", test.token)
+		req.Header.Set("X-Gitlab-Event", test.event)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Fatalf("event=%s,want=%d,got=%d", test.event, test.statusCode, resp.StatusCode)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	first := stream.Publish("test:stream", stream.EventScale, stream.ScaleData{Replicas: 1})
+	last := stream.Publish("test:stream", stream.EventScale, stream.ScaleData{Replicas: 0})
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Last-Event-ID", first.ID)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("wrong content type %s", contentType)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	for scanner.Scan() {
+		if scanner.Text() == "id: "+first.ID {
+			t.Fatal("event before Last-Event-ID must be skipped")
+		}
+
+		if scanner.Text() == "id: "+last.ID {
+			return
+		}
+	}
+
+	t.Fatal("event was not received")
+}
+
+func TestWebsocketAuthorization(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		url        string
+		owner      string
+		statusCode int
+	}
+
+	tests := []test{
+		{url: "/api/test:test/terminal?container=pod:container", owner: "", statusCode: http.StatusUnauthorized},
+		// no authorization rules for user
+		{url: "/api/test:test/terminal?container=pod:container", owner: "test-user", statusCode: http.StatusForbidden},
+		{url: "/api/test:test/port-forward?service=mysql&port=3306", owner: "", statusCode: http.StatusUnauthorized},
+		{url: "/api/test:test/port-forward?service=mysql&port=3306", owner: "test-user", statusCode: http.StatusForbidden},
+		{url: "/api/test:test/port-forward?service=mysql", owner: "test-user", statusCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+test.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(test.owner) > 0 {
+			req.Header.Set(config.HeaderOwner, test.owner)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Fatalf("url=%s,owner=%s,want=%d,got=%d", test.url, test.owner, test.statusCode, resp.StatusCode)
+		}
+	}
+}
@@ -119,7 +119,7 @@ func (provider *Provider) Process(ctx context.Context) error {
 	defer span.End()
 
 	if !provider.validEvent() {
-		log.Warn("this event not supported")
+		log.Debugf("event %s not supported", provider.message.Event)
 
 		return nil
 	}
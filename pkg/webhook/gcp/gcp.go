@@ -0,0 +1,527 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// gcp labels can not contain "/", use same keys as azure tags.
+const (
+	labelNamespace = config.Namespace + "-namespace"
+	labelCluster   = config.Namespace + "-cluster"
+
+	defaultComputeEndpoint  = "https://compute.googleapis.com/compute/v1"
+	defaultSQLAdminEndpoint = "https://sqladmin.googleapis.com/v1"
+	defaultMetadataEndpoint = "http://metadata.google.internal/computeMetadata/v1"
+
+	instanceStatusRunning    = "RUNNING"
+	instanceStatusTerminated = "TERMINATED"
+
+	sqlActivationPolicyAlways = "ALWAYS"
+	sqlActivationPolicyNever  = "NEVER"
+)
+
+type providerConfig struct {
+	ProjectID string
+	// static OAuth2 access token, if empty token will be requested from metadata server
+	AccessToken      string
+	ComputeEndpoint  string
+	SQLAdminEndpoint string
+	MetadataEndpoint string
+	TimeoutSeconds   int
+}
+
+type Provider struct {
+	config    providerConfig
+	condition config.WebHook
+	message   types.WebhookMessage
+}
+
+var httpClient = &http.Client{
+	Jar: nil,
+}
+
+func (provider *Provider) Init(condition config.WebHook, message types.WebhookMessage) error {
+	log.Info("init gcp provider")
+
+	configBytes, err := json.Marshal(condition.Config)
+	if err != nil {
+		return errors.Wrap(err, "invalid condition config")
+	}
+
+	err = json.Unmarshal(configBytes, &provider.config)
+	if err != nil {
+		return errors.Wrap(err, "invalid config")
+	}
+
+	if len(provider.config.ProjectID) == 0 {
+		return errors.New("ProjectID is required")
+	}
+
+	if len(provider.config.ComputeEndpoint) == 0 {
+		provider.config.ComputeEndpoint = defaultComputeEndpoint
+	}
+
+	if len(provider.config.SQLAdminEndpoint) == 0 {
+		provider.config.SQLAdminEndpoint = defaultSQLAdminEndpoint
+	}
+
+	if len(provider.config.MetadataEndpoint) == 0 {
+		provider.config.MetadataEndpoint = defaultMetadataEndpoint
+	}
+
+	if provider.config.TimeoutSeconds == 0 {
+		provider.config.TimeoutSeconds = 60 //nolint:mnd
+	}
+
+	provider.condition = condition
+	provider.message = message
+
+	return nil
+}
+
+func (provider *Provider) validEvent() bool {
+	switch provider.message.Event { //nolint:exhaustive
+	case types.EventStart:
+		return true
+	case types.EventStop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (provider *Provider) Process(ctx context.Context) error {
+	ctx, span := telemetry.Start(ctx, "webhook.gcp.Process")
+	defer span.End()
+
+	if !provider.validEvent() {
+		log.Debugf("event %s not supported", provider.message.Event)
+
+		return nil
+	}
+
+	log.Info("process gcp provider")
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(provider.config.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	token, err := provider.getAccessToken(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting access token")
+	}
+
+	processInstances := make(chan error)
+	processDatabases := make(chan error)
+
+	go func() {
+		processInstances <- provider.processInstances(ctx, token)
+	}()
+
+	go func() {
+		processDatabases <- provider.processDatabases(ctx, token)
+	}()
+
+	type Result struct {
+		ErrProcessInstances string
+		ErrProcessDatases   string
+	}
+
+	result := Result{}
+	hasError := false
+
+	err = <-processInstances
+	if err != nil {
+		hasError = true
+		result.ErrProcessInstances = err.Error()
+	}
+
+	err = <-processDatabases
+	if err != nil {
+		hasError = true
+		result.ErrProcessDatases = err.Error()
+	}
+
+	if hasError {
+		resultText, err := json.Marshal(result)
+		if err != nil {
+			log.WithError(err).Error("error while marshaling result")
+		}
+
+		return errors.New(string(resultText))
+	}
+
+	return nil
+}
+
+// get access token from config or from metadata server (GCE, GKE Workload Identity).
+func (provider *Provider) getAccessToken(ctx context.Context) (string, error) {
+	ctx, span := telemetry.Start(ctx, "webhook.gcp.getAccessToken")
+	defer span.End()
+
+	if len(provider.config.AccessToken) > 0 {
+		return provider.config.AccessToken, nil
+	}
+
+	tokenURL := provider.config.MetadataEndpoint + "/instance/service-accounts/default/token"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "error creating request")
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	type tokenResponse struct {
+		AccessToken string `json:"access_token"` //nolint:tagliatelle
+	}
+
+	token := tokenResponse{}
+
+	if err := provider.doRequest(req, &token); err != nil {
+		return "", errors.Wrap(err, "error getting token from metadata server")
+	}
+
+	if len(token.AccessToken) == 0 {
+		return "", errors.New("empty access token")
+	}
+
+	return token.AccessToken, nil
+}
+
+type computeInstance struct {
+	Name   string            `json:"name"`
+	Zone   string            `json:"zone"`
+	Status string            `json:"status"`
+	Labels map[string]string `json:"labels"`
+}
+
+type computeAggregatedList struct {
+	Items map[string]struct {
+		Instances []*computeInstance `json:"instances"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func (provider *Provider) listInstances(ctx context.Context, token string) ([]*computeInstance, error) {
+	ctx, span := telemetry.Start(ctx, "webhook.gcp.listInstances")
+	defer span.End()
+
+	result := make([]*computeInstance, 0)
+	pageToken := ""
+
+	for ctx.Err() == nil {
+		query := url.Values{}
+		query.Set("filter", provider.getLabelFilter("labels"))
+
+		if len(pageToken) > 0 {
+			query.Set("pageToken", pageToken)
+		}
+
+		listURL := fmt.Sprintf("%s/projects/%s/aggregated/instances?%s",
+			provider.config.ComputeEndpoint,
+			provider.config.ProjectID,
+			query.Encode(),
+		)
+
+		req, err := provider.newRequest(ctx, http.MethodGet, listURL, token, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating request")
+		}
+
+		page := computeAggregatedList{}
+
+		if err := provider.doRequest(req, &page); err != nil {
+			return nil, errors.Wrap(err, "error listing instances")
+		}
+
+		for _, scope := range page.Items {
+			for _, instance := range scope.Instances {
+				// api filter can be ignored, check labels again
+				if provider.matchLabels(instance.Labels) {
+					result = append(result, instance)
+				}
+			}
+		}
+
+		if len(page.NextPageToken) == 0 {
+			break
+		}
+
+		pageToken = page.NextPageToken
+	}
+
+	return result, nil
+}
+
+func (provider *Provider) processInstances(ctx context.Context, token string) error {
+	ctx, span := telemetry.Start(ctx, "webhook.gcp.processInstances")
+	defer span.End()
+
+	instances, err := provider.listInstances(ctx, token)
+	if err != nil {
+		return errors.Wrap(err, "error while getting instances")
+	}
+
+	if len(instances) == 0 {
+		log.Warnf("no instances found for %s:%s", provider.message.Cluster, provider.message.Namespace)
+
+		return nil
+	}
+
+	for _, instance := range instances {
+		// zone is full url to zone resource
+		zone := path.Base(instance.Zone)
+
+		var action string
+
+		switch provider.message.Event { //nolint:exhaustive
+		case types.EventStart:
+			if instance.Status != instanceStatusTerminated {
+				log.Warnf("instance %s has invalid status=%s to start", instance.Name, instance.Status)
+
+				continue
+			}
+
+			action = "start"
+		case types.EventStop:
+			if instance.Status != instanceStatusRunning {
+				log.Warnf("instance %s has invalid status=%s to stop", instance.Name, instance.Status)
+
+				continue
+			}
+
+			action = "stop"
+		default:
+			log.Warn("unknown event " + provider.message.Event)
+
+			continue
+		}
+
+		actionURL := fmt.Sprintf("%s/projects/%s/zones/%s/instances/%s/%s",
+			provider.config.ComputeEndpoint,
+			provider.config.ProjectID,
+			zone,
+			instance.Name,
+			action,
+		)
+
+		req, err := provider.newRequest(ctx, http.MethodPost, actionURL, token, nil)
+		if err != nil {
+			return errors.Wrap(err, "error creating request")
+		}
+
+		if err := provider.doRequest(req, nil); err != nil {
+			return errors.Wrapf(err, "error while %s instance %s", action, instance.Name)
+		}
+
+		log.Infof("instance %s/%s %s", zone, instance.Name, action)
+	}
+
+	return nil
+}
+
+type sqlInstance struct {
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Settings struct {
+		ActivationPolicy string            `json:"activationPolicy"`
+		UserLabels       map[string]string `json:"userLabels"`
+	} `json:"settings"`
+}
+
+type sqlInstancesList struct {
+	Items         []*sqlInstance `json:"items"`
+	NextPageToken string         `json:"nextPageToken"`
+}
+
+func (provider *Provider) listDatabases(ctx context.Context, token string) ([]*sqlInstance, error) {
+	ctx, span := telemetry.Start(ctx, "webhook.gcp.listDatabases")
+	defer span.End()
+
+	result := make([]*sqlInstance, 0)
+	pageToken := ""
+
+	for ctx.Err() == nil {
+		query := url.Values{}
+		query.Set("filter", provider.getLabelFilter("settings.userLabels"))
+
+		if len(pageToken) > 0 {
+			query.Set("pageToken", pageToken)
+		}
+
+		listURL := fmt.Sprintf("%s/projects/%s/instances?%s",
+			provider.config.SQLAdminEndpoint,
+			provider.config.ProjectID,
+			query.Encode(),
+		)
+
+		req, err := provider.newRequest(ctx, http.MethodGet, listURL, token, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating request")
+		}
+
+		page := sqlInstancesList{}
+
+		if err := provider.doRequest(req, &page); err != nil {
+			return nil, errors.Wrap(err, "error listing databases")
+		}
+
+		for _, instance := range page.Items {
+			if provider.matchLabels(instance.Settings.UserLabels) {
+				result = append(result, instance)
+			}
+		}
+
+		if len(page.NextPageToken) == 0 {
+			break
+		}
+
+		pageToken = page.NextPageToken
+	}
+
+	return result, nil
+}
+
+func (provider *Provider) processDatabases(ctx context.Context, token string) error {
+	ctx, span := telemetry.Start(ctx, "webhook.gcp.processDatabases")
+	defer span.End()
+
+	databases, err := provider.listDatabases(ctx, token)
+	if err != nil {
+		return errors.Wrap(err, "error getting databases")
+	}
+
+	for _, database := range databases {
+		var activationPolicy string
+
+		// Cloud SQL instances are started and stopped by changing activation policy
+		switch provider.message.Event { //nolint:exhaustive
+		case types.EventStart:
+			if database.Settings.ActivationPolicy != sqlActivationPolicyNever {
+				log.Warnf("database %s has invalid activationPolicy=%s to start", database.Name, database.Settings.ActivationPolicy)
+
+				continue
+			}
+
+			activationPolicy = sqlActivationPolicyAlways
+		case types.EventStop:
+			if database.Settings.ActivationPolicy != sqlActivationPolicyAlways {
+				log.Warnf("database %s has invalid activationPolicy=%s to stop", database.Name, database.Settings.ActivationPolicy)
+
+				continue
+			}
+
+			activationPolicy = sqlActivationPolicyNever
+		default:
+			log.Warn("unknown event " + provider.message.Event)
+
+			continue
+		}
+
+		patchURL := fmt.Sprintf("%s/projects/%s/instances/%s",
+			provider.config.SQLAdminEndpoint,
+			provider.config.ProjectID,
+			database.Name,
+		)
+
+		body := fmt.Sprintf(`{"settings":{"activationPolicy":%q}}`, activationPolicy)
+
+		req, err := provider.newRequest(ctx, http.MethodPatch, patchURL, token, strings.NewReader(body))
+		if err != nil {
+			return errors.Wrap(err, "error creating request")
+		}
+
+		if err := provider.doRequest(req, nil); err != nil {
+			return errors.Wrapf(err, "error while patching database %s", database.Name)
+		}
+
+		log.Infof("database %s activationPolicy=%s", database.Name, activationPolicy)
+	}
+
+	return nil
+}
+
+func (provider *Provider) getLabelFilter(prefix string) string {
+	return fmt.Sprintf(`%s.%s="%s" AND %s.%s="%s"`,
+		prefix, labelNamespace, provider.message.Namespace,
+		prefix, labelCluster, provider.message.Cluster,
+	)
+}
+
+func (provider *Provider) matchLabels(labels map[string]string) bool {
+	if labels == nil {
+		return false
+	}
+
+	return labels[labelNamespace] == provider.message.Namespace && labels[labelCluster] == provider.message.Cluster
+}
+
+func (provider *Provider) newRequest(ctx context.Context, method, requestURL, token string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating request")
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+func (provider *Provider) doRequest(req *http.Request, result any) error {
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "error sending request")
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(err, "error reading response")
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("status code %d: %s", res.StatusCode, string(bytes.TrimSpace(body)))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return errors.Wrap(err, "error parsing response")
+	}
+
+	return nil
+}
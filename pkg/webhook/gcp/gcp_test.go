@@ -0,0 +1,267 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gcp_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/webhook/gcp"
+)
+
+const testToken = "test-token"
+
+type fakeAPI struct {
+	lock  sync.Mutex
+	calls []string
+}
+
+func (f *fakeAPI) addCall(call string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeAPI) getCalls() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return slices.Clone(f.calls)
+}
+
+func testHandler(t *testing.T, api *fakeAPI) *mux.Router {
+	t.Helper()
+
+	router := mux.NewRouter()
+
+	checkToken := func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			t.Errorf("bad token: %s", r.Header.Get("Authorization"))
+
+			return false
+		}
+
+		return true
+	}
+
+	router.HandleFunc("/metadata/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"access_token":"` + testToken + `","expires_in":3599,"token_type":"Bearer"}`))
+	})
+
+	router.HandleFunc("/compute/projects/test-project/aggregated/instances", func(w http.ResponseWriter, r *http.Request) {
+		if !checkToken(w, r) {
+			return
+		}
+
+		if r.URL.Query().Get("filter") == "" {
+			t.Error("filter is empty")
+		}
+
+		_, _ = w.Write([]byte(`{
+	"items": {
+		"zones/europe-west1-b": {
+			"instances": [
+				{
+					"name": "vm-stopped",
+					"zone": "https://www.googleapis.com/compute/v1/projects/test-project/zones/europe-west1-b",
+					"status": "TERMINATED",
+					"labels": {"kubernetes-manager-namespace": "test-namespace", "kubernetes-manager-cluster": "test-cluster"}
+				},
+				{
+					"name": "vm-running",
+					"zone": "https://www.googleapis.com/compute/v1/projects/test-project/zones/europe-west1-b",
+					"status": "RUNNING",
+					"labels": {"kubernetes-manager-namespace": "test-namespace", "kubernetes-manager-cluster": "test-cluster"}
+				},
+				{
+					"name": "vm-other",
+					"zone": "https://www.googleapis.com/compute/v1/projects/test-project/zones/europe-west1-b",
+					"status": "TERMINATED",
+					"labels": {"kubernetes-manager-namespace": "other", "kubernetes-manager-cluster": "test-cluster"}
+				}
+			]
+		},
+		"zones/us-east1-b": {
+			"warning": {"code": "NO_RESULTS_ON_PAGE"}
+		}
+	}
+}`))
+	})
+
+	router.HandleFunc("/compute/projects/test-project/zones/{zone}/instances/{name}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		if !checkToken(w, r) {
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		vars := mux.Vars(r)
+
+		api.addCall(vars["action"] + ":" + vars["zone"] + "/" + vars["name"])
+
+		_, _ = w.Write([]byte(`{"kind":"compute#operation","status":"RUNNING"}`))
+	})
+
+	router.HandleFunc("/sql/projects/test-project/instances", func(w http.ResponseWriter, r *http.Request) {
+		if !checkToken(w, r) {
+			return
+		}
+
+		_, _ = w.Write([]byte(`{
+	"items": [
+		{
+			"name": "db-stopped",
+			"state": "RUNNABLE",
+			"settings": {
+				"activationPolicy": "NEVER",
+				"userLabels": {"kubernetes-manager-namespace": "test-namespace", "kubernetes-manager-cluster": "test-cluster"}
+			}
+		},
+		{
+			"name": "db-running",
+			"state": "RUNNABLE",
+			"settings": {
+				"activationPolicy": "ALWAYS",
+				"userLabels": {"kubernetes-manager-namespace": "test-namespace", "kubernetes-manager-cluster": "test-cluster"}
+			}
+		}
+	]
+}`))
+	})
+
+	router.HandleFunc("/sql/projects/test-project/instances/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if !checkToken(w, r) {
+			return
+		}
+
+		if r.Method != http.MethodPatch {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		patch := struct {
+			Settings struct {
+				ActivationPolicy string `json:"activationPolicy"`
+			} `json:"settings"`
+		}{}
+
+		if err := json.Unmarshal(body, &patch); err != nil {
+			t.Error(err)
+		}
+
+		api.addCall("patch:" + mux.Vars(r)["name"] + "=" + patch.Settings.ActivationPolicy)
+
+		_, _ = w.Write([]byte(`{"kind":"sql#operation","status":"PENDING"}`))
+	})
+
+	return router
+}
+
+func TestGCP(t *testing.T) {
+	t.Parallel()
+
+	tests := map[types.Event][]string{
+		types.EventStart: {
+			"start:europe-west1-b/vm-stopped",
+			"patch:db-stopped=ALWAYS",
+		},
+		types.EventStop: {
+			"stop:europe-west1-b/vm-running",
+			"patch:db-running=NEVER",
+		},
+		types.EventPrestop: {},
+	}
+
+	for event, want := range tests {
+		t.Run(string(event), func(t *testing.T) {
+			t.Parallel()
+
+			api := &fakeAPI{}
+
+			ts := httptest.NewServer(testHandler(t, api))
+			defer ts.Close()
+
+			condition := config.WebHook{
+				Provider: "gcp",
+				Config: map[string]any{
+					"ProjectID":        "test-project",
+					"ComputeEndpoint":  ts.URL + "/compute",
+					"SQLAdminEndpoint": ts.URL + "/sql",
+					"MetadataEndpoint": ts.URL + "/metadata",
+				},
+			}
+
+			message := types.WebhookMessage{
+				Event:     event,
+				Cluster:   "test-cluster",
+				Namespace: "test-namespace",
+			}
+
+			provider := gcp.Provider{}
+
+			if err := provider.Init(condition, message); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := provider.Process(t.Context()); err != nil {
+				t.Fatal(err)
+			}
+
+			calls := api.getCalls()
+
+			slices.Sort(calls)
+			slices.Sort(want)
+
+			if !slices.Equal(calls, want) {
+				t.Fatalf("want=%v,got=%v", want, calls)
+			}
+		})
+	}
+}
+
+func TestGCPInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	provider := gcp.Provider{}
+
+	err := provider.Init(config.WebHook{Config: map[string]any{}}, types.WebhookMessage{})
+	if err == nil {
+		t.Fatal("must be error without ProjectID")
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright paskal.maksim@gmail.com
+Licensed under the Apache License, Version 2.0 (the "License")
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package httpcall
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type PayloadFormat string
+
+const (
+	// generic JSON of types.WebhookMessage.
+	PayloadFormatJSON PayloadFormat = "json"
+	// CloudEvents v1.0 structured mode.
+	PayloadFormatCloudEvents PayloadFormat = "cloudevents"
+	// Microsoft Teams MessageCard.
+	PayloadFormatTeams PayloadFormat = "teams"
+)
+
+const (
+	contentTypeJSON        = "application/json"
+	contentTypeCloudEvents = "application/cloudevents+json"
+
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsIDLength    = 16
+
+	teamsThemeColor = "0076D7"
+)
+
+func (f PayloadFormat) Validate() error {
+	switch f {
+	case "", PayloadFormatJSON, PayloadFormatCloudEvents, PayloadFormatTeams:
+		return nil
+	default:
+		return errors.Errorf("unknown format %s", f)
+	}
+}
+
+// returns payload and content type.
+func (f PayloadFormat) Payload(message types.WebhookMessage) ([]byte, string, error) {
+	var (
+		payload     any
+		contentType = contentTypeJSON
+	)
+
+	switch f { //nolint:exhaustive
+	case PayloadFormatJSON:
+		payload = message
+	case PayloadFormatCloudEvents:
+		payload = newCloudEvent(message)
+		contentType = contentTypeCloudEvents
+	case PayloadFormatTeams:
+		payload = newTeamsMessageCard(message)
+	default:
+		return nil, "", errors.Errorf("unknown format %s", f)
+	}
+
+	result, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "error marshaling payload")
+	}
+
+	return result, contentType, nil
+}
+
+type cloudEvent struct {
+	SpecVersion     string               `json:"specversion"`
+	Type            string               `json:"type"`
+	Source          string               `json:"source"`
+	Subject         string               `json:"subject"`
+	ID              string               `json:"id"`
+	Time            string               `json:"time"`
+	DataContentType string               `json:"datacontenttype"`
+	Data            types.WebhookMessage `json:"data"`
+}
+
+func newCloudEvent(message types.WebhookMessage) *cloudEvent {
+	return &cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		Type:            fmt.Sprintf("com.%s.%s", config.Namespace, message.Event),
+		Source:          fmt.Sprintf("%s/%s", config.Namespace, message.Cluster),
+		Subject:         message.Namespace,
+		ID:              utils.RandomString(cloudEventsIDLength),
+		Time:            time.Now().UTC().Format(time.RFC3339),
+		DataContentType: contentTypeJSON,
+		Data:            message,
+	}
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type teamsSection struct {
+	Facts []teamsFact `json:"facts"`
+}
+
+type teamsMessageCard struct {
+	Type       string         `json:"@type"`
+	Context    string         `json:"@context"`
+	ThemeColor string         `json:"themeColor"`
+	Summary    string         `json:"summary"`
+	Title      string         `json:"title"`
+	Text       string         `json:"text"`
+	Sections   []teamsSection `json:"sections"`
+}
+
+func newTeamsMessageCard(message types.WebhookMessage) *teamsMessageCard {
+	facts := []teamsFact{
+		{Name: "Event", Value: string(message.Event)},
+		{Name: "Cluster", Value: message.Cluster},
+		{Name: "Namespace", Value: message.Namespace},
+	}
+
+	keys := make([]string, 0, len(message.Properties))
+	for key := range message.Properties {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		facts = append(facts, teamsFact{Name: key, Value: message.Properties[key]})
+	}
+
+	title := message.Name
+	if len(title) == 0 {
+		title = message.Namespace
+	}
+
+	return &teamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: teamsThemeColor,
+		Summary:    fmt.Sprintf("%s %s", title, message.Event),
+		Title:      title,
+		Text:       message.Reason,
+		Sections:   []teamsSection{{Facts: facts}},
+	}
+}
@@ -15,8 +15,13 @@ package httpcall
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
@@ -27,12 +32,34 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	DefaultSignatureHeader = "X-Kubernetes-Manager-Signature"
+	DefaultTimestampHeader = "X-Kubernetes-Manager-Timestamp"
+
+	signaturePrefix = "sha256="
+
+	defaultMaxRetries = 3
+	defaultRetryDelay = time.Second
+)
+
 type ProviderConfig struct {
 	URL     string
 	Method  string
 	Headers map[string]string
 	Body    string
 	Timeout time.Duration
+	// built-in payload format, if empty Body template will be used
+	Format PayloadFormat
+	// secret to sign request body with HMAC-SHA256
+	Secret          string
+	SignatureHeader string
+	TimestampHeader string
+	// status codes that means request was delivered, default 200
+	SuccessStatusCodes []int
+	// status codes to retry request
+	RetryOnStatus []int
+	MaxRetries    int
+	RetryDelay    time.Duration
 }
 
 type Provider struct {
@@ -66,6 +93,30 @@ func (provider *Provider) Init(condition config.WebHook, message types.WebhookMe
 		provider.Config.Method = http.MethodPost
 	}
 
+	if err := provider.Config.Format.Validate(); err != nil {
+		return errors.Wrap(err, "invalid format")
+	}
+
+	if len(provider.Config.SignatureHeader) == 0 {
+		provider.Config.SignatureHeader = DefaultSignatureHeader
+	}
+
+	if len(provider.Config.TimestampHeader) == 0 {
+		provider.Config.TimestampHeader = DefaultTimestampHeader
+	}
+
+	if len(provider.Config.SuccessStatusCodes) == 0 {
+		provider.Config.SuccessStatusCodes = []int{http.StatusOK}
+	}
+
+	if len(provider.Config.RetryOnStatus) > 0 && provider.Config.MaxRetries == 0 {
+		provider.Config.MaxRetries = defaultMaxRetries
+	}
+
+	if provider.Config.RetryDelay == 0 {
+		provider.Config.RetryDelay = defaultRetryDelay
+	}
+
 	provider.Condition = condition
 	provider.Message = message
 
@@ -81,34 +132,97 @@ func (provider *Provider) Process(ctx context.Context) error {
 
 	log.Info("process notify provider")
 
+	result, contentType, err := provider.getPayload(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting payload")
+	}
+
+	for attempt := 0; ; attempt++ {
+		statusCode, err := provider.send(ctx, result, contentType)
+		if err != nil {
+			return errors.Wrap(err, "error sending request")
+		}
+
+		if slices.Contains(provider.Config.SuccessStatusCodes, statusCode) {
+			return nil
+		}
+
+		if attempt >= provider.Config.MaxRetries || !slices.Contains(provider.Config.RetryOnStatus, statusCode) {
+			return errors.Errorf("error sending request, status code: %d", statusCode)
+		}
+
+		log.Warnf("retry request, status code: %d, attempt: %d", statusCode, attempt+1)
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "error waiting retry")
+		case <-time.After(provider.Config.RetryDelay):
+		}
+	}
+}
+
+func (provider *Provider) getPayload(ctx context.Context) ([]byte, string, error) {
+	if len(provider.Config.Format) > 0 {
+		return provider.Config.Format.Payload(provider.Message)
+	}
+
 	result, err := utils.GetTemplatedResult(ctx, provider.Config.Body, provider)
 	if err != nil {
-		return errors.Wrap(err, "error templating body")
+		return nil, "", errors.Wrap(err, "error templating body")
 	}
 
+	return result, "", nil
+}
+
+// send request and return response status code.
+func (provider *Provider) send(ctx context.Context, body []byte, contentType string) (int, error) {
 	req, err := http.NewRequestWithContext(ctx,
 		provider.Config.Method,
 		provider.Config.URL,
-		bytes.NewBuffer(result),
+		bytes.NewBuffer(body),
 	)
 	if err != nil {
-		return errors.Wrap(err, "error creating request")
+		return 0, errors.Wrap(err, "error creating request")
+	}
+
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
 	}
 
 	for key, value := range provider.Config.Headers {
 		req.Header.Set(key, value)
 	}
 
+	if len(provider.Config.Secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		req.Header.Set(provider.Config.TimestampHeader, timestamp)
+		req.Header.Set(provider.Config.SignatureHeader, Sign(provider.Config.Secret, timestamp, body))
+	}
+
 	res, err := httpClient.Do(req)
 	if err != nil {
-		return errors.Wrap(err, "error sending request")
+		return 0, errors.Wrap(err, "error sending request")
 	}
 
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return errors.Errorf("error sending request, status code: %d", res.StatusCode)
-	}
+	return res.StatusCode, nil
+}
 
-	return nil
+// Sign returns signature of request, receiver must calculate
+// HMAC-SHA256 of "<timestamp>.<body>" with shared secret and compare it with signature header.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks signature of request.
+func VerifySignature(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
 }
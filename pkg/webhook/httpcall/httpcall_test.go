@@ -13,11 +13,14 @@ limitations under the License.
 package httpcall_test
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
@@ -108,3 +111,172 @@ func TestNotify(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestSignedRequest(t *testing.T) {
+	t.Parallel()
+
+	const secret = "some-secret"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		timestamp := r.Header.Get(httpcall.DefaultTimestampHeader)
+		signature := r.Header.Get(httpcall.DefaultSignatureHeader)
+
+		if len(timestamp) == 0 {
+			t.Error("no timestamp header")
+		}
+
+		if !httpcall.VerifySignature(secret, timestamp, body, signature) {
+			t.Errorf("bad signature: %s", signature)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if httpcall.VerifySignature("bad-secret", timestamp, body, signature) {
+			t.Error("signature must be invalid with another secret")
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	provider := httpcall.Provider{}
+
+	conditions := config.WebHook{
+		Config: httpcall.ProviderConfig{
+			URL:                ts.URL,
+			Format:             httpcall.PayloadFormatJSON,
+			Secret:             secret,
+			SuccessStatusCodes: []int{http.StatusAccepted},
+		},
+	}
+
+	if err := provider.Init(conditions, types.WebhookMessage{Event: types.EventStart}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.Process(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPayloadFormats(t *testing.T) {
+	t.Parallel()
+
+	message := types.WebhookMessage{
+		Event:      types.EventStop,
+		Name:       "testName",
+		Cluster:    "testCluster",
+		Namespace:  "testNamespace",
+		Reason:     "testReason",
+		Properties: map[string]string{"key": "value"},
+	}
+
+	tests := map[httpcall.PayloadFormat]string{
+		httpcall.PayloadFormatJSON:        "application/json",
+		httpcall.PayloadFormatCloudEvents: "application/cloudevents+json",
+		httpcall.PayloadFormatTeams:       "application/json",
+	}
+
+	for format, wantContentType := range tests {
+		payload, contentType, err := format.Payload(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if contentType != wantContentType {
+			t.Fatalf("%s: want=%s,got=%s", format, wantContentType, contentType)
+		}
+
+		result := make(map[string]any)
+
+		if err := json.Unmarshal(payload, &result); err != nil {
+			t.Fatal(err)
+		}
+
+		switch format { //nolint:exhaustive
+		case httpcall.PayloadFormatJSON:
+			if result["Namespace"] != message.Namespace {
+				t.Fatalf("bad payload %s", string(payload))
+			}
+		case httpcall.PayloadFormatCloudEvents:
+			if result["specversion"] != "1.0" || result["type"] != "com.kubernetes-manager.stop" {
+				t.Fatalf("bad payload %s", string(payload))
+			}
+		case httpcall.PayloadFormatTeams:
+			if result["@type"] != "MessageCard" || result["text"] != message.Reason {
+				t.Fatalf("bad payload %s", string(payload))
+			}
+		}
+	}
+
+	if err := httpcall.PayloadFormat("unknown").Validate(); err == nil {
+		t.Fatal("unknown format must be invalid")
+	}
+}
+
+func TestRetryOnStatus(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	conditions := config.WebHook{
+		Config: httpcall.ProviderConfig{
+			URL:           ts.URL,
+			Format:        httpcall.PayloadFormatJSON,
+			RetryOnStatus: []int{http.StatusServiceUnavailable},
+			RetryDelay:    10 * time.Millisecond,
+		},
+	}
+
+	provider := httpcall.Provider{}
+
+	if err := provider.Init(conditions, types.WebhookMessage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.Process(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("want 3 requests, got %d", got)
+	}
+
+	// status not in RetryOnStatus must fail without retries
+	requests.Store(0)
+
+	conditions.Config = httpcall.ProviderConfig{
+		URL:           ts.URL,
+		Format:        httpcall.PayloadFormatJSON,
+		RetryOnStatus: []int{http.StatusTooManyRequests},
+	}
+
+	if err := provider.Init(conditions, types.WebhookMessage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.Process(t.Context()); err == nil {
+		t.Fatal("must be error")
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("want 1 request, got %d", got)
+	}
+}
@@ -17,10 +17,12 @@ import (
 	"slices"
 
 	"github.com/maksim-paskal/kubernetes-manager/pkg/config"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/stream"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/telemetry"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/types"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/webhook/aws"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/webhook/azure"
+	"github.com/maksim-paskal/kubernetes-manager/pkg/webhook/gcp"
 	"github.com/maksim-paskal/kubernetes-manager/pkg/webhook/httpcall"
 	"github.com/pkg/errors"
 )
@@ -36,10 +38,28 @@ func NewEvent(ctx context.Context, message types.WebhookMessage) error {
 	ctx, span := telemetry.Start(ctx, "webhook.NewEvent")
 	defer span.End()
 
-	for _, condition := range config.Get().WebHooks {
-		id := message.Cluster + ":" + message.Namespace
+	err := processEvents(ctx, message)
+
+	// send webhook result to environment stream
+	data := stream.WebhookData{
+		Event:      message.Event,
+		Reason:     message.Reason,
+		Properties: message.Properties,
+	}
+
+	if err != nil {
+		data.Error = err.Error()
+	}
+
+	stream.Publish(message.ID(), stream.EventWebhook, data)
 
-		if slices.Contains(condition.IDs, id) {
+	return err
+}
+
+// process event in all webhooks with matched conditions.
+func processEvents(ctx context.Context, message types.WebhookMessage) error {
+	for _, condition := range config.Get().WebHooks {
+		if slices.Contains(condition.IDs, message.ID()) {
 			if len(condition.Events) == 0 || slices.Contains(condition.Events, message.Event) {
 				err := processEvent(ctx, condition, message)
 				if err != nil {
@@ -94,6 +114,8 @@ func NewProvider(provider string) (Provider, error) {
 		return new(aws.Provider), nil
 	case "azure":
 		return new(azure.Provider), nil
+	case "gcp":
+		return new(gcp.Provider), nil
 	case "httpcall":
 		return new(httpcall.Provider), nil
 	default: